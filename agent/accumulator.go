@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
@@ -18,6 +19,12 @@ type MetricMaker interface {
 	MakeMetric(metric telegraf.Metric) telegraf.Metric
 }
 
+// errorRecorder is implemented by MetricMakers that keep track of the errors
+// added to their accumulator.
+type errorRecorder interface {
+	SetLastError(err error)
+}
+
 type accumulator struct {
 	maker     MetricMaker
 	metrics   chan<- telegraf.Metric
@@ -82,7 +89,7 @@ func (ac *accumulator) AddHistogram(
 }
 
 func (ac *accumulator) AddMetric(m telegraf.Metric) {
-	m.SetTime(m.Time().Round(ac.precision))
+	m.SetTime(internal.RoundTime(m.Time(), ac.precision))
 	if m := ac.maker.MakeMetric(m); m != nil {
 		ac.metrics <- m
 	}
@@ -111,6 +118,9 @@ func (ac *accumulator) AddError(err error) {
 		return
 	}
 	NErrors.Incr(1)
+	if r, ok := ac.maker.(errorRecorder); ok {
+		r.SetLastError(err)
+	}
 	log.Printf("E! [%s] Error in plugin: %v", ac.maker.LogName(), err)
 }
 
@@ -125,7 +135,7 @@ func (ac *accumulator) getTime(t []time.Time) time.Time {
 	} else {
 		timestamp = time.Now()
 	}
-	return timestamp.Round(ac.precision)
+	return internal.RoundTime(timestamp, ac.precision)
 }
 
 func (ac *accumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
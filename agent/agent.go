@@ -15,15 +15,68 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
+// startupRetryInterval is the time between attempts to start a service input
+// whose startup_error_behavior is "retry".
+var startupRetryInterval = 15 * time.Second
+
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// The fields below track the plugins of a running agent so that they can
+	// be replaced individually by Reload.
+	reloadMu        sync.Mutex
+	inputsMu        sync.RWMutex
+	processorsMu    sync.RWMutex
+	aggProcessorsMu sync.RWMutex
+	aggregatorMu    sync.RWMutex
+	outputsMu       sync.RWMutex
+
+	runCtx            context.Context
+	startTime         time.Time
+	inputDst          chan<- telegraf.Metric
+	inputs            map[*models.RunningInput]*pluginUnit
+	processorChain    *processorChain
+	aggProcessorChain *processorChain
+	aggregateCtx      context.Context
+	aggregateDst      chan<- telegraf.Metric
+	aggregators       map[*models.RunningAggregator]*pluginUnit
+	outputCtx         context.Context
+	outputs           map[*models.RunningOutput]*pluginUnit
+	routing           *outputRouting
+}
+
+// pluginUnit controls the goroutine running a single plugin.
+type pluginUnit struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// flush requests an immediate write of an output.
+	flush chan struct{}
+}
+
+func newPluginUnit(ctx context.Context) (context.Context, *pluginUnit) {
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, &pluginUnit{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		flush:  make(chan struct{}, 1),
+	}
+}
+
+// stop cancels the plugin and waits for its goroutine to return.
+func (u *pluginUnit) stop() {
+	u.cancel()
+	<-u.done
 }
 
 // NewAgent returns an Agent for the given Config.
 func NewAgent(config *config.Config) (*Agent, error) {
 	a := &Agent{
-		Config: config,
+		Config:      config,
+		inputs:      make(map[*models.RunningInput]*pluginUnit),
+		aggregators: make(map[*models.RunningAggregator]*pluginUnit),
+		outputs:     make(map[*models.RunningOutput]*pluginUnit),
 	}
 	return a, nil
 }
@@ -57,12 +110,23 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	startTime := time.Now()
 
+	log.Printf("D! [agent] Starting processors")
+	err = a.startProcessors(procC, outputC)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("D! [agent] Starting service inputs")
 	err = a.startServiceInputs(ctx, inputC)
 	if err != nil {
+		a.stopProcessors()
 		return err
 	}
 
+	// The plugins started, the remote configurations are kept as the last
+	// good copies.
+	a.Config.AcceptRemoteConfigs()
+
 	var wg sync.WaitGroup
 
 	src := inputC
@@ -86,41 +150,38 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	src = dst
 
-	if len(a.Config.Processors) > 0 {
-		dst = procC
+	// The processor and aggregator stages are always started, even without
+	// any plugins, so that plugins can be added on reload.
+	dst = procC
 
-		wg.Add(1)
-		go func(src, dst chan telegraf.Metric) {
-			defer wg.Done()
-
-			err := a.runProcessors(src, dst)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-			close(dst)
-			log.Printf("D! [agent] Processor channel closed")
-		}(src, dst)
+	wg.Add(1)
+	go func(src, dst chan telegraf.Metric) {
+		defer wg.Done()
 
-		src = dst
-	}
+		err := a.runProcessors(src)
+		if err != nil {
+			log.Printf("E! [agent] Error running processors: %v", err)
+		}
+		close(dst)
+		log.Printf("D! [agent] Processor channel closed")
+	}(src, dst)
 
-	if len(a.Config.Aggregators) > 0 {
-		dst = outputC
+	src = dst
+	dst = outputC
 
-		wg.Add(1)
-		go func(src, dst chan telegraf.Metric) {
-			defer wg.Done()
+	wg.Add(1)
+	go func(src, dst chan telegraf.Metric) {
+		defer wg.Done()
 
-			err := a.runAggregators(startTime, src, dst)
-			if err != nil {
-				log.Printf("E! [agent] Error running aggregators: %v", err)
-			}
-			close(dst)
-			log.Printf("D! [agent] Output channel closed")
-		}(src, dst)
+		err := a.runAggregators(startTime, src, dst)
+		if err != nil {
+			log.Printf("E! [agent] Error running aggregators: %v", err)
+		}
+		close(dst)
+		log.Printf("D! [agent] Output channel closed")
+	}(src, dst)
 
-		src = dst
-	}
+	src = dst
 
 	wg.Add(1)
 	go func(src chan telegraf.Metric) {
@@ -204,15 +265,19 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 			break
 		}
 
+		if !input.Started() {
+			continue
+		}
+
 		acc := NewAccumulator(input, metricC)
-		acc.SetPrecision(a.Precision())
+		acc.SetPrecision(a.inputPrecision(input))
 
 		// Special instructions for some inputs. cpu, for example, needs to be
 		// run twice in order to return cpu usage percentages.
 		switch input.Config.Name {
 		case "cpu", "mongodb", "procstat":
 			nulAcc := NewAccumulator(input, nulC)
-			nulAcc.SetPrecision(a.Precision())
+			nulAcc.SetPrecision(a.inputPrecision(input))
 			if err := input.Input.Gather(nulAcc); err != nil {
 				acc.AddError(err)
 			}
@@ -250,67 +315,99 @@ func (a *Agent) runInputs(
 	startTime time.Time,
 	dst chan<- telegraf.Metric,
 ) error {
-	var wg sync.WaitGroup
+	a.reloadMu.Lock()
 	for _, input := range a.Config.Inputs {
-		interval := a.Config.Agent.Interval.Duration
-		jitter := a.Config.Agent.CollectionJitter.Duration
+		a.startInput(ctx, startTime, input, dst)
+	}
+	a.runCtx = ctx
+	a.startTime = startTime
+	a.inputDst = dst
+	a.reloadMu.Unlock()
+
+	<-ctx.Done()
+
+	// Once the context is done no further inputs are started by Reload.
+	a.reloadMu.Lock()
+	units := make([]*pluginUnit, 0, len(a.inputs))
+	for _, unit := range a.inputs {
+		units = append(units, unit)
+	}
+	a.reloadMu.Unlock()
 
-		// Overwrite agent interval if this plugin has its own.
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
+	for _, unit := range units {
+		unit.stop()
+	}
 
-		acc := NewAccumulator(input, dst)
-		acc.SetPrecision(a.Precision())
+	return nil
+}
 
-		wg.Add(1)
-		go func(input *models.RunningInput) {
-			defer wg.Done()
+// startInput starts the periodic gather for a single input.  Must be called
+// with the reloadMu held.
+func (a *Agent) startInput(
+	ctx context.Context,
+	startTime time.Time,
+	input *models.RunningInput,
+	dst chan<- telegraf.Metric,
+) {
+	interval := a.Config.Agent.Interval.Duration
+	jitter := a.Config.Agent.CollectionJitter.Duration
 
-			if a.Config.Agent.RoundInterval {
-				err := internal.SleepContext(
-					ctx, internal.AlignDuration(startTime, interval))
-				if err != nil {
-					return
-				}
-			}
+	// Overwrite agent interval and jitter if this plugin has its own.
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
+	if input.Config.CollectionJitter != nil {
+		jitter = *input.Config.CollectionJitter
+	}
+
+	acc := NewAccumulator(input, dst)
+	acc.SetPrecision(a.inputPrecision(input))
 
-			a.gatherOnInterval(ctx, acc, input, interval, jitter)
-		}(input)
+	ctx, unit := newPluginUnit(ctx)
+	a.inputs[input] = unit
+
+	var ticker *Ticker
+	switch {
+	case input.Config.Schedule != nil:
+		ticker = NewScheduleTicker(input.Config.Schedule, jitter)
+	case a.Config.Agent.RoundInterval:
+		ticker = NewAlignedTicker(
+			startTime, interval, jitter, input.Config.CollectionOffset)
+	default:
+		ticker = NewUnalignedTicker(
+			interval, jitter, input.Config.CollectionOffset)
 	}
-	wg.Wait()
 
-	return nil
+	go func() {
+		defer close(unit.done)
+		defer ticker.Stop()
+
+		if err := a.retryServiceInput(ctx, input, dst); err != nil {
+			return
+		}
+
+		a.gatherOnInterval(ctx, acc, input, ticker, interval)
+	}()
 }
 
-// gather runs an input's gather function periodically until the context is
-// done.
+// gatherOnInterval runs an input's gather function on each tick until the
+// context is done.
 func (a *Agent) gatherOnInterval(
 	ctx context.Context,
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
+	ticker *Ticker,
 	interval time.Duration,
-	jitter time.Duration,
 ) {
 	defer panicRecover(input)
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
 	for {
-		err := internal.SleepContext(ctx, internal.RandomDuration(jitter))
-		if err != nil {
-			return
-		}
-
-		err = a.gatherOnce(acc, input, interval)
-		if err != nil {
-			acc.AddError(err)
-		}
-
 		select {
 		case <-ticker.C:
-			continue
+			err := a.gatherOnce(acc, input, interval)
+			if err != nil {
+				acc.AddError(err)
+			}
 		case <-ctx.Done():
 			return
 		}
@@ -343,30 +440,180 @@ func (a *Agent) gatherOnce(
 	}
 }
 
-// runProcessors applies processors to metrics.
-func (a *Agent) runProcessors(
-	src <-chan telegraf.Metric,
-	agg chan<- telegraf.Metric,
-) error {
-	for metric := range src {
-		metrics := a.applyProcessors(metric)
+// startProcessors starts the processor chains.  Metrics from inputs are
+// written to dst after processing, metrics from aggregators to aggDst.
+func (a *Agent) startProcessors(dst, aggDst chan<- telegraf.Metric) error {
+	chain, err := startProcessorChain(a.Config.Processors, dst)
+	if err != nil {
+		return err
+	}
 
-		for _, metric := range metrics {
-			agg <- metric
-		}
+	aggChain, err := startProcessorChain(a.Config.AggProcessors, aggDst)
+	if err != nil {
+		chain.stop()
+		return err
 	}
 
+	a.processorChain = chain
+	a.aggProcessorChain = aggChain
 	return nil
 }
 
-// applyProcessors applies all processors to a metric.
-func (a *Agent) applyProcessors(m telegraf.Metric) []telegraf.Metric {
-	metrics := []telegraf.Metric{m}
-	for _, processor := range a.Config.Processors {
-		metrics = processor.Apply(metrics...)
+// stopProcessors stops the processor chains if the pipeline is not started.
+func (a *Agent) stopProcessors() {
+	a.processorChain.stop()
+	a.aggProcessorChain.stop()
+}
+
+// runProcessors passes the metrics from src through the processor chain.
+//
+// Runs until src is closed and all metrics have been processed.
+func (a *Agent) runProcessors(src <-chan telegraf.Metric) error {
+	for metric := range src {
+		a.processorsMu.RLock()
+		a.processorChain.in <- metric
+		a.processorsMu.RUnlock()
+	}
+
+	a.processorsMu.Lock()
+	a.processorChain.stop()
+	a.processorsMu.Unlock()
+	return nil
+}
+
+// replaceProcessors swaps the running processor chains for chains of the given
+// processors, which must not have been started before.
+//
+// The chain for aggregator metrics is replaced first, since stopping the input
+// chain requires the aggregators to keep consuming.
+func (a *Agent) replaceProcessors(processors, aggProcessors models.RunningProcessors) error {
+	a.aggProcessorsMu.Lock()
+	err := replaceProcessorChain(&a.aggProcessorChain, aggProcessors)
+	a.Config.AggProcessors = aggProcessors
+	a.aggProcessorsMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	a.processorsMu.Lock()
+	err = replaceProcessorChain(&a.processorChain, processors)
+	a.Config.Processors = processors
+	a.processorsMu.Unlock()
+	return err
+}
+
+// replaceProcessorChain stops the chain and starts a new one with the given
+// processors writing to the same destination.  If the new chain fails to
+// start, metrics are passed through unprocessed.
+func replaceProcessorChain(chain **processorChain, processors models.RunningProcessors) error {
+	dst := (*chain).dst
+	(*chain).stop()
+
+	var err error
+	*chain, err = startProcessorChain(processors, dst)
+	if err != nil {
+		*chain, _ = startProcessorChain(nil, dst)
+	}
+	return err
+}
+
+// processorChain runs processors as a pipeline, each processor in its own
+// goroutine reading from the one before it.
+type processorChain struct {
+	in   chan telegraf.Metric
+	dst  chan<- telegraf.Metric
+	done chan struct{}
+}
+
+// startProcessorChain starts the processors in order.  Metrics written to the
+// chain are passed through each processor and then written to dst.
+func startProcessorChain(
+	processors models.RunningProcessors,
+	dst chan<- telegraf.Metric,
+) (*processorChain, error) {
+	chain := &processorChain{
+		in:   make(chan telegraf.Metric, 100),
+		dst:  dst,
+		done: make(chan struct{}),
+	}
+
+	if len(processors) == 0 {
+		go func() {
+			defer close(chain.done)
+			for metric := range chain.in {
+				dst <- metric
+			}
+		}()
+		return chain, nil
+	}
+
+	var wg sync.WaitGroup
+	src := chain.in
+	for i, processor := range processors {
+		var next chan telegraf.Metric
+		out := dst
+		if i < len(processors)-1 {
+			next = make(chan telegraf.Metric, 100)
+			out = next
+		}
+
+		acc := NewAccumulator(processor, out)
+		err := processor.Start(acc)
+		if err != nil {
+			// Stop the processors already started, discarding anything
+			// they emit.
+			close(chain.in)
+			go func(src <-chan telegraf.Metric) {
+				for metric := range src {
+					metric.Drop()
+				}
+			}(src)
+			wg.Wait()
+			return nil, fmt.Errorf("could not start processor %s: %v",
+				processor.LogName(), err)
+		}
+
+		wg.Add(1)
+		go func(
+			processor *models.RunningProcessor,
+			src <-chan telegraf.Metric,
+			next chan telegraf.Metric,
+			acc telegraf.Accumulator,
+		) {
+			defer wg.Done()
+
+			for metric := range src {
+				if err := processor.Add(metric, acc); err != nil {
+					acc.AddError(err)
+				}
+			}
+
+			if err := processor.Stop(); err != nil {
+				log.Printf("E! [agent] Error stopping processor %s: %v",
+					processor.LogName(), err)
+			}
+
+			if next != nil {
+				close(next)
+			}
+		}(processor, src, next, acc)
+
+		src = next
 	}
 
-	return metrics
+	go func() {
+		wg.Wait()
+		close(chain.done)
+	}()
+
+	return chain, nil
+}
+
+// stop closes the chain and waits until all metrics are written to the
+// destination.
+func (c *processorChain) stop() {
+	close(c.in)
+	<-c.done
 }
 
 func updateWindow(start time.Time, roundInterval bool, period time.Duration) (time.Time, time.Time) {
@@ -385,6 +632,16 @@ func updateWindow(start time.Time, roundInterval bool, period time.Duration) (ti
 	return since, until
 }
 
+// alignWindow returns the window of the period containing start, aligned to
+// the wall clock in the local time zone of start.
+func alignWindow(start time.Time, period time.Duration) (time.Time, time.Time) {
+	_, offset := start.Zone()
+	zone := time.Duration(offset) * time.Second
+
+	since := start.Add(zone).Truncate(period).Add(-zone)
+	return since, since.Add(period)
+}
+
 // runAggregators adds metrics to the aggregators and triggers their periodic
 // push call.
 //
@@ -396,13 +653,15 @@ func (a *Agent) runAggregators(
 	dst chan<- telegraf.Metric,
 ) error {
 	ctx, cancel := context.WithCancel(context.Background())
+	aggregations := make(chan telegraf.Metric, 100)
 
-	// Before calling Add, initialize the aggregation window.  This ensures
-	// that any metric created after start time will be aggregated.
+	a.reloadMu.Lock()
 	for _, agg := range a.Config.Aggregators {
-		since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
-		agg.UpdateWindow(since, until)
+		a.startAggregator(ctx, startTime, agg, aggregations)
 	}
+	a.aggregateCtx = ctx
+	a.aggregateDst = aggregations
+	a.reloadMu.Unlock()
 
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -410,11 +669,13 @@ func (a *Agent) runAggregators(
 		defer wg.Done()
 		for metric := range src {
 			var dropOriginal bool
+			a.aggregatorMu.RLock()
 			for _, agg := range a.Config.Aggregators {
 				if ok := agg.Add(metric); ok {
 					dropOriginal = true
 				}
 			}
+			a.aggregatorMu.RUnlock()
 
 			if !dropOriginal {
 				dst <- metric
@@ -425,38 +686,63 @@ func (a *Agent) runAggregators(
 		cancel()
 	}()
 
-	aggregations := make(chan telegraf.Metric, 100)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
-		var aggWg sync.WaitGroup
-		for _, agg := range a.Config.Aggregators {
-			aggWg.Add(1)
-			go func(agg *models.RunningAggregator) {
-				defer aggWg.Done()
-
-				acc := NewAccumulator(agg, aggregations)
-				acc.SetPrecision(a.Precision())
-				a.push(ctx, agg, acc)
-			}(agg)
+		// The source is closed only after the inputs are stopped, at which
+		// point no more aggregators are started by Reload.
+		<-ctx.Done()
+		for _, unit := range a.aggregators {
+			<-unit.done
 		}
-
-		aggWg.Wait()
 		close(aggregations)
 	}()
 
 	for metric := range aggregations {
-		metrics := a.applyProcessors(metric)
-		for _, metric := range metrics {
-			dst <- metric
-		}
+		a.aggProcessorsMu.RLock()
+		a.aggProcessorChain.in <- metric
+		a.aggProcessorsMu.RUnlock()
 	}
 
+	a.aggProcessorsMu.Lock()
+	a.aggProcessorChain.stop()
+	a.aggProcessorsMu.Unlock()
+
 	wg.Wait()
 	return nil
 }
 
+// startAggregator initializes the aggregation window and starts the periodic
+// push for a single aggregator.  Must be called with the reloadMu held.
+func (a *Agent) startAggregator(
+	ctx context.Context,
+	startTime time.Time,
+	agg *models.RunningAggregator,
+	dst chan<- telegraf.Metric,
+) {
+	// Before calling Add, initialize the aggregation window.  This ensures
+	// that any metric created after start time will be aggregated.
+	var since, until time.Time
+	if agg.Config.AlignWindows {
+		since, until = alignWindow(startTime, agg.Period())
+	} else {
+		since, until = updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
+	}
+	agg.UpdateWindow(since, until)
+
+	acc := NewAccumulator(agg, dst)
+	acc.SetPrecision(a.Precision())
+
+	ctx, unit := newPluginUnit(ctx)
+	a.aggregators[agg] = unit
+
+	go func() {
+		defer close(unit.done)
+		a.push(ctx, agg, acc)
+	}()
+}
+
 // push runs the push for a single aggregator every period.
 func (a *Agent) push(
 	ctx context.Context,
@@ -468,7 +754,7 @@ func (a *Agent) push(
 		// already elapsed before this function is called.  This is guaranteed
 		// because so long as only Push updates the EndPeriod.  This method
 		// also avoids drift by not using a ticker.
-		until := time.Until(aggregator.EndPeriod())
+		until := time.Until(aggregator.NextPush())
 
 		select {
 		case <-time.After(until):
@@ -490,58 +776,72 @@ func (a *Agent) runOutputs(
 	startTime time.Time,
 	src <-chan telegraf.Metric,
 ) error {
-	interval := a.Config.Agent.FlushInterval.Duration
-	jitter := a.Config.Agent.FlushJitter.Duration
-
 	ctx, cancel := context.WithCancel(context.Background())
 
-	var wg sync.WaitGroup
+	a.reloadMu.Lock()
 	for _, output := range a.Config.Outputs {
-		interval := interval
-		// Overwrite agent flush_interval if this plugin has its own.
-		if output.Config.FlushInterval != 0 {
-			interval = output.Config.FlushInterval
-		}
-
-		jitter := jitter
-		// Overwrite agent flush_jitter if this plugin has its own.
-		if output.Config.FlushJitter != nil {
-			jitter = *output.Config.FlushJitter
-		}
-
-		wg.Add(1)
-		go func(output *models.RunningOutput) {
-			defer wg.Done()
-
-			if a.Config.Agent.RoundInterval {
-				err := internal.SleepContext(
-					ctx, internal.AlignDuration(startTime, interval))
-				if err != nil {
-					return
-				}
-			}
-
-			a.flush(ctx, output, interval, jitter)
-		}(output)
+		a.startOutput(ctx, startTime, output)
 	}
+	a.outputCtx = ctx
+	a.reloadMu.Unlock()
 
 	for metric := range src {
-		for i, output := range a.Config.Outputs {
-			if i == len(a.Config.Outputs)-1 {
-				output.AddMetric(metric)
-			} else {
-				output.AddMetric(metric.Copy())
-			}
-		}
+		a.outputsMu.RLock()
+		a.routing.addMetric(metric)
+		a.outputsMu.RUnlock()
 	}
 
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
 	cancel()
-	wg.Wait()
+
+	// The source is closed only after the inputs are stopped, at which point
+	// no more outputs are started by Reload.
+	for _, unit := range a.outputs {
+		<-unit.done
+	}
 
 	return nil
 }
 
+// startOutput starts the periodic flush for a single output.  Must be called
+// with the reloadMu held.
+func (a *Agent) startOutput(
+	ctx context.Context,
+	startTime time.Time,
+	output *models.RunningOutput,
+) {
+	interval := a.Config.Agent.FlushInterval.Duration
+	// Overwrite agent flush_interval if this plugin has its own.
+	if output.Config.FlushInterval != 0 {
+		interval = output.Config.FlushInterval
+	}
+
+	jitter := a.Config.Agent.FlushJitter.Duration
+	// Overwrite agent flush_jitter if this plugin has its own.
+	if output.Config.FlushJitter != nil {
+		jitter = *output.Config.FlushJitter
+	}
+
+	ctx, unit := newPluginUnit(ctx)
+	a.outputs[output] = unit
+
+	go func() {
+		defer close(unit.done)
+
+		if a.Config.Agent.RoundInterval {
+			err := internal.SleepContext(
+				ctx, internal.AlignDuration(startTime, interval))
+			if err != nil {
+				// Write any metrics received before the output is stopped.
+				a.flushOnce(output, interval, output.WriteFinal)
+				return
+			}
+		}
+
+		a.flush(ctx, output, interval, jitter, unit.flush)
+	}()
+}
+
 // flush runs an output's flush function periodically until the context is
 // done.
 func (a *Agent) flush(
@@ -549,8 +849,9 @@ func (a *Agent) flush(
 	output *models.RunningOutput,
 	interval time.Duration,
 	jitter time.Duration,
+	flushC <-chan struct{},
 ) {
-	// since we are watching two channels we need a ticker with the jitter
+	// since we are watching multiple channels we need a ticker with the jitter
 	// integrated.
 	ticker := NewTicker(interval, jitter)
 	defer ticker.Stop()
@@ -565,7 +866,7 @@ func (a *Agent) flush(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, output.WriteFinal))
 			return
 		default:
 		}
@@ -573,6 +874,8 @@ func (a *Agent) flush(
 		select {
 		case <-ticker.C:
 			logError(a.flushOnce(output, interval, output.Write))
+		case <-flushC:
+			logError(a.flushOnce(output, interval, output.Write))
 		case <-output.BatchReady:
 			// Favor the ticker over batch ready
 			select {
@@ -582,12 +885,31 @@ func (a *Agent) flush(
 				logError(a.flushOnce(output, interval, output.WriteBatch))
 			}
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, output.WriteFinal))
 			return
 		}
 	}
 }
 
+// Flush requests all outputs to write the metrics in their buffer without
+// waiting for the next flush interval.  The writes happen asynchronously.
+func (a *Agent) Flush() error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	if a.outputCtx == nil || a.outputCtx.Err() != nil {
+		return ErrNotRunning
+	}
+
+	for _, unit := range a.outputs {
+		select {
+		case unit.flush <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
 // flushOnce runs the output's Write function once, logging a warning each
 // interval it fails to complete before.
 func (a *Agent) flushOnce(
@@ -633,6 +955,13 @@ func (a *Agent) initPlugins() error {
 				processor.Config.Name, err)
 		}
 	}
+	for _, processor := range a.Config.AggProcessors {
+		err := processor.Init()
+		if err != nil {
+			return fmt.Errorf("could not initialize processor %s: %v",
+				processor.Config.Name, err)
+		}
+	}
 	for _, aggregator := range a.Config.Aggregators {
 		err := aggregator.Init()
 		if err != nil {
@@ -647,30 +976,69 @@ func (a *Agent) initPlugins() error {
 				output.Config.Name, err)
 		}
 	}
-	return nil
+	return a.Config.CheckOutputRouting()
 }
 
-// connectOutputs connects to all outputs.
+// connectOutputs connects to all outputs, removing the outputs that failed
+// to connect and have their startup errors ignored.
 func (a *Agent) connectOutputs(ctx context.Context) error {
+	var outputs []*models.RunningOutput
 	for _, output := range a.Config.Outputs {
-		log.Printf("D! [agent] Attempting connection to [%s]", output.LogName())
-		err := output.Output.Connect()
+		err := a.connectOutput(ctx, output)
+		if err == errPluginIgnored {
+			continue
+		}
 		if err != nil {
-			log.Printf("E! [agent] Failed to connect to [%s], retrying in 15s, "+
+			return err
+		}
+		outputs = append(outputs, output)
+	}
+	a.setOutputs(outputs)
+	return nil
+}
+
+// setOutputs replaces the running outputs and the routing of metrics to them.
+func (a *Agent) setOutputs(outputs []*models.RunningOutput) {
+	a.outputsMu.Lock()
+	a.Config.Outputs = outputs
+	a.routing = newOutputRouting(outputs)
+	a.outputsMu.Unlock()
+}
+
+// connectOutput connects to a single output.  On failure the output's
+// startup_error_behavior decides whether to retry once and return the error,
+// to retry in the background, or to return errPluginIgnored.
+func (a *Agent) connectOutput(ctx context.Context, output *models.RunningOutput) error {
+	log.Printf("D! [agent] Attempting connection to [%s]", output.LogName())
+	err := output.Output.Connect()
+	if err != nil {
+		switch output.Config.StartupErrorBehavior {
+		case models.StartupErrorBehaviorIgnore:
+			log.Printf("E! [agent] Failed to connect to [%s], ignoring output, "+
 				"error was '%s'", output.LogName(), err)
+			output.CloseBuffer()
+			return errPluginIgnored
+		case models.StartupErrorBehaviorRetry:
+			log.Printf("E! [agent] Failed to connect to [%s], retrying on each "+
+				"flush, error was '%s'", output.LogName(), err)
+			output.RetryConnect()
+			return nil
+		}
 
-			err := internal.SleepContext(ctx, 15*time.Second)
-			if err != nil {
-				return err
-			}
+		log.Printf("E! [agent] Failed to connect to [%s], retrying in 15s, "+
+			"error was '%s'", output.LogName(), err)
 
-			err = output.Output.Connect()
-			if err != nil {
-				return err
-			}
+		err := internal.SleepContext(ctx, 15*time.Second)
+		if err != nil {
+			return err
+		}
+
+		err = output.Output.Connect()
+		if err != nil {
+			return err
 		}
-		log.Printf("D! [agent] Successfully connected to %s", output.LogName())
 	}
+	log.Printf("D! [agent] Successfully connected to %s", output.LogName())
 	return nil
 }
 
@@ -681,59 +1049,138 @@ func (a *Agent) closeOutputs() {
 	}
 }
 
-// startServiceInputs starts all service inputs.
+// startServiceInputs starts all service inputs, removing the inputs that
+// failed to start and have their startup errors ignored.
 func (a *Agent) startServiceInputs(
 	ctx context.Context,
 	dst chan<- telegraf.Metric,
 ) error {
-	started := []telegraf.ServiceInput{}
-
+	var inputs []*models.RunningInput
 	for _, input := range a.Config.Inputs {
-		if si, ok := input.Input.(telegraf.ServiceInput); ok {
-			// Service input plugins are not subject to timestamp rounding.
-			// This only applies to the accumulator passed to Start(), the
-			// Gather() accumulator does apply rounding according to the
-			// precision agent setting.
-			acc := NewAccumulator(input, dst)
-			acc.SetPrecision(time.Nanosecond)
-
-			err := si.Start(acc)
-			if err != nil {
-				log.Printf("E! [agent] Service for [%s] failed to start: %v",
-					input.LogName(), err)
+		err := a.startServiceInput(input, dst)
+		if err == errPluginIgnored {
+			continue
+		}
+		if err != nil {
+			for _, input := range inputs {
+				input.Stop()
+			}
+			return err
+		}
+		inputs = append(inputs, input)
+	}
+	a.setInputs(inputs)
+	return nil
+}
 
-				for _, si := range started {
-					si.Stop()
-				}
+// setInputs replaces the running inputs.
+func (a *Agent) setInputs(inputs []*models.RunningInput) {
+	a.inputsMu.Lock()
+	a.Config.Inputs = inputs
+	a.inputsMu.Unlock()
+}
 
-				return err
-			}
+// startServiceInput starts a single service input.  On failure the input's
+// startup_error_behavior decides whether to return the error, to leave the
+// input to be retried by startInput, or to return errPluginIgnored.
+func (a *Agent) startServiceInput(
+	input *models.RunningInput,
+	dst chan<- telegraf.Metric,
+) error {
+	if !input.IsServiceInput() {
+		return nil
+	}
 
-			started = append(started, si)
-		}
+	err := input.Start(serviceAccumulator(input, dst))
+	if err == nil {
+		return nil
+	}
+
+	switch input.Config.StartupErrorBehavior {
+	case models.StartupErrorBehaviorIgnore:
+		log.Printf("E! [agent] Service for [%s] failed to start, ignoring input: %v",
+			input.LogName(), err)
+		return errPluginIgnored
+	case models.StartupErrorBehaviorRetry:
+		log.Printf("E! [agent] Service for [%s] failed to start, retrying in %s: %v",
+			input.LogName(), startupRetryInterval, err)
+		return nil
+	default:
+		log.Printf("E! [agent] Service for [%s] failed to start: %v",
+			input.LogName(), err)
+		return err
 	}
+}
+
+// retryServiceInput starts a service input that failed to start, retrying
+// until it succeeds or the context is done.
+func (a *Agent) retryServiceInput(
+	ctx context.Context,
+	input *models.RunningInput,
+	dst chan<- telegraf.Metric,
+) error {
+	for !input.Started() {
+		err := internal.SleepContext(ctx, startupRetryInterval)
+		if err != nil {
+			return err
+		}
 
+		err = input.Start(serviceAccumulator(input, dst))
+		if err != nil {
+			log.Printf("E! [agent] Service for [%s] failed to start, retrying in %s: %v",
+				input.LogName(), startupRetryInterval, err)
+			continue
+		}
+		log.Printf("I! [agent] Service for [%s] started", input.LogName())
+	}
 	return nil
 }
 
+// serviceAccumulator returns the accumulator passed to a service input's
+// Start function.
+func serviceAccumulator(
+	input *models.RunningInput,
+	dst chan<- telegraf.Metric,
+) telegraf.Accumulator {
+	// Service input plugins are not subject to timestamp rounding unless
+	// the input sets its own precision.  This only applies to the
+	// accumulator passed to Start(), the Gather() accumulator does apply
+	// rounding according to the precision agent setting.
+	acc := NewAccumulator(input, dst)
+	acc.SetPrecision(time.Nanosecond)
+	if input.Config.Precision > 0 {
+		acc.SetPrecision(input.Config.Precision)
+	}
+	return acc
+}
+
 // stopServiceInputs stops all service inputs.
 func (a *Agent) stopServiceInputs() {
 	for _, input := range a.Config.Inputs {
-		if si, ok := input.Input.(telegraf.ServiceInput); ok {
-			si.Stop()
-		}
+		input.Stop()
 	}
 }
 
 // Returns the rounding precision for metrics.
 func (a *Agent) Precision() time.Duration {
-	precision := a.Config.Agent.Precision.Duration
-	interval := a.Config.Agent.Interval.Duration
+	if a.Config.Agent.Precision.Duration > 0 {
+		return a.Config.Agent.Precision.Duration
+	}
+	return intervalPrecision(a.Config.Agent.Interval.Duration)
+}
 
-	if precision > 0 {
-		return precision
+// inputPrecision returns the rounding precision for the metrics gathered by
+// the input.  The precision of the input overrides the agent precision.
+func (a *Agent) inputPrecision(input *models.RunningInput) time.Duration {
+	if input.Config.Precision > 0 {
+		return input.Config.Precision
 	}
+	return a.Precision()
+}
 
+// intervalPrecision returns the default precision for an interval, the
+// largest of a second, millisecond or microsecond not exceeding it.
+func intervalPrecision(interval time.Duration) time.Duration {
 	switch {
 	case interval >= time.Second:
 		return time.Second
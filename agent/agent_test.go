@@ -1,12 +1,18 @@
 package agent
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -166,3 +172,300 @@ func TestWindow(t *testing.T) {
 		})
 	}
 }
+
+// holdProcessor is a streaming processor that holds all metrics until it is
+// stopped.
+type holdProcessor struct {
+	acc     telegraf.Accumulator
+	metrics []telegraf.Metric
+}
+
+func (p *holdProcessor) SampleConfig() string { return "" }
+func (p *holdProcessor) Description() string  { return "" }
+
+func (p *holdProcessor) Start(acc telegraf.Accumulator) error {
+	p.acc = acc
+	return nil
+}
+
+func (p *holdProcessor) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	p.metrics = append(p.metrics, m)
+	return nil
+}
+
+func (p *holdProcessor) Stop() error {
+	for _, m := range p.metrics {
+		p.acc.AddMetric(m)
+	}
+	return nil
+}
+
+// tagProcessor is a processor adding a tag to each metric.
+type tagProcessor struct {
+	key, value string
+}
+
+func (p *tagProcessor) SampleConfig() string { return "" }
+func (p *tagProcessor) Description() string  { return "" }
+
+func (p *tagProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.AddTag(p.key, p.value)
+	}
+	return in
+}
+
+func TestAlignWindow(t *testing.T) {
+	zone := time.FixedZone("IST", 5*3600+1800)
+	tests := []struct {
+		name   string
+		start  time.Time
+		period time.Duration
+		since  time.Time
+		until  time.Time
+	}{
+		{
+			name:   "utc",
+			start:  time.Date(2018, 3, 27, 10, 20, 0, 0, time.UTC),
+			period: time.Hour,
+			since:  time.Date(2018, 3, 27, 10, 0, 0, 0, time.UTC),
+			until:  time.Date(2018, 3, 27, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "exact alignment",
+			start:  time.Date(2018, 3, 27, 10, 0, 0, 0, time.UTC),
+			period: time.Hour,
+			since:  time.Date(2018, 3, 27, 10, 0, 0, 0, time.UTC),
+			until:  time.Date(2018, 3, 27, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "local time zone",
+			start:  time.Date(2018, 3, 27, 10, 20, 0, 0, zone),
+			period: time.Hour,
+			since:  time.Date(2018, 3, 27, 10, 0, 0, 0, zone),
+			until:  time.Date(2018, 3, 27, 11, 0, 0, 0, zone),
+		},
+		{
+			name:   "local midnight",
+			start:  time.Date(2018, 3, 27, 10, 20, 0, 0, zone),
+			period: 24 * time.Hour,
+			since:  time.Date(2018, 3, 27, 0, 0, 0, 0, zone),
+			until:  time.Date(2018, 3, 28, 0, 0, 0, 0, zone),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, until := alignWindow(tt.start, tt.period)
+			require.True(t, tt.since.Equal(since), "since: %v", since)
+			require.True(t, tt.until.Equal(until), "until: %v", until)
+		})
+	}
+}
+
+func TestProcessorChain(t *testing.T) {
+	hold := &holdProcessor{}
+	chain := models.RunningProcessors{
+		models.NewRunningProcessor(
+			processors.NewStreamingProcessorFromProcessor(&tagProcessor{"first", "true"}),
+			&models.ProcessorConfig{Name: "first"}),
+		models.NewRunningProcessor(hold, &models.ProcessorConfig{Name: "hold"}),
+		models.NewRunningProcessor(
+			processors.NewStreamingProcessorFromProcessor(&tagProcessor{"last", "true"}),
+			&models.ProcessorConfig{Name: "last"}),
+	}
+
+	dst := make(chan telegraf.Metric, 10)
+	c, err := startProcessorChain(chain, dst)
+	require.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	c.in <- testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 1}, now)
+	c.in <- testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 2}, now)
+	c.stop()
+	close(dst)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"first": "true", "last": "true"},
+			map[string]interface{}{"value": 1}, now),
+		testutil.MustMetric("cpu",
+			map[string]string{"first": "true", "last": "true"},
+			map[string]interface{}{"value": 2}, now),
+	}
+
+	var actual []telegraf.Metric
+	for m := range dst {
+		actual = append(actual, m)
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestProcessorChain_Empty(t *testing.T) {
+	dst := make(chan telegraf.Metric, 10)
+	c, err := startProcessorChain(nil, dst)
+	require.NoError(t, err)
+
+	m := testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	c.in <- m
+	c.stop()
+	require.Equal(t, m, <-dst)
+}
+
+type failingOutput struct {
+	connectErr error
+	closed     bool
+}
+
+func (o *failingOutput) SampleConfig() string                  { return "" }
+func (o *failingOutput) Description() string                   { return "" }
+func (o *failingOutput) Connect() error                        { return o.connectErr }
+func (o *failingOutput) Close() error                          { o.closed = true; return nil }
+func (o *failingOutput) Write(metrics []telegraf.Metric) error { return nil }
+
+func TestConnectOutputs_StartupErrorBehavior(t *testing.T) {
+	newOutput := func(name, behavior string) (*models.RunningOutput, *failingOutput) {
+		o := &failingOutput{connectErr: errors.New("connection refused")}
+		ro := models.NewRunningOutput(name, o, &models.OutputConfig{
+			Name:                 name,
+			StartupErrorBehavior: behavior,
+		}, 0, 0)
+		return ro, o
+	}
+
+	retry, _ := newOutput("retry", models.StartupErrorBehaviorRetry)
+	ignore, ignored := newOutput("ignore", models.StartupErrorBehaviorIgnore)
+
+	c := config.NewConfig()
+	c.Outputs = []*models.RunningOutput{retry, ignore}
+	a, _ := NewAgent(c)
+
+	require.NoError(t, a.connectOutputs(context.Background()))
+	require.Equal(t, []*models.RunningOutput{retry}, a.Config.Outputs)
+	require.False(t, retry.Connected())
+	require.False(t, ignored.closed)
+
+	failing, _ := newOutput("error", models.StartupErrorBehaviorError)
+	c.Outputs = []*models.RunningOutput{failing}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, a.connectOutputs(ctx))
+}
+
+type failingServiceInput struct {
+	startErr error
+	started  int
+	stopped  int
+}
+
+func (i *failingServiceInput) SampleConfig() string                  { return "" }
+func (i *failingServiceInput) Description() string                   { return "" }
+func (i *failingServiceInput) Gather(acc telegraf.Accumulator) error { return nil }
+func (i *failingServiceInput) Stop()                                 { i.stopped++ }
+
+func (i *failingServiceInput) Start(acc telegraf.Accumulator) error {
+	if i.startErr != nil {
+		return i.startErr
+	}
+	i.started++
+	return nil
+}
+
+func TestStartServiceInputs_StartupErrorBehavior(t *testing.T) {
+	newInput := func(name, behavior string) (*models.RunningInput, *failingServiceInput) {
+		i := &failingServiceInput{startErr: errors.New("address in use")}
+		ri := models.NewRunningInput(i, &models.InputConfig{
+			Name:                 name,
+			StartupErrorBehavior: behavior,
+		})
+		return ri, i
+	}
+
+	ok := models.NewRunningInput(&failingServiceInput{}, &models.InputConfig{Name: "ok"})
+	retry, retried := newInput("retry", models.StartupErrorBehaviorRetry)
+	ignore, _ := newInput("ignore", models.StartupErrorBehaviorIgnore)
+
+	c := config.NewConfig()
+	c.Inputs = []*models.RunningInput{ok, retry, ignore}
+	a, _ := NewAgent(c)
+
+	dst := make(chan telegraf.Metric, 10)
+	require.NoError(t, a.startServiceInputs(context.Background(), dst))
+	require.Equal(t, []*models.RunningInput{ok, retry}, a.Config.Inputs)
+	require.True(t, ok.Started())
+	require.False(t, retry.Started())
+
+	defer func(interval time.Duration) {
+		startupRetryInterval = interval
+	}(startupRetryInterval)
+	startupRetryInterval = time.Millisecond
+
+	retried.startErr = nil
+	require.NoError(t, a.retryServiceInput(context.Background(), retry, dst))
+	require.True(t, retry.Started())
+	require.Equal(t, 1, retried.started)
+
+	a.stopServiceInputs()
+	require.Equal(t, 1, retried.stopped)
+
+	failing, _ := newInput("error", models.StartupErrorBehaviorError)
+	okInput := &failingServiceInput{}
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(okInput, &models.InputConfig{Name: "ok"}),
+		failing,
+	}
+	require.Error(t, a.startServiceInputs(context.Background(), dst))
+	require.Equal(t, 1, okInput.stopped)
+}
+
+func TestInputPrecision(t *testing.T) {
+	tests := []struct {
+		name           string
+		agentInterval  time.Duration
+		agentPrecision time.Duration
+		inputInterval  time.Duration
+		inputPrecision time.Duration
+		expected       time.Duration
+	}{
+		{
+			name:          "agent interval",
+			agentInterval: 10 * time.Second,
+			expected:      time.Second,
+		},
+		{
+			name:           "agent precision",
+			agentInterval:  10 * time.Second,
+			agentPrecision: time.Minute,
+			inputInterval:  100 * time.Millisecond,
+			expected:       time.Minute,
+		},
+		{
+			name:          "input interval ignored",
+			agentInterval: 10 * time.Second,
+			inputInterval: 100 * time.Millisecond,
+			expected:      time.Second,
+		},
+		{
+			name:           "input precision",
+			agentInterval:  10 * time.Second,
+			agentPrecision: time.Second,
+			inputPrecision: time.Microsecond,
+			expected:       time.Microsecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.NewConfig()
+			c.Agent.Interval.Duration = tt.agentInterval
+			c.Agent.Precision.Duration = tt.agentPrecision
+			a, err := NewAgent(c)
+			require.NoError(t, err)
+
+			input := models.NewRunningInput(&failingServiceInput{}, &models.InputConfig{
+				Name:      "test",
+				Interval:  tt.inputInterval,
+				Precision: tt.inputPrecision,
+			})
+			require.Equal(t, tt.expected, a.inputPrecision(input))
+		})
+	}
+}
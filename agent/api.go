@@ -0,0 +1,268 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// API is a http.Handler exposing the state of a running agent along with
+// actions to control it.
+//
+// The API has no authentication and must only be served on a unix socket or
+// loopback address, see ListenAPI.  Requests from browsers are rejected, see
+// ServeHTTP.
+//
+//	GET  /plugins  list the plugins with their internal statistics
+//	POST /reload   reload the configuration, as with SIGHUP
+//	POST /flush    write the metrics buffered by all outputs
+type API struct {
+	agent  *Agent
+	reload func()
+	mux    *http.ServeMux
+}
+
+// NewAPI returns an API for the agent.  The reload function is called when a
+// reload is requested and should not block.
+func NewAPI(agent *Agent, reload func()) *API {
+	api := &API{
+		agent:  agent,
+		reload: reload,
+		mux:    http.NewServeMux(),
+	}
+	api.mux.HandleFunc("/plugins", api.handlePlugins)
+	api.mux.HandleFunc("/reload", api.handleReload)
+	api.mux.HandleFunc("/flush", api.handleFlush)
+	return api
+}
+
+// ListenAPI opens a listener for the API.  The address is either a unix
+// socket, such as "unix:///var/run/telegraf.sock", or a tcp address on the
+// loopback interface, such as "tcp://127.0.0.1:8089".
+func ListenAPI(address string) (net.Listener, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api_address %q: %v", address, err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		path := u.Path
+		if path == "" {
+			path = u.Opaque
+		}
+		// Remove a socket left over by an unclean shutdown.
+		if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0660); err != nil {
+			l.Close()
+			return nil, err
+		}
+		return l, nil
+	case "tcp", "tcp4", "tcp6":
+		if !isLoopback(u.Hostname()) {
+			return nil, fmt.Errorf("api_address %q must be a loopback address", address)
+		}
+		return net.Listen(u.Scheme, u.Host)
+	default:
+		return nil, fmt.Errorf("api_address %q has unsupported scheme %q", address, u.Scheme)
+	}
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ServeHTTP serves the API.  Requests that a browser may send on behalf of a
+// web page are rejected: requests carrying an Origin header, and requests
+// over tcp for a host other than a loopback address, as sent after DNS
+// rebinding.
+func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Origin") != "" || !isLoopbackRequest(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	api.mux.ServeHTTP(w, r)
+}
+
+// isLoopbackRequest returns true if the request was received on a unix socket
+// or its Host is a loopback address.
+func isLoopbackRequest(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if ok && addr.Network() == "unix" {
+		return true
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return isLoopback(strings.Trim(host, "[]"))
+}
+
+type pluginStatus struct {
+	Name       string           `json:"name"`
+	Alias      string           `json:"alias,omitempty"`
+	Stats      map[string]int64 `json:"stats"`
+	LastGather *gatherStatus    `json:"last_gather,omitempty"`
+	Buffer     *bufferStatus    `json:"buffer,omitempty"`
+}
+
+type gatherStatus struct {
+	Time     time.Time `json:"time"`
+	Duration int64     `json:"duration_ns"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type bufferStatus struct {
+	Size  int `json:"size"`
+	Limit int `json:"limit"`
+}
+
+type pluginsResponse struct {
+	Inputs      []pluginStatus `json:"inputs"`
+	Processors  []pluginStatus `json:"processors"`
+	Aggregators []pluginStatus `json:"aggregators"`
+	Outputs     []pluginStatus `json:"outputs"`
+}
+
+func (api *API) handlePlugins(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, api.agent.pluginStatus())
+}
+
+func (api *API) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	log.Printf("I! [agent] Reload requested by API")
+	api.reload()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (api *API) handleFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	if err := api.agent.Flush(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed),
+		http.StatusMethodNotAllowed)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("E! [agent] Error writing API response: %v", err)
+	}
+}
+
+// pluginStatus returns the status of the currently loaded plugins.  The
+// plugin lists are copied so that no lock is held while collecting the
+// status, which would block reloads.
+func (a *Agent) pluginStatus() *pluginsResponse {
+	a.inputsMu.RLock()
+	inputs := append([]*models.RunningInput(nil), a.Config.Inputs...)
+	a.inputsMu.RUnlock()
+
+	a.processorsMu.RLock()
+	processors := append([]*models.RunningProcessor(nil), a.Config.Processors...)
+	a.processorsMu.RUnlock()
+
+	a.aggregatorMu.RLock()
+	aggregators := append([]*models.RunningAggregator(nil), a.Config.Aggregators...)
+	a.aggregatorMu.RUnlock()
+
+	a.outputsMu.RLock()
+	outputs := append([]*models.RunningOutput(nil), a.Config.Outputs...)
+	a.outputsMu.RUnlock()
+
+	resp := &pluginsResponse{
+		Inputs:      []pluginStatus{},
+		Processors:  []pluginStatus{},
+		Aggregators: []pluginStatus{},
+		Outputs:     []pluginStatus{},
+	}
+
+	for _, input := range inputs {
+		last := input.LastGather()
+		status := pluginStatus{
+			Name:  input.Config.Name,
+			Alias: input.Config.Alias,
+			Stats: input.Stats(),
+		}
+		if !last.Time.IsZero() {
+			status.LastGather = &gatherStatus{
+				Time:     last.Time,
+				Duration: last.Duration.Nanoseconds(),
+			}
+			if last.Err != nil {
+				status.LastGather.Error = last.Err.Error()
+			}
+		}
+		resp.Inputs = append(resp.Inputs, status)
+	}
+
+	for _, processor := range processors {
+		resp.Processors = append(resp.Processors, pluginStatus{
+			Name:  processor.Config.Name,
+			Alias: processor.Config.Alias,
+			Stats: processor.Stats(),
+		})
+	}
+
+	for _, agg := range aggregators {
+		resp.Aggregators = append(resp.Aggregators, pluginStatus{
+			Name:  agg.Config.Name,
+			Alias: agg.Config.Alias,
+			Stats: agg.Stats(),
+		})
+	}
+
+	for _, output := range outputs {
+		resp.Outputs = append(resp.Outputs, pluginStatus{
+			Name:  output.Config.Name,
+			Alias: output.Config.Alias,
+			Stats: output.Stats(),
+			Buffer: &bufferStatus{
+				Size:  output.BufferLength(),
+				Limit: output.MetricBufferLimit,
+			},
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/plugins/outputs/discard"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAPI(t *testing.T, reload func()) *API {
+	c := config.NewConfig()
+	c.Inputs = append(c.Inputs, models.NewRunningInput(&testInput{},
+		&models.InputConfig{Name: "test", Alias: "first"}))
+	c.Outputs = append(c.Outputs, models.NewRunningOutput("discard",
+		&discard.Discard{}, &models.OutputConfig{Name: "discard"}, 0, 100))
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	return NewAPI(a, reload)
+}
+
+func TestAPI_Plugins(t *testing.T) {
+	api := newTestAPI(t, func() {})
+	require.NoError(t, api.agent.Config.Inputs[0].Gather(&testutil.Accumulator{}))
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest("GET", "http://127.0.0.1:8089/plugins", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp pluginsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	require.Len(t, resp.Inputs, 1)
+	require.Equal(t, "test", resp.Inputs[0].Name)
+	require.Equal(t, "first", resp.Inputs[0].Alias)
+	require.Contains(t, resp.Inputs[0].Stats, "metrics_gathered")
+	require.NotNil(t, resp.Inputs[0].LastGather)
+	require.Empty(t, resp.Inputs[0].LastGather.Error)
+
+	require.Len(t, resp.Outputs, 1)
+	require.Equal(t, "discard", resp.Outputs[0].Name)
+	require.Contains(t, resp.Outputs[0].Stats, "metrics_added")
+	require.Equal(t, &bufferStatus{Size: 0, Limit: 100}, resp.Outputs[0].Buffer)
+
+	require.Empty(t, resp.Processors)
+	require.Empty(t, resp.Aggregators)
+}
+
+func TestAPI_Reload(t *testing.T) {
+	var reloaded bool
+	api := newTestAPI(t, func() { reloaded = true })
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest("GET", "http://127.0.0.1:8089/reload", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	require.False(t, reloaded)
+
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest("POST", "http://127.0.0.1:8089/reload", nil))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.True(t, reloaded)
+}
+
+func TestAPI_FlushNotRunning(t *testing.T) {
+	api := newTestAPI(t, func() {})
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest("POST", "http://127.0.0.1:8089/flush", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestAPI_RejectsBrowserRequests(t *testing.T) {
+	var reloaded bool
+	api := newTestAPI(t, func() { reloaded = true })
+
+	// A page served from another origin
+	req := httptest.NewRequest("POST", "http://127.0.0.1:8089/reload", nil)
+	req.Header.Set("Origin", "http://example.org")
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	// A name rebound to the loopback address
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest("POST", "http://example.org:8089/reload", nil))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.False(t, reloaded)
+
+	for _, host := range []string{"localhost:8089", "[::1]:8089", "127.0.0.1"} {
+		req = httptest.NewRequest("POST", "/reload", nil)
+		req.Host = host
+		w = httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		require.Equal(t, http.StatusAccepted, w.Code, host)
+	}
+
+	// Any host is accepted on a unix socket
+	req = httptest.NewRequest("POST", "http://telegraf/reload", nil)
+	req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey,
+		&net.UnixAddr{Name: "/var/run/telegraf.sock", Net: "unix"}))
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestListenAPI(t *testing.T) {
+	l, err := ListenAPI("tcp://127.0.0.1:0")
+	require.NoError(t, err)
+	l.Close()
+
+	_, err = ListenAPI("tcp://0.0.0.0:0")
+	require.Error(t, err)
+
+	_, err = ListenAPI("udp://127.0.0.1:0")
+	require.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "telegraf-api")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.sock")
+	l, err = ListenAPI("unix://" + path)
+	require.NoError(t, err)
+	l.Close()
+}
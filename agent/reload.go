@@ -0,0 +1,330 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+var (
+	// ErrNotRunning is returned by Reload if the agent is not running.
+	ErrNotRunning = errors.New("agent is not running")
+
+	// ErrRestartRequired is returned by Reload if the new configuration
+	// changes settings that cannot be applied to individual plugins.
+	ErrRestartRequired = errors.New("agent settings changed, restart required")
+
+	// errPluginIgnored is returned when a plugin fails to start and its
+	// startup_error_behavior is "ignore".
+	errPluginIgnored = errors.New("plugin ignored")
+)
+
+// NotAppliedError is returned by Reload if the new configuration is invalid
+// and was not applied, the agent keeps running unchanged.
+type NotAppliedError struct {
+	Err error
+}
+
+func (e *NotAppliedError) Error() string {
+	return fmt.Sprintf("config not applied: %v", e.Err)
+}
+
+// Reload applies the plugins of the new config to the running agent.  Plugins
+// whose configuration did not change keep running, all other plugins are
+// stopped or started.
+//
+// If the agent settings or global tags differ ErrRestartRequired is returned
+// without changing any plugins.  If a plugin of the new configuration fails
+// to initialize a *NotAppliedError is returned, also without changing any
+// plugins.  On any other error the agent may be left partially reloaded and
+// should be restarted.  On success the remote
+// configurations of c are accepted.
+func (a *Agent) Reload(c *config.Config) error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	if a.runCtx == nil || a.aggregateCtx == nil || a.outputCtx == nil ||
+		a.runCtx.Err() != nil {
+		return ErrNotRunning
+	}
+
+	if !reflect.DeepEqual(a.Config.Agent, c.Agent) || !reflect.DeepEqual(a.Config.Tags, c.Tags) {
+		return ErrRestartRequired
+	}
+
+	runningInputs := a.Config.Inputs
+	runningProcessors := a.Config.Processors
+	runningAggregators := a.Config.Aggregators
+	runningOutputs := a.Config.Outputs
+
+	var runningIDs, updatedIDs []string
+	for _, input := range runningInputs {
+		runningIDs = append(runningIDs, pluginID(input.Config.Name, input.Fingerprint))
+	}
+	for _, input := range c.Inputs {
+		updatedIDs = append(updatedIDs, pluginID(input.Config.Name, input.Fingerprint))
+	}
+	inputMatches, newInputs, oldInputs := diffPlugins(runningIDs, updatedIDs)
+
+	runningIDs, updatedIDs = nil, nil
+	for _, processor := range runningProcessors {
+		runningIDs = append(runningIDs, pluginID(processor.Config.Name, processor.Fingerprint))
+	}
+	for _, processor := range c.Processors {
+		updatedIDs = append(updatedIDs, pluginID(processor.Config.Name, processor.Fingerprint))
+	}
+	_, newProcessors, oldProcessors := diffPlugins(runningIDs, updatedIDs)
+
+	runningIDs, updatedIDs = nil, nil
+	for _, agg := range runningAggregators {
+		runningIDs = append(runningIDs, pluginID(agg.Config.Name, agg.Fingerprint))
+	}
+	for _, agg := range c.Aggregators {
+		updatedIDs = append(updatedIDs, pluginID(agg.Config.Name, agg.Fingerprint))
+	}
+	aggMatches, newAggregators, oldAggregators := diffPlugins(runningIDs, updatedIDs)
+
+	runningIDs, updatedIDs = nil, nil
+	for _, output := range runningOutputs {
+		runningIDs = append(runningIDs, pluginID(output.Config.Name, output.Fingerprint))
+	}
+	for _, output := range c.Outputs {
+		updatedIDs = append(updatedIDs, pluginID(output.Config.Name, output.Fingerprint))
+	}
+	outputMatches, newOutputs, oldOutputs := diffPlugins(runningIDs, updatedIDs)
+
+	if err := c.CheckOutputRouting(); err != nil {
+		return &NotAppliedError{Err: err}
+	}
+
+	// Initialize the new plugins before anything is stopped so that an
+	// invalid configuration leaves the agent untouched.  Outputs are
+	// initialized later since they may share resources, such as a disk
+	// buffer, with the output they are replacing.
+	for _, i := range newInputs {
+		if err := c.Inputs[i].Init(); err != nil {
+			return &NotAppliedError{Err: fmt.Errorf("could not initialize input %s: %v",
+				c.Inputs[i].LogName(), err)}
+		}
+	}
+	// Processors are run as a chain, so if any processor changed the chain is
+	// replaced using the new instances of all processors.
+	processorsChanged := len(newProcessors) > 0 || len(oldProcessors) > 0
+	reloadedProcessors := 0
+	if processorsChanged {
+		reloadedProcessors = len(c.Processors)
+		for _, processor := range append(c.Processors, c.AggProcessors...) {
+			if err := processor.Init(); err != nil {
+				return &NotAppliedError{Err: fmt.Errorf("could not initialize processor %s: %v",
+					processor.Config.Name, err)}
+			}
+		}
+	}
+	for _, i := range newAggregators {
+		if err := c.Aggregators[i].Init(); err != nil {
+			return &NotAppliedError{Err: fmt.Errorf("could not initialize aggregator %s: %v",
+				c.Aggregators[i].Config.Name, err)}
+		}
+	}
+
+	log.Printf("I! [agent] Reloading %d inputs, %d processors, %d aggregators and %d outputs",
+		len(newInputs)+len(oldInputs), reloadedProcessors,
+		len(newAggregators)+len(oldAggregators), len(newOutputs)+len(oldOutputs))
+
+	// Inputs
+	inputs := append([]*models.RunningInput(nil), c.Inputs...)
+	for i, match := range inputMatches {
+		if match >= 0 {
+			inputs[i] = runningInputs[match]
+		}
+	}
+	for _, i := range oldInputs {
+		input := runningInputs[i]
+		log.Printf("D! [agent] Stopping input %s", input.LogName())
+		a.inputs[input].stop()
+		delete(a.inputs, input)
+		input.Stop()
+	}
+	a.setInputs(inputs)
+	var ignored []int
+	for _, i := range newInputs {
+		input := inputs[i]
+		log.Printf("D! [agent] Starting input %s", input.LogName())
+		err := a.startServiceInput(input, a.inputDst)
+		if err == errPluginIgnored {
+			ignored = append(ignored, i)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("service for [%s] failed to start: %v",
+				input.LogName(), err)
+		}
+		a.startInput(a.runCtx, a.startTime, input, a.inputDst)
+	}
+	if len(ignored) > 0 {
+		inputs = append([]*models.RunningInput(nil), inputs...)
+		n := removeIndexes(len(inputs), ignored, func(dst, src int) {
+			inputs[dst] = inputs[src]
+		})
+		a.setInputs(inputs[:n])
+	}
+
+	// Processors
+	if processorsChanged {
+		log.Printf("D! [agent] Restarting processors")
+		if err := a.replaceProcessors(c.Processors, c.AggProcessors); err != nil {
+			return err
+		}
+	}
+
+	// Aggregators
+	aggregators := append([]*models.RunningAggregator(nil), c.Aggregators...)
+	for i, match := range aggMatches {
+		if match >= 0 {
+			aggregators[i] = runningAggregators[match]
+		}
+	}
+	kept := append([]*models.RunningAggregator(nil), runningAggregators...)
+	n := removeIndexes(len(kept), oldAggregators, func(dst, src int) {
+		kept[dst] = kept[src]
+	})
+	a.aggregatorMu.Lock()
+	a.Config.Aggregators = kept[:n]
+	a.aggregatorMu.Unlock()
+	for _, i := range oldAggregators {
+		agg := runningAggregators[i]
+		log.Printf("D! [agent] Stopping aggregator %s", agg.LogName())
+		a.aggregators[agg].stop()
+		delete(a.aggregators, agg)
+	}
+	for _, i := range newAggregators {
+		agg := aggregators[i]
+		log.Printf("D! [agent] Starting aggregator %s", agg.LogName())
+		a.startAggregator(a.aggregateCtx, time.Now(), agg, a.aggregateDst)
+	}
+	a.aggregatorMu.Lock()
+	a.Config.Aggregators = aggregators
+	a.aggregatorMu.Unlock()
+
+	// Outputs, the kept outputs are detached from old dead letter outputs
+	// before those are stopped and attached to new ones once running.
+	outputs := append([]*models.RunningOutput(nil), c.Outputs...)
+	for i, match := range outputMatches {
+		if match >= 0 {
+			outputs[i] = runningOutputs[match]
+		}
+	}
+	keptOutputs := append([]*models.RunningOutput(nil), runningOutputs...)
+	n = removeIndexes(len(keptOutputs), oldOutputs, func(dst, src int) {
+		keptOutputs[dst] = keptOutputs[src]
+	})
+	a.setOutputs(keptOutputs[:n])
+	for _, i := range oldOutputs {
+		output := runningOutputs[i]
+		log.Printf("D! [agent] Stopping output %s", output.LogName())
+		a.outputs[output].stop()
+		delete(a.outputs, output)
+		output.Close()
+	}
+	ignored = ignored[:0]
+	for _, i := range newOutputs {
+		output := outputs[i]
+		log.Printf("D! [agent] Starting output %s", output.LogName())
+		if err := output.Init(); err != nil {
+			return fmt.Errorf("could not initialize output %s: %v",
+				output.Config.Name, err)
+		}
+		err := a.connectOutput(a.runCtx, output)
+		if err == errPluginIgnored {
+			ignored = append(ignored, i)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		a.startOutput(a.outputCtx, a.startTime, output)
+	}
+	n = removeIndexes(len(outputs), ignored, func(dst, src int) {
+		outputs[dst] = outputs[src]
+	})
+	a.setOutputs(outputs[:n])
+
+	c.AcceptRemoteConfigs()
+	return nil
+}
+
+// pluginID identifies a plugin by its name and the fingerprint of its
+// configuration.
+func pluginID(name, fingerprint string) string {
+	return name + ":" + fingerprint
+}
+
+// diffPlugins compares the running plugins with the plugins of the new
+// configuration by their IDs.  For each new plugin matches holds the index of
+// the running plugin to keep or -1, started holds the indexes of the new
+// plugins that must be started and stopped the indexes of the running plugins
+// that must be stopped.
+func diffPlugins(running, updated []string) (matches, started, stopped []int) {
+	matches = matchFingerprints(running, updated)
+	for i, match := range matches {
+		if match < 0 {
+			started = append(started, i)
+		}
+	}
+	return matches, started, otherIndexes(len(running), matches)
+}
+
+// matchFingerprints pairs plugins of the new configuration with running
+// plugins of identical configuration.  The returned slice contains for each
+// new plugin the index of the running plugin to keep, or -1 if the new plugin
+// has to be started.
+func matchFingerprints(running, updated []string) []int {
+	unmatched := make(map[string][]int)
+	for i, fp := range running {
+		unmatched[fp] = append(unmatched[fp], i)
+	}
+
+	matches := make([]int, len(updated))
+	for i, fp := range updated {
+		matches[i] = -1
+		if indexes := unmatched[fp]; len(indexes) > 0 {
+			matches[i] = indexes[0]
+			unmatched[fp] = indexes[1:]
+		}
+	}
+	return matches
+}
+
+// otherIndexes returns the indexes below n that are not in indexes.
+func otherIndexes(n int, indexes []int) []int {
+	contained := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		if i >= 0 {
+			contained[i] = true
+		}
+	}
+
+	var others []int
+	for i := 0; i < n; i++ {
+		if !contained[i] {
+			others = append(others, i)
+		}
+	}
+	return others
+}
+
+// removeIndexes removes the elements at indexes from a slice of length n by
+// moving the remaining elements to the front with move, and returns the new
+// length of the slice.
+func removeIndexes(n int, indexes []int, move func(dst, src int)) int {
+	others := otherIndexes(n, indexes)
+	for dst, src := range others {
+		move(dst, src)
+	}
+	return len(others)
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchFingerprints(t *testing.T) {
+	matches := matchFingerprints(
+		[]string{"a", "b", "a", "c"},
+		[]string{"a", "d", "a", "a", "c"},
+	)
+	require.Equal(t, []int{0, -1, 2, -1, 3}, matches)
+	require.Equal(t, []int{1}, otherIndexes(4, matches))
+}
+
+func TestDiffPlugins(t *testing.T) {
+	matches, started, stopped := diffPlugins(
+		[]string{"cpu:1", "mem:1", "disk:1"},
+		[]string{"cpu:1", "mem:2", "net:1"},
+	)
+	require.Equal(t, []int{0, -1, -1}, matches)
+	require.Equal(t, []int{1, 2}, started)
+	require.Equal(t, []int{1, 2}, stopped)
+}
+
+func TestRemoveIndexes(t *testing.T) {
+	s := []string{"a", "b", "c", "d", "e"}
+	n := removeIndexes(len(s), []int{1, 3}, func(dst, src int) {
+		s[dst] = s[src]
+	})
+	require.Equal(t, []string{"a", "c", "e"}, s[:n])
+}
+
+func TestReload_NotApplied(t *testing.T) {
+	running := models.NewRunningInput(&testInput{}, &models.InputConfig{Name: "test"})
+	running.Fingerprint = "1"
+	c := config.NewConfig()
+	c.Inputs = []*models.RunningInput{running}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	a.runCtx = context.Background()
+	a.aggregateCtx = context.Background()
+	a.outputCtx = context.Background()
+
+	invalid := models.NewRunningInput(&invalidInput{}, &models.InputConfig{Name: "invalid"})
+	invalid.Fingerprint = "2"
+	nc := config.NewConfig()
+	nc.Inputs = []*models.RunningInput{running, invalid}
+
+	err = a.Reload(nc)
+	require.Error(t, err)
+	require.IsType(t, &NotAppliedError{}, err)
+	require.Equal(t, []*models.RunningInput{running}, a.Config.Inputs)
+}
+
+type testInput struct{}
+
+func (i *testInput) SampleConfig() string                  { return "" }
+func (i *testInput) Description() string                   { return "" }
+func (i *testInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+type invalidInput struct {
+	testInput
+}
+
+func (i *invalidInput) Init() error { return errors.New("invalid") }
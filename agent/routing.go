@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// outputRouting decides which outputs receive each metric.  Metrics are
+// broadcast to all outputs, except the outputs of router outputs which only
+// receive the metrics routed to them, and the dead letter outputs which only
+// receive the metrics rejected by other outputs.
+type outputRouting struct {
+	broadcast []*models.RunningOutput
+	routers   []*models.RunningOutput
+	byAlias   map[string]*models.RunningOutput
+}
+
+// routedAliases returns the aliases of the outputs of all router outputs and
+// of the dead letter outputs.
+func routedAliases(outputs []*models.RunningOutput) map[string]bool {
+	routed := make(map[string]bool)
+	for _, output := range outputs {
+		if output.Config.DeadLetterOutput != "" {
+			routed[output.Config.DeadLetterOutput] = true
+		}
+		if !output.IsRouter() {
+			continue
+		}
+		for _, alias := range output.RoutedOutputs() {
+			routed[alias] = true
+		}
+	}
+	return routed
+}
+
+func newOutputRouting(outputs []*models.RunningOutput) *outputRouting {
+	r := &outputRouting{
+		byAlias: make(map[string]*models.RunningOutput),
+	}
+
+	routed := routedAliases(outputs)
+	for _, output := range outputs {
+		switch {
+		case output.IsRouter():
+			r.routers = append(r.routers, output)
+		case routed[output.Config.Alias]:
+			r.byAlias[output.Config.Alias] = output
+		default:
+			r.broadcast = append(r.broadcast, output)
+		}
+	}
+
+	// Outputs whose dead letter output is not in the list are detached from
+	// it, so that it can be stopped.
+	for _, output := range outputs {
+		if output.Config.DeadLetterOutput != "" {
+			output.SetDeadLetterOutput(r.byAlias[output.Config.DeadLetterOutput])
+		}
+	}
+	return r
+}
+
+// addMetric adds the metric to the outputs it is broadcast or routed to.
+//
+// Takes ownership of metric
+func (r *outputRouting) addMetric(metric telegraf.Metric) {
+	outputs := r.broadcast
+	if len(r.routers) > 0 {
+		outputs = append([]*models.RunningOutput(nil), r.broadcast...)
+		for _, router := range r.routers {
+			for _, alias := range router.Route(metric) {
+				output, ok := r.byAlias[alias]
+				if ok && !containsOutput(outputs, output) {
+					outputs = append(outputs, output)
+				}
+			}
+		}
+	}
+
+	if len(outputs) == 0 {
+		metric.Drop()
+		return
+	}
+	for i, output := range outputs {
+		if i == len(outputs)-1 {
+			output.AddMetric(metric)
+		} else {
+			output.AddMetric(metric.Copy())
+		}
+	}
+}
+
+func containsOutput(outputs []*models.RunningOutput, output *models.RunningOutput) bool {
+	for _, o := range outputs {
+		if o == output {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/plugins/outputs/router"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputRouting(t *testing.T) {
+	newOutput := func(alias string) *models.RunningOutput {
+		return models.NewRunningOutput("test", &failingOutput{}, &models.OutputConfig{
+			Name:  "test",
+			Alias: alias,
+		}, 0, 0)
+	}
+
+	r := &router.Router{
+		Rules: []*router.Route{
+			{Name: "eu", Outputs: []string{"eu"}, TagPass: map[string][]string{"region": {"eu-*"}}},
+			{Name: "us", Outputs: []string{"us"}, TagPass: map[string][]string{"region": {"us-*"}}},
+		},
+		Default: []string{"other"},
+	}
+	ro := models.NewRunningOutput("router", r, &models.OutputConfig{
+		Name:  "router",
+		Alias: "TestOutputRouting",
+	}, 0, 0)
+	require.NoError(t, ro.Init())
+
+	eu, us, other, all := newOutput("eu"), newOutput("us"), newOutput("other"), newOutput("")
+	routing := newOutputRouting([]*models.RunningOutput{ro, eu, us, other, all})
+
+	for _, region := range []string{"eu-west", "eu-central", "us-east", "ap-south"} {
+		routing.addMetric(testutil.MustMetric("cpu",
+			map[string]string{"region": region},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0)))
+	}
+
+	require.Equal(t, 2, eu.BufferLength())
+	require.Equal(t, 1, us.BufferLength())
+	require.Equal(t, 1, other.BufferLength())
+	require.Equal(t, 4, all.BufferLength())
+	require.Equal(t, 0, ro.BufferLength())
+
+	require.Equal(t, map[string]int64{"metrics_routed": 2}, selfstat.Values("router",
+		map[string]string{"output": "router", "alias": "TestOutputRouting", "route": "eu"}))
+	require.Equal(t, map[string]int64{"metrics_routed": 1}, selfstat.Values("router",
+		map[string]string{"output": "router", "alias": "TestOutputRouting", "route": "default"}))
+}
+
+func TestOutputRouting_DeadLetter(t *testing.T) {
+	output := models.NewRunningOutput("test", &failingOutput{}, &models.OutputConfig{
+		Name:             "test",
+		DeadLetterOutput: "rejected",
+	}, 0, 0)
+	deadLetter := models.NewRunningOutput("test", &failingOutput{}, &models.OutputConfig{
+		Name:  "test",
+		Alias: "rejected",
+	}, 0, 0)
+	routing := newOutputRouting([]*models.RunningOutput{output, deadLetter})
+
+	routing.addMetric(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0)))
+
+	require.Equal(t, 1, output.BufferLength())
+	require.Equal(t, 0, deadLetter.BufferLength())
+}
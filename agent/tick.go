@@ -6,31 +6,75 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cron"
 )
 
+// Ticker sends the time of each tick on C, delayed by a random jitter.  Ticks
+// are dropped if the previous tick has not been received yet.
 type Ticker struct {
 	C          chan time.Time
-	ticker     *time.Ticker
+	next       func(time.Time) time.Time
 	jitter     time.Duration
 	wg         sync.WaitGroup
 	cancelFunc context.CancelFunc
 }
 
+// NewTicker returns a ticker that ticks every interval, starting one interval
+// from now.
 func NewTicker(
 	interval time.Duration,
 	jitter time.Duration,
+) *Ticker {
+	return newTicker(time.Now().Add(interval), intervalNext(interval), jitter)
+}
+
+// NewUnalignedTicker returns a ticker that ticks every interval, starting
+// offset from now.
+func NewUnalignedTicker(
+	interval time.Duration,
+	jitter time.Duration,
+	offset time.Duration,
+) *Ticker {
+	return newTicker(time.Now().Add(offset), intervalNext(interval), jitter)
+}
+
+// NewAlignedTicker returns a ticker that ticks on each multiple of interval
+// shifted by offset, starting at the first such time not before now.  With an
+// interval of 1m and an offset of 30s it ticks at :30 of every minute.
+func NewAlignedTicker(
+	now time.Time,
+	interval time.Duration,
+	jitter time.Duration,
+	offset time.Duration,
+) *Ticker {
+	return newTicker(alignedStart(now, interval, offset), intervalNext(interval), jitter)
+}
+
+// NewScheduleTicker returns a ticker that ticks at each time matching the
+// schedule.
+func NewScheduleTicker(
+	schedule *cron.Schedule,
+	jitter time.Duration,
+) *Ticker {
+	return newTicker(schedule.Next(time.Now()), schedule.Next, jitter)
+}
+
+func newTicker(
+	first time.Time,
+	next func(time.Time) time.Time,
+	jitter time.Duration,
 ) *Ticker {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	t := &Ticker{
 		C:          make(chan time.Time, 1),
-		ticker:     time.NewTicker(interval),
+		next:       next,
 		jitter:     jitter,
 		cancelFunc: cancel,
 	}
 
 	t.wg.Add(1)
-	go t.relayTime(ctx)
+	go t.relayTime(ctx, first)
 
 	return t
 }
@@ -40,18 +84,48 @@ func (t *Ticker) Stop() {
 	t.wg.Wait()
 }
 
-func (t *Ticker) relayTime(ctx context.Context) {
+func (t *Ticker) relayTime(ctx context.Context, tick time.Time) {
 	defer t.wg.Done()
-	for {
+	for !tick.IsZero() {
+		timer := time.NewTimer(time.Until(tick))
 		select {
-		case tm := <-t.ticker.C:
-			internal.SleepContext(ctx, internal.RandomDuration(t.jitter))
-			select {
-			case t.C <- tm:
-			default:
-			}
+		case <-timer.C:
 		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		err := internal.SleepContext(ctx, internal.RandomDuration(t.jitter))
+		if err != nil {
 			return
 		}
+
+		select {
+		case t.C <- tick:
+		default:
+		}
+
+		tick = nextTick(tick, time.Now(), t.next)
 	}
+	<-ctx.Done()
+}
+
+// nextTick returns the first tick after the previous tick that is not before
+// now, skipping the ticks missed while waiting.
+func nextTick(prev, now time.Time, next func(time.Time) time.Time) time.Time {
+	tick := next(prev)
+	for !tick.IsZero() && tick.Before(now) {
+		tick = next(tick)
+	}
+	return tick
+}
+
+func intervalNext(interval time.Duration) func(time.Time) time.Time {
+	return func(tm time.Time) time.Time {
+		return tm.Add(interval)
+	}
+}
+
+func alignedStart(now time.Time, interval, offset time.Duration) time.Time {
+	return internal.AlignTime(now.Add(-offset), interval).Add(offset)
 }
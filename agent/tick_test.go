@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/cron"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlignedStart(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		offset   time.Duration
+		expected time.Time
+	}{
+		{
+			name:     "aligned",
+			now:      time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC),
+			interval: time.Minute,
+			expected: time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC),
+		},
+		{
+			name:     "unaligned",
+			now:      time.Date(2020, 1, 1, 0, 1, 5, 0, time.UTC),
+			interval: time.Minute,
+			expected: time.Date(2020, 1, 1, 0, 2, 0, 0, time.UTC),
+		},
+		{
+			name:     "offset in this interval",
+			now:      time.Date(2020, 1, 1, 0, 1, 5, 0, time.UTC),
+			interval: time.Minute,
+			offset:   30 * time.Second,
+			expected: time.Date(2020, 1, 1, 0, 1, 30, 0, time.UTC),
+		},
+		{
+			name:     "offset in next interval",
+			now:      time.Date(2020, 1, 1, 0, 1, 45, 0, time.UTC),
+			interval: time.Minute,
+			offset:   30 * time.Second,
+			expected: time.Date(2020, 1, 1, 0, 2, 30, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := alignedStart(tt.now, tt.interval, tt.offset)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestNextTick_SkipsMissedTicks(t *testing.T) {
+	prev := time.Date(2020, 1, 1, 0, 0, 30, 0, time.UTC)
+	now := time.Date(2020, 1, 1, 0, 3, 10, 0, time.UTC)
+
+	tick := nextTick(prev, now, intervalNext(time.Minute))
+	require.Equal(t, time.Date(2020, 1, 1, 0, 3, 30, 0, time.UTC), tick)
+
+	schedule, err := cron.Parse("*/2 * * * *")
+	require.NoError(t, err)
+	tick = nextTick(prev, now, schedule.Next)
+	require.Equal(t, time.Date(2020, 1, 1, 0, 4, 0, 0, time.UTC), tick)
+}
+
+func TestUnalignedTicker(t *testing.T) {
+	ticker := NewUnalignedTicker(10*time.Millisecond, 0, 0)
+	defer ticker.Stop()
+
+	first := <-ticker.C
+	second := <-ticker.C
+	require.Equal(t, 10*time.Millisecond, second.Sub(first))
+}
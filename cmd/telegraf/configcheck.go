@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/influxdata/telegraf/internal/config"
+)
+
+// configFiles returns the config files given by the --config and
+// --config-directory flags, in the order they are loaded.
+func configFiles() ([]string, error) {
+	files := append([]string{}, fConfigs.paths()...)
+	if *fConfigDirectory != "" {
+		dirFiles, err := config.ConfigFiles(*fConfigDirectory)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+// runConfigCheck prints the problems found in the config files and returns
+// false if any errors were found.
+func runConfigCheck() (bool, error) {
+	files, err := configFiles()
+	if err != nil {
+		return false, err
+	}
+
+	var errs, warnings int
+	checker := config.NewChecker()
+	for _, file := range files {
+		problems, err := checker.Check(file)
+		if err != nil {
+			fmt.Println(err)
+			errs++
+			continue
+		}
+
+		for _, p := range problems {
+			fmt.Println(p)
+			if p.Severity == config.SeverityError {
+				errs++
+			} else {
+				warnings++
+			}
+		}
+	}
+
+	if errs == 0 && warnings == 0 {
+		fmt.Println("No problems found")
+	} else {
+		fmt.Printf("Found %d errors and %d warnings\n", errs, warnings)
+	}
+	return errs == 0, nil
+}
+
+// runConfigMigrate writes the migrated config of each config file with
+// deprecated plugins or options to <file>.migrated, and prints the
+// deprecations that must be migrated by hand.
+func runConfigMigrate() error {
+	if len(fConfigs) == 0 {
+		return errors.New("the --config flag must be set to migrate the configuration")
+	}
+
+	files, err := configFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if config.IsRemoteConfig(file) {
+			fmt.Printf("Skipping %s, remote configurations cannot be migrated\n", file)
+			continue
+		}
+
+		migrated, manual, err := config.MigrateConfig(file)
+		if err != nil {
+			return err
+		}
+
+		if migrated != nil {
+			if err := ioutil.WriteFile(file+".migrated", migrated, 0640); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s.migrated\n", file)
+		}
+		for _, p := range manual {
+			fmt.Println(p)
+		}
+	}
+	return nil
+}
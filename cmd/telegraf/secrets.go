@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+// runSecrets runs the secrets command:
+//
+//	telegraf secrets set <store-id> <key>
+//
+// The value of the secret is read from stdin so that it is not recorded in
+// the shell history.
+func runSecrets(args []string) error {
+	if len(args) != 3 || args[0] != "set" {
+		return errors.New("usage: telegraf [--config <file>] secrets set <store-id> <key>")
+	}
+	id, key := args[1], args[2]
+
+	c := config.NewConfig()
+	for _, path := range fConfigs.paths() {
+		if err := c.LoadSecretStores(path); err != nil {
+			return err
+		}
+	}
+
+	store, ok := c.SecretStores[id]
+	if !ok {
+		return fmt.Errorf("secret store %q not found", id)
+	}
+	setter, ok := store.(secretstores.Setter)
+	if !ok {
+		return fmt.Errorf("secret store %q does not support setting secrets", id)
+	}
+
+	b, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	return setter.Set(key, strings.TrimRight(string(b), "\r\n"))
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/outputs"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	_ "github.com/influxdata/telegraf/plugins/processors/all"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/all"
 	"github.com/kardianos/service"
 )
 
@@ -38,7 +39,11 @@ var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "enable test mode: gather metrics, print them out, and exit")
 var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for service inputs to complete in test mode")
-var fConfig = flag.String("config", "", "configuration file to load")
+var fConfigs configList
+var fConfigURLWatchInterval = flag.Duration("config-url-watch-interval", 0,
+	"interval to poll configuration URLs for changes, not polled if zero")
+var fConfigCacheDirectory = flag.String("config-cache-directory", "",
+	"directory to store the last good copy of configuration URLs")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
 var fVersion = flag.Bool("version", false, "display the version and exit")
@@ -77,6 +82,28 @@ var (
 
 var stop chan struct{}
 
+// configList is the list of configuration files and URLs given by the
+// --config flag, which may be repeated.
+type configList []string
+
+func (l *configList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *configList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// paths returns the configurations to load, the default configuration file
+// is loaded if none were given.
+func (l configList) paths() []string {
+	if len(l) == 0 {
+		return []string{""}
+	}
+	return l
+}
+
 func reloadLoop(
 	stop chan struct{},
 	inputFilters []string,
@@ -91,72 +118,136 @@ func reloadLoop(
 
 		ctx, cancel := context.WithCancel(context.Background())
 
+		log.Printf("I! Starting Telegraf %s", version)
+
+		c, err := loadConfig(inputFilters, outputFilters)
+		if err != nil {
+			log.Fatalf("E! [telegraf] Error running agent: %v", err)
+		}
+
+		ag, err := agent.NewAgent(c)
+		if err != nil {
+			log.Fatalf("E! [telegraf] Error running agent: %v", err)
+		}
+
 		signals := make(chan os.Signal)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config")
-					<-reload
-					reload <- true
+			for {
+				select {
+				case sig := <-signals:
+					if sig == syscall.SIGHUP {
+						log.Printf("I! Reloading Telegraf config")
+						if reloadPlugins(ag, inputFilters, outputFilters) {
+							continue
+						}
+						<-reload
+						reload <- true
+					}
+					cancel()
+				case <-stop:
+					cancel()
 				}
-				cancel()
-			case <-stop:
-				cancel()
+				return
 			}
 		}()
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+		// Reloads requested through the API take the same path as SIGHUP.
+		requestReload := func() {
+			go func() {
+				select {
+				case signals <- syscall.SIGHUP:
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		err = runAgent(ctx, ag, requestReload)
 		if err != nil && err != context.Canceled {
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
 	}
 }
 
-func runAgent(ctx context.Context,
+// reloadPlugins replaces the plugins of the running agent whose configuration
+// changed.  Returns false if the agent must be restarted instead.
+func reloadPlugins(
+	ag *agent.Agent,
 	inputFilters []string,
 	outputFilters []string,
-) error {
-	log.Printf("I! Starting Telegraf %s", version)
+) bool {
+	c, err := loadConfig(inputFilters, outputFilters)
+	if err != nil {
+		log.Printf("E! [telegraf] Error loading config, keeping current config: %v", err)
+		return true
+	}
+
+	err = ag.Reload(c)
+	if _, ok := err.(*agent.NotAppliedError); ok {
+		log.Printf("E! [telegraf] Error reloading plugins, keeping current config: %v", err)
+		return true
+	}
+	switch err {
+	case nil:
+		return true
+	case agent.ErrRestartRequired:
+		log.Printf("I! [telegraf] Agent settings changed, restarting")
+	default:
+		log.Printf("E! [telegraf] Error reloading plugins, restarting: %v", err)
+	}
+	return false
+}
 
+// loadConfig loads and validates the configuration files.
+func loadConfig(
+	inputFilters []string,
+	outputFilters []string,
+) (*config.Config, error) {
 	// If no other options are specified, load the config file and run.
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
-	err := c.LoadConfig(*fConfig)
-	if err != nil {
-		return err
+	c.ConfigCacheDirectory = *fConfigCacheDirectory
+	for _, path := range fConfigs.paths() {
+		err := c.LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if *fConfigDirectory != "" {
-		err = c.LoadDirectory(*fConfigDirectory)
+		err := c.LoadDirectory(*fConfigDirectory)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if !*fTest && len(c.Outputs) == 0 {
-		return errors.New("Error: no outputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no outputs found, did you provide a valid config file?")
 	}
 	if *fPlugins == "" && len(c.Inputs) == 0 {
-		return errors.New("Error: no inputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no inputs found, did you provide a valid config file?")
+	}
+
+	if err := c.CheckOutputRouting(); err != nil {
+		return nil, err
 	}
 
 	if int64(c.Agent.Interval.Duration) <= 0 {
-		return fmt.Errorf("Agent interval must be positive, found %s",
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
 			c.Agent.Interval.Duration)
 	}
 
 	if int64(c.Agent.FlushInterval.Duration) <= 0 {
-		return fmt.Errorf("Agent flush_interval must be positive; found %s",
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
 			c.Agent.Interval.Duration)
 	}
 
-	ag, err := agent.NewAgent(c)
-	if err != nil {
-		return err
-	}
+	return c, nil
+}
+
+func runAgent(ctx context.Context, ag *agent.Agent, requestReload func()) error {
+	c := ag.Config
 
 	// Setup logging as configured.
 	logConfig := logger.LogConfig{
@@ -200,9 +291,64 @@ func runAgent(ctx context.Context,
 		}
 	}
 
+	if c.Agent.APIAddress != "" {
+		listener, err := agent.ListenAPI(c.Agent.APIAddress)
+		if err != nil {
+			return err
+		}
+
+		server := &http.Server{Handler: agent.NewAPI(ag, requestReload)}
+		go func() {
+			err := server.Serve(listener)
+			if err != nil && err != http.ErrServerClosed {
+				log.Printf("E! [telegraf] Error serving API: %v", err)
+			}
+		}()
+		defer server.Close()
+
+		log.Printf("I! Started API at: %s", c.Agent.APIAddress)
+	}
+
+	if *fConfigURLWatchInterval > 0 && len(c.RemoteConfigs()) > 0 {
+		go watchRemoteConfigs(ctx, c, *fConfigURLWatchInterval, requestReload)
+	}
+
 	return ag.Run(ctx)
 }
 
+// watchRemoteConfigs polls the configurations loaded from URLs and requests
+// a reload when one of them changed.  Invalid configurations are reported
+// and ignored, the current configuration is kept.
+func watchRemoteConfigs(
+	ctx context.Context,
+	c *config.Config,
+	interval time.Duration,
+	requestReload func(),
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, u := range c.RemoteConfigs() {
+			changed, err := c.CheckRemoteConfig(u)
+			if err != nil {
+				log.Printf("E! [telegraf] Error checking config %s, keeping current config: %v", u, err)
+				continue
+			}
+			if changed {
+				log.Printf("I! [telegraf] Config %s changed, reloading", u)
+				requestReload()
+			}
+		}
+	}
+}
+
 func usageExit(rc int) {
 	fmt.Println(internal.Usage)
 	os.Exit(rc)
@@ -258,6 +404,7 @@ func formatFullVersion() string {
 }
 
 func main() {
+	flag.Var(&fConfigs, "config", "configuration file or URL to load, may be repeated")
 	flag.Usage = func() { usageExit(0) }
 	flag.Parse()
 	args := flag.Args()
@@ -314,6 +461,24 @@ func main() {
 			fmt.Println(formatFullVersion())
 			return
 		case "config":
+			if len(args) > 1 {
+				switch args[1] {
+				case "check":
+					ok, err := runConfigCheck()
+					if err != nil {
+						log.Fatalf("E! %s", err)
+					}
+					if !ok {
+						os.Exit(1)
+					}
+					return
+				case "migrate":
+					if err := runConfigMigrate(); err != nil {
+						log.Fatalf("E! %s", err)
+					}
+					return
+				}
+			}
 			config.PrintSampleConfig(
 				sectionFilters,
 				inputFilters,
@@ -322,6 +487,11 @@ func main() {
 				processorFilters,
 			)
 			return
+		case "secrets":
+			if err := runSecrets(args[1:]); err != nil {
+				log.Fatalf("E! %s", err)
+			}
+			return
 		}
 	}
 
@@ -406,8 +576,11 @@ func main() {
 		// Handle the --service flag here to prevent any issues with tooling that
 		// may not have an interactive session, e.g. installing from Ansible.
 		if *fService != "" {
-			if *fConfig != "" {
-				svcConfig.Arguments = []string{"--config", *fConfig}
+			if len(fConfigs) > 0 {
+				svcConfig.Arguments = nil
+				for _, path := range fConfigs {
+					svcConfig.Arguments = append(svcConfig.Arguments, "--config", path)
+				}
 			}
 			if *fConfigDirectory != "" {
 				svcConfig.Arguments = append(svcConfig.Arguments, "--config-directory", *fConfigDirectory)
@@ -0,0 +1,1000 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Expression is a compiled boolean expression over a metric.
+//
+// The expression can refer to the metric with the variables:
+//
+//   name     the measurement name
+//   tags     the tags, as tags.key or tags["key"]
+//   fields   the fields, as fields.key or fields["key"]
+//   time     the timestamp
+//
+// It supports the operators || && ! == != < <= > >= + - * / %, the "in"
+// operator to test if a key exists in tags or fields or if a value is in a
+// list such as ["a", "b"], the string methods startsWith, endsWith, contains
+// and matches (regular expression), and the functions now(), duration("1h")
+// and timestamp("2006-01-02T15:04:05Z").  Numbers are compared as floats,
+// times can be compared and shifted by durations:
+//
+//   e, _ := CompileExpression(`tags.env == "prod" && fields.usage_idle > 95`)
+//   e, _ := CompileExpression(`tags.region.startsWith("us-") || !("region" in tags)`)
+//   e, _ := CompileExpression(`time > now() - duration("1h")`)
+//
+// A tag or field missing from the metric has the value null, which is only
+// equal to null and never ordered.
+type Expression struct {
+	source string
+	root   node
+}
+
+// CompileExpression parses an expression.
+func CompileExpression(source string) (*Expression, error) {
+	p := &parser{lexer: newLexer(source)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression for the metric.  An error is returned if the
+// expression fails to evaluate or does not result in a boolean.
+func (e *Expression) Eval(metric telegraf.Metric) (bool, error) {
+	v, err := e.root.eval(&env{metric: metric, now: time.Now()})
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression result is %s, not bool", typeName(v))
+	}
+	return b, nil
+}
+
+type env struct {
+	metric telegraf.Metric
+	now    time.Time
+}
+
+// tagsValue and fieldsValue are the values of the tags and fields variables.
+type tagsValue struct{ metric telegraf.Metric }
+type fieldsValue struct{ metric telegraf.Metric }
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case time.Time:
+		return "timestamp"
+	case time.Duration:
+		return "duration"
+	case []interface{}:
+		return "list"
+	case tagsValue:
+		return "tags"
+	case fieldsValue:
+		return "fields"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type lexer struct {
+	src []rune
+	pos int
+}
+
+func newLexer(source string) *lexer {
+	return &lexer{src: []rune(source)}
+}
+
+var operators = []string{
+	"||", "&&", "==", "!=", "<=", ">=",
+	"!", "<", ">", "+", "-", "*", "/", "%", "(", ")", "[", "]", ".", ",",
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case c == '_' || unicode.IsLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' ||
+			unicode.IsLetter(l.src[l.pos]) || unicode.IsDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: string(l.src[start:l.pos]), pos: start}, nil
+	case unicode.IsDigit(c):
+		for l.pos < len(l.src) && (unicode.IsDigit(l.src[l.pos]) ||
+			l.src[l.pos] == '.' || l.src[l.pos] == 'e' || l.src[l.pos] == 'E' ||
+			((l.src[l.pos] == '+' || l.src[l.pos] == '-') &&
+				(l.src[l.pos-1] == 'e' || l.src[l.pos-1] == 'E'))) {
+			l.pos++
+		}
+		text := string(l.src[start:l.pos])
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return token{}, fmt.Errorf("invalid number %q at position %d", text, start)
+		}
+		return token{kind: tokNumber, text: text, value: v, pos: start}, nil
+	case c == '"' || c == '\'':
+		return l.lexString(c)
+	}
+
+	for _, op := range operators {
+		r := []rune(op)
+		if l.pos+len(r) <= len(l.src) && string(l.src[l.pos:l.pos+len(r)]) == op {
+			l.pos += len(r)
+			return token{kind: tokOperator, text: op, pos: start}, nil
+		}
+	}
+	return token{}, fmt.Errorf("unexpected character %q at position %d", c, start)
+}
+
+func (l *lexer) lexString(quote rune) (token, error) {
+	start := l.pos
+	l.pos++
+
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+		switch c {
+		case quote:
+			return token{
+				kind:  tokString,
+				text:  string(l.src[start:l.pos]),
+				value: sb.String(),
+				pos:   start,
+			}, nil
+		case '\\':
+			if l.pos >= len(l.src) {
+				break
+			}
+			e := l.src[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			default:
+				sb.WriteRune(e)
+			}
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return token{}, fmt.Errorf("unterminated string at position %d", start)
+}
+
+// Parser
+
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), p.tok.pos)
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	if p.tok.kind != tokOperator && !(p.tok.kind == tokIdent && p.tok.text == "in") {
+		return false
+	}
+	for _, op := range ops {
+		if p.tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOperator(op) {
+		return p.errorf("expected %q, found %s", op, p.tok)
+	}
+	return p.advance()
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOperator("==", "!=", "<", "<=", ">", ">=", "in") {
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+", "-") {
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*", "/", "%") {
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("!", "-") {
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.isOperator("."):
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.tok.kind != tokIdent {
+				return nil, p.errorf("expected name after \".\", found %s", p.tok)
+			}
+			member := p.tok.text
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			if !p.isOperator("(") {
+				n = &indexNode{target: n, index: &literalNode{value: member}}
+				continue
+			}
+
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			n, err = newMethodNode(n, member, args)
+			if err != nil {
+				return nil, err
+			}
+		case p.isOperator("["):
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexNode{target: n, index: index}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) parseArgs() ([]node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var args []node
+	for !p.isOperator(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, p.advance()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokNumber, tokString:
+		return &literalNode{value: tok.value}, p.advance()
+	case tokIdent:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		case "name", "tags", "fields", "time":
+			return &variableNode{name: tok.text}, nil
+		}
+
+		if !p.isOperator("(") {
+			return nil, fmt.Errorf("unknown variable %q at position %d", tok.text, tok.pos)
+		}
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		return newFunctionNode(tok, args)
+	case tokOperator:
+		switch tok.text {
+		case "(":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			list := &listNode{}
+			for !p.isOperator("]") {
+				if len(list.items) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+			}
+			return list, p.advance()
+		}
+	}
+	return nil, p.errorf("unexpected %s", tok)
+}
+
+// Evaluation
+
+type node interface {
+	eval(env *env) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(env *env) (interface{}, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(env *env) (interface{}, error) {
+	switch n.name {
+	case "name":
+		return env.metric.Name(), nil
+	case "tags":
+		return tagsValue{env.metric}, nil
+	case "fields":
+		return fieldsValue{env.metric}, nil
+	default:
+		return env.metric.Time(), nil
+	}
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(env *env) (interface{}, error) {
+	list := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+type indexNode struct {
+	target node
+	index  node
+}
+
+func (n *indexNode) eval(env *env) (interface{}, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := index.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot index %s with %s", typeName(target), typeName(index))
+	}
+
+	switch t := target.(type) {
+	case tagsValue:
+		if v, ok := t.metric.GetTag(key); ok {
+			return v, nil
+		}
+		return nil, nil
+	case fieldsValue:
+		if v, ok := t.metric.GetField(key); ok {
+			return fieldValue(v), nil
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("cannot index %s", typeName(target))
+	}
+}
+
+// fieldValue converts a field value to an expression value.
+func fieldValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(env *env) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := v.(type) {
+	case bool:
+		if n.op == "!" {
+			return !v, nil
+		}
+	case float64:
+		if n.op == "-" {
+			return -v, nil
+		}
+	case time.Duration:
+		if n.op == "-" {
+			return -v, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid operation %s%s", n.op, typeName(v))
+}
+
+type logicalNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n *logicalNode) eval(env *env) (interface{}, error) {
+	left, err := evalBool(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "||" && left {
+		return true, nil
+	}
+	if n.op == "&&" && !left {
+		return false, nil
+	}
+	return evalBool(n.right, env)
+}
+
+func evalBool(n node, env *env) (bool, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected bool, found %s", typeName(v))
+	}
+	return b, nil
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n *binaryNode) eval(env *env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	case "in":
+		return contains(left, right)
+	default:
+		return arithmetic(n.op, left, right)
+	}
+}
+
+func equal(left, right interface{}) bool {
+	switch l := left.(type) {
+	case time.Time:
+		r, ok := right.(time.Time)
+		return ok && l.Equal(r)
+	case []interface{}, tagsValue, fieldsValue:
+		return false
+	}
+	switch right.(type) {
+	case []interface{}, tagsValue, fieldsValue:
+		return false
+	}
+	return left == right
+}
+
+func compare(op string, left, right interface{}) (bool, error) {
+	// Missing tags and fields are never ordered.
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %s to %s", typeName(left), typeName(right))
+		}
+		cmp = compareFloat(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %s to %s", typeName(left), typeName(right))
+		}
+		cmp = strings.Compare(l, r)
+	case time.Time:
+		r, ok := right.(time.Time)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %s to %s", typeName(left), typeName(right))
+		}
+		if l.Before(r) {
+			cmp = -1
+		} else if l.After(r) {
+			cmp = 1
+		}
+	case time.Duration:
+		r, ok := right.(time.Duration)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %s to %s", typeName(left), typeName(right))
+		}
+		cmp = compareFloat(float64(l), float64(r))
+	default:
+		return false, fmt.Errorf("cannot compare %s to %s", typeName(left), typeName(right))
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func compareFloat(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func contains(item, container interface{}) (bool, error) {
+	switch c := container.(type) {
+	case tagsValue:
+		key, ok := item.(string)
+		return ok && c.metric.HasTag(key), nil
+	case fieldsValue:
+		key, ok := item.(string)
+		return ok && c.metric.HasField(key), nil
+	case []interface{}:
+		for _, v := range c {
+			if equal(item, v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		s, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot search %s in string", typeName(item))
+		}
+		return strings.Contains(c, s), nil
+	default:
+		return false, fmt.Errorf("cannot search in %s", typeName(container))
+	}
+}
+
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			switch op {
+			case "+":
+				return l + r, nil
+			case "-":
+				return l - r, nil
+			case "*":
+				return l * r, nil
+			case "/":
+				if r == 0 {
+					return nil, fmt.Errorf("division by zero")
+				}
+				return l / r, nil
+			case "%":
+				if r == 0 {
+					return nil, fmt.Errorf("division by zero")
+				}
+				return math.Mod(l, r), nil
+			}
+		}
+	case string:
+		if r, ok := right.(string); ok && op == "+" {
+			return l + r, nil
+		}
+	case time.Time:
+		switch r := right.(type) {
+		case time.Duration:
+			switch op {
+			case "+":
+				return l.Add(r), nil
+			case "-":
+				return l.Add(-r), nil
+			}
+		case time.Time:
+			if op == "-" {
+				return l.Sub(r), nil
+			}
+		}
+	case time.Duration:
+		switch r := right.(type) {
+		case time.Duration:
+			switch op {
+			case "+":
+				return l + r, nil
+			case "-":
+				return l - r, nil
+			}
+		case time.Time:
+			if op == "+" {
+				return r.Add(l), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("invalid operation %s %s %s", typeName(left), op, typeName(right))
+}
+
+type functionNode struct {
+	name string
+	arg  node
+}
+
+func newFunctionNode(tok token, args []node) (node, error) {
+	switch tok.text {
+	case "now":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("now() takes no arguments at position %d", tok.pos)
+		}
+		return &functionNode{name: tok.text}, nil
+	case "duration", "timestamp":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s() takes one argument at position %d", tok.text, tok.pos)
+		}
+		n := &functionNode{name: tok.text, arg: args[0]}
+
+		// Parse constant arguments only once.
+		if _, ok := args[0].(*literalNode); ok {
+			v, err := n.eval(nil)
+			if err != nil {
+				return nil, fmt.Errorf("%v at position %d", err, tok.pos)
+			}
+			return &literalNode{value: v}, nil
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("unknown function %q at position %d", tok.text, tok.pos)
+	}
+}
+
+func (n *functionNode) eval(env *env) (interface{}, error) {
+	if n.name == "now" {
+		return env.now, nil
+	}
+
+	v, err := n.arg.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s() expects a string, found %s", n.name, typeName(v))
+	}
+
+	if n.name == "duration" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q", s)
+		}
+		return d, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q", s)
+	}
+	return t, nil
+}
+
+type methodNode struct {
+	target node
+	method string
+	arg    node
+	regex  *regexp.Regexp
+}
+
+func newMethodNode(target node, method string, args []node) (node, error) {
+	switch method {
+	case "startsWith", "endsWith", "contains", "matches":
+	default:
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s() takes one argument", method)
+	}
+
+	n := &methodNode{target: target, method: method, arg: args[0]}
+
+	// Compile constant regular expressions only once.
+	if lit, ok := args[0].(*literalNode); ok && method == "matches" {
+		s, ok := lit.value.(string)
+		if !ok {
+			return nil, fmt.Errorf("matches() expects a string")
+		}
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", s, err)
+		}
+		n.regex = re
+	}
+	return n, nil
+}
+
+func (n *methodNode) eval(env *env) (interface{}, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// Methods of missing tags and fields are false, so that for example
+	// tags.region.startsWith("us-") does not fail without a region tag.
+	if target == nil {
+		return false, nil
+	}
+	s, ok := target.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s() called on %s, not string", n.method, typeName(target))
+	}
+
+	arg, err := n.arg.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := arg.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s() expects a string, found %s", n.method, typeName(arg))
+	}
+
+	switch n.method {
+	case "startsWith":
+		return strings.HasPrefix(s, a), nil
+	case "endsWith":
+		return strings.HasSuffix(s, a), nil
+	case "contains":
+		return strings.Contains(s, a), nil
+	default:
+		re := n.regex
+		if re == nil {
+			re, err = regexp.Compile(a)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %v", a, err)
+			}
+		}
+		return re.MatchString(s), nil
+	}
+}
@@ -0,0 +1,114 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpression(t *testing.T) {
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"env":    "prod",
+			"region": "us-east-1",
+		},
+		map[string]interface{}{
+			"usage_idle":   97.5,
+			"usage_system": int64(2),
+			"count":        uint64(10),
+			"healthy":      true,
+			"state":        "running",
+		},
+		time.Unix(1577836800, 0),
+	)
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`name == "cpu"`, true},
+		{`name != 'cpu'`, false},
+		{`fields.usage_idle > 95`, true},
+		{`fields.usage_idle > 95 && tags.env == "prod"`, true},
+		{`fields["usage_idle"] <= 95 || tags["env"] == "dev"`, false},
+		{`tags.env == "prod" && tags.region.startsWith("us-")`, true},
+		{`tags.region.endsWith("-1") && tags.region.contains("east")`, true},
+		{`tags.region.matches("^us-(east|west)-[0-9]+$")`, true},
+		{`fields.usage_system == 2 && fields.count >= 10`, true},
+		{`fields.usage_system + fields.count * 2 == 22`, true},
+		{`fields.count % 3 == 1 && -fields.count < 0`, true},
+		{`fields.usage_idle % 0.5 == 0`, true},
+		{`fields.usage_idle % 2 == 1.5`, true},
+		{`fields.healthy`, true},
+		{`!fields.healthy || fields.state != "running"`, false},
+		{`"env" in tags && !("host" in tags)`, true},
+		{`"usage_idle" in fields`, true},
+		{`tags.env in ["dev", "prod"]`, true},
+		{`"east" in tags.region`, true},
+		{`tags.host == null`, true},
+		{`tags.host == "a"`, false},
+		{`tags.host != "a"`, true},
+		{`fields.missing > 1`, false},
+		{`fields.missing < 1`, false},
+		{`tags.host.startsWith("a")`, false},
+		{`time == timestamp("2020-01-01T00:00:00Z")`, true},
+		{`time > timestamp("2020-01-01T00:00:00Z") - duration("1h")`, true},
+		{`time < now() - duration("24h")`, true},
+		{`now() - time > duration("1h")`, true},
+		{`(true || false) && !(false)`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := CompileExpression(tt.expr)
+			require.NoError(t, err)
+			actual, err := e.Eval(m)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestExpression_CompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`name ==`,
+		`name == "cpu`,
+		`host == "a"`,
+		`tags.env ==== "a"`,
+		`(name == "cpu"`,
+		`tags.env.upper()`,
+		`foo()`,
+		`duration("1 hour")`,
+		`tags.env.matches("(")`,
+		`name == "cpu" #`,
+	} {
+		_, err := CompileExpression(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestExpression_EvalErrors(t *testing.T) {
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{"env": "prod"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0),
+	)
+
+	for _, expr := range []string{
+		`name`,
+		`fields.value`,
+		`tags.env > 1`,
+		`fields.value && true`,
+		`fields.value / 0 == 1`,
+		`fields.value.startsWith("4")`,
+	} {
+		e, err := CompileExpression(expr)
+		require.NoError(t, err, expr)
+		_, err = e.Eval(m)
+		require.Error(t, err, expr)
+	}
+}
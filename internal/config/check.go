@@ -0,0 +1,358 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// unknownFieldRe matches the error returned by toml.UnmarshalTable for keys
+// not matching any field of the plugin.
+var unknownFieldRe = regexp.MustCompile("field corresponding to `(.+)' is not defined")
+
+// Severity is the severity of a Problem.
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Problem is an issue found in a config file.
+type Problem struct {
+	File     string
+	Line     int
+	Plugin   string
+	Severity Severity
+	Message  string
+}
+
+func (p Problem) String() string {
+	plugin := ""
+	if p.Plugin != "" {
+		plugin = p.Plugin + ": "
+	}
+	return fmt.Sprintf("%s:%d: %s: %s%s", p.File, p.Line, p.Severity, plugin, p.Message)
+}
+
+// Checker checks config files for unknown plugins and keys, invalid values,
+// and deprecated plugins and options, without creating secret stores or
+// plugins.
+//
+// Files should be checked in the order they are loaded, secret stores defined
+// in a file can be referenced in the files checked after it.
+type Checker struct {
+	// config is a scratch config the plugins are added to, to run them
+	// through the same code as when loading the config.
+	config *Config
+}
+
+func NewChecker() *Checker {
+	return &Checker{config: NewConfig()}
+}
+
+// Check checks the config file and returns the problems found, ordered by
+// line.  An error is returned if the file cannot be read or parsed.
+func (c *Checker) Check(path string) ([]Problem, error) {
+	var err error
+	if path == "" {
+		if path, err = getDefaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := loadConfig(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading %s, %s", path, err)
+	}
+
+	contents := expandEnvVars(trimBOM(data))
+	tbl, err := toml.Parse(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing %s, %s", path, err)
+	}
+
+	fc := &fileChecker{
+		config: c.config,
+		file:   path,
+		lines:  newLineIndex(contents),
+	}
+	fc.check(tbl)
+
+	sort.SliceStable(fc.problems, func(i, j int) bool {
+		return fc.problems[i].Line < fc.problems[j].Line
+	})
+	return fc.problems, nil
+}
+
+type fileChecker struct {
+	config   *Config
+	file     string
+	lines    lineIndex
+	problems []Problem
+}
+
+func (fc *fileChecker) add(severity Severity, line int, plugin, msg string) {
+	fc.problems = append(fc.problems, Problem{
+		File:     fc.file,
+		Line:     line,
+		Plugin:   plugin,
+		Severity: severity,
+		Message:  msg,
+	})
+}
+
+func (fc *fileChecker) check(tbl *ast.Table) {
+	if val, ok := tbl.Fields["agent"]; ok {
+		if t, ok := val.(*ast.Table); ok {
+			fc.checkKeys("agent", t, func() error {
+				return toml.UnmarshalTable(t, fc.config.Agent)
+			})
+		}
+	}
+
+	if val, ok := tbl.Fields["secretstores"]; ok {
+		if subTable, ok := val.(*ast.Table); ok {
+			for _, name := range sortedKeys(subTable) {
+				tables, _ := subTable.Fields[name].([]*ast.Table)
+				for _, t := range tables {
+					fc.checkSecretStore(name, t)
+				}
+			}
+		}
+	}
+
+	eachPlugin(tbl, fc.checkPlugin)
+}
+
+func (fc *fileChecker) checkSecretStore(name string, t *ast.Table) {
+	plugin := "secretstores." + name
+	creator, ok := secretstores.SecretStores[name]
+	if !ok {
+		fc.add(SeverityError, t.Line, plugin, "unknown plugin")
+		return
+	}
+
+	var id string
+	if kv, ok := t.Fields["id"].(*ast.KeyValue); ok {
+		if str, ok := kv.Value.(*ast.String); ok {
+			id = str.Value
+		}
+	}
+	if !secretStoreIDRe.MatchString(id) {
+		fc.add(SeverityError, t.Line, plugin,
+			"id must be set and contain only letters, digits and underscores")
+	} else {
+		fc.config.SecretStores[id] = placeholderStore{}
+	}
+
+	fc.checkKeys(plugin, t, func() error {
+		return toml.UnmarshalTable(t, creator())
+	})
+}
+
+func (fc *fileChecker) checkPlugin(kind, name string, t *ast.Table) {
+	plugin := kind + "." + name
+	if d, ok := deprecatedPlugins[plugin]; ok {
+		fc.add(SeverityWarning, t.Line, plugin, d.pluginMessage())
+	}
+
+	var add func(string, *ast.Table) error
+	switch kind {
+	case "inputs":
+		add = fc.config.addInput
+	case "outputs":
+		add = fc.config.addOutput
+	case "processors":
+		add = fc.config.addProcessor
+	case "aggregators":
+		add = fc.config.addAggregator
+	}
+
+	// Keys of deprecated options are not consumed when adding the plugin.
+	options := make(map[string]*ast.KeyValue)
+	for key, node := range t.Fields {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if _, ok := lookupDeprecatedOption(plugin, key, kv); ok {
+				options[key] = kv
+			}
+		}
+	}
+
+	if !fc.checkKeys(plugin, t, func() error { return add(name, t) }) {
+		return
+	}
+
+	for key, kv := range options {
+		// Unknown keys are removed from the table, only warn about the
+		// options supported by the plugin.
+		if _, ok := t.Fields[key]; !ok {
+			continue
+		}
+		d, _ := lookupDeprecatedOption(plugin, key, kv)
+		fc.add(SeverityWarning, fc.lines.keyLine(kv), plugin, d.optionMessage(key))
+	}
+}
+
+// checkKeys calls apply, which unmarshals the table, until it no longer fails
+// because of unknown keys.  Each unknown key is reported and removed from the
+// table before retrying.  It returns false if the plugin does not exist.
+func (fc *fileChecker) checkKeys(plugin string, t *ast.Table, apply func() error) bool {
+	for {
+		err := apply()
+		if err == nil {
+			return true
+		}
+
+		if _, ok := err.(*undefinedPluginError); ok {
+			fc.add(SeverityError, t.Line, plugin, "unknown plugin")
+			return false
+		}
+
+		lerr, ok := err.(*toml.LineError)
+		if !ok {
+			fc.add(SeverityError, t.Line, plugin, err.Error())
+			return true
+		}
+
+		if m := unknownFieldRe.FindStringSubmatch(lerr.Err.Error()); m != nil {
+			if parent, line, ok := fc.findKey(t, m[1], lerr.Line); ok {
+				fc.add(SeverityError, line, plugin, fmt.Sprintf("unknown key %q", m[1]))
+				delete(parent.Fields, m[1])
+				continue
+			}
+		}
+
+		msg := lerr.Err.Error()
+		if lerr.StructField != "" {
+			msg = lerr.StructField + ": " + msg
+		}
+		fc.add(SeverityError, lerr.Line, plugin, msg)
+		return true
+	}
+}
+
+// findKey returns the table containing the key reported at the line of an
+// unmarshal error, along with the line the key starts on.
+func (fc *fileChecker) findKey(t *ast.Table, key string, line int) (*ast.Table, int, bool) {
+	if node, ok := t.Fields[key]; ok {
+		switch node := node.(type) {
+		case *ast.KeyValue:
+			if node.Line == line {
+				return t, fc.lines.keyLine(node), true
+			}
+		case *ast.Table:
+			if node.Line == line {
+				return t, node.Line, true
+			}
+		case []*ast.Table:
+			for _, sub := range node {
+				if sub.Line == line {
+					return t, sub.Line, true
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(t) {
+		switch node := t.Fields[name].(type) {
+		case *ast.Table:
+			if parent, l, ok := fc.findKey(node, key, line); ok {
+				return parent, l, true
+			}
+		case []*ast.Table:
+			for _, sub := range node {
+				if parent, l, ok := fc.findKey(sub, key, line); ok {
+					return parent, l, true
+				}
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// eachPlugin calls fn with each plugin table of the config, in the same way
+// as LoadConfig.
+func eachPlugin(tbl *ast.Table, fn func(kind, name string, t *ast.Table)) {
+	for _, section := range sortedKeys(tbl) {
+		subTable, ok := tbl.Fields[section].(*ast.Table)
+		if !ok {
+			continue
+		}
+
+		kind := section
+		switch section {
+		case "agent", "global_tags", "tags", "secretstores":
+			continue
+		case "outputs", "inputs", "processors", "aggregators":
+		case "plugins":
+			kind = "inputs"
+		default:
+			// Legacy input table, such as [cpu]
+			fn("inputs", section, subTable)
+			continue
+		}
+
+		for _, name := range sortedKeys(subTable) {
+			switch pluginTable := subTable.Fields[name].(type) {
+			case *ast.Table:
+				fn(kind, name, pluginTable)
+			case []*ast.Table:
+				for _, t := range pluginTable {
+					fn(kind, name, t)
+				}
+			}
+		}
+	}
+}
+
+func sortedKeys(tbl *ast.Table) []string {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lineIndex maps offsets in the parsed contents of a config file to lines.
+type lineIndex []int
+
+func newLineIndex(contents []byte) lineIndex {
+	idx := lineIndex{0}
+	for i, r := range []rune(string(contents)) {
+		if r == '\n' {
+			idx = append(idx, i+1)
+		}
+	}
+	return idx
+}
+
+// line returns the line number of the rune offset, starting at 1.
+func (idx lineIndex) line(offset int) int {
+	return sort.Search(len(idx), func(i int) bool { return idx[i] > offset })
+}
+
+// keyLine returns the line the key of a key/value pair is on, the Line of
+// the KeyValue is the line its value ends on.
+func (idx lineIndex) keyLine(kv *ast.KeyValue) int {
+	return idx.line(kv.Value.Pos())
+}
+
+// placeholderStore stands in for the secret stores when checking, so that
+// secrets are not read.
+type placeholderStore struct{}
+
+func (placeholderStore) SampleConfig() string           { return "" }
+func (placeholderStore) Description() string            { return "" }
+func (placeholderStore) Get(key string) (string, error) { return "", nil }
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	_ "github.com/influxdata/telegraf/plugins/inputs/cpu"
+	_ "github.com/influxdata/telegraf/plugins/inputs/http"
+	_ "github.com/influxdata/telegraf/plugins/inputs/http_response"
+	_ "github.com/influxdata/telegraf/plugins/inputs/snmp_legacy"
+	_ "github.com/influxdata/telegraf/plugins/outputs/influxdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	problems, err := NewChecker().Check("./testdata/check.toml")
+	require.NoError(t, err)
+
+	var actual []string
+	for _, p := range problems {
+		actual = append(actual, p.String())
+	}
+
+	f := "./testdata/check.toml"
+	require.Equal(t, []string{
+		f + `:3: error: agent: unknown key "flush_intervall"`,
+		f + ":7: error: inputs.cpu: cpu.CPUStats.TotalCPU: cannot unmarshal TOML string into bool",
+		f + `:11: error: inputs.memcached: unknown key "unix_socket"`,
+		f + ":13: error: inputs.nonexistent: unknown plugin",
+		f + `:17: warning: inputs.http_response: option "address" is deprecated since 1.12, use "urls" instead`,
+		f + `:18: warning: inputs.http_response: option "ssl_ca" is deprecated since 1.7, use "tls_ca" instead`,
+		f + `:23: error: inputs.exec: unknown key "ssl_ca"`,
+		f + `:27: error: outputs.http: unknown key "timeoutt"`,
+	}, actual)
+}
+
+func TestChecker_Valid(t *testing.T) {
+	problems, err := NewChecker().Check("./testdata/single_plugin.toml")
+	require.NoError(t, err)
+	require.Empty(t, problems)
+}
+
+func TestMigrateConfig(t *testing.T) {
+	os.Setenv("AUTH_TOKEN", "secret")
+	defer os.Unsetenv("AUTH_TOKEN")
+
+	migrated, manual, err := MigrateConfig("./testdata/migrate.toml")
+	require.NoError(t, err)
+
+	expected, err := ioutil.ReadFile("./testdata/migrate.toml.migrated")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(migrated))
+
+	var actual []string
+	for _, p := range manual {
+		actual = append(actual, p.String())
+	}
+	f := "./testdata/migrate.toml"
+	require.ElementsMatch(t, []string{
+		f + `:2: warning: inputs.httpjson: the "server" tag is replaced by the "url" tag`,
+		f + `:11: warning: inputs.httpjson: parameters are not supported, add them to the query string of the urls or to the body for POST requests`,
+		f + `:21: warning: inputs.snmp_legacy: plugin is deprecated since 1.0, use inputs.snmp instead`,
+	}, actual)
+
+	// The migrated config has no remaining deprecations besides the manual
+	// ones.
+	tmp, err := ioutil.TempFile("", "telegraf-migrated")
+	require.NoError(t, err)
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(migrated)
+	require.NoError(t, err)
+	require.NoError(t, tmp.Close())
+
+	problems, err := NewChecker().Check(tmp.Name())
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0].Message, "plugin is deprecated")
+}
+
+func TestMigrateConfig_NothingToMigrate(t *testing.T) {
+	migrated, manual, err := MigrateConfig("./testdata/single_plugin.toml")
+	require.NoError(t, err)
+	require.Nil(t, migrated)
+	require.Empty(t, manual)
+}
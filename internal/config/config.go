@@ -2,13 +2,14 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -20,11 +21,14 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cron"
 	"github.com/influxdata/telegraf/internal/models"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
+	"github.com/influxdata/telegraf/plugins/parsers/xml"
 	"github.com/influxdata/telegraf/plugins/processors"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/toml"
@@ -66,6 +70,28 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+	// AggProcessors are a second instance of the Processors applied to the
+	// metrics emitted by aggregators, processors may be stateful so the
+	// instances cannot be shared.
+	AggProcessors models.RunningProcessors
+
+	// SecretStores are the secret stores by id, secrets are referenced in
+	// the configuration of other plugins as "@{<id>:<key>}".
+	SecretStores map[string]telegraf.SecretStore
+
+	// ConfigCacheDirectory is the directory where the last good copy of
+	// each configuration loaded over HTTP(S) is stored.  The cached copy is
+	// loaded if the server cannot be reached.
+	ConfigCacheDirectory string
+
+	remoteConfigs []string
+	// pendingRemoteConfigs are the loaded remote configurations by URL, not
+	// accepted yet.
+	pendingRemoteConfigs map[string]*remoteResponse
+
+	// secretStorePaths are the paths of the files defining the secret
+	// stores by id.
+	secretStorePaths map[string]string
 }
 
 func NewConfig() *Config {
@@ -83,8 +109,12 @@ func NewConfig() *Config {
 		Inputs:        make([]*models.RunningInput, 0),
 		Outputs:       make([]*models.RunningOutput, 0),
 		Processors:    make([]*models.RunningProcessor, 0),
+		AggProcessors: make([]*models.RunningProcessor, 0),
+		SecretStores:  make(map[string]telegraf.SecretStore),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
+
+		secretStorePaths: make(map[string]string),
 	}
 	return c
 }
@@ -131,6 +161,11 @@ type AgentConfig struct {
 	// not be less than 2 times MetricBatchSize.
 	MetricBufferLimit int
 
+	// BufferDirectory is the directory in which outputs using the "disk"
+	// buffer_strategy store their unwritten metrics.  Each output uses its own
+	// subdirectory.
+	BufferDirectory string `toml:"buffer_directory"`
+
 	// FlushBufferWhenFull tells Telegraf to flush the metric buffer whenever
 	// it fills up, regardless of FlushInterval. Setting this option to true
 	// does _not_ deactivate FlushInterval.
@@ -170,6 +205,10 @@ type AgentConfig struct {
 
 	Hostname     string
 	OmitHostname bool
+
+	// APIAddress is the address of the management API, either a unix socket
+	// or a loopback tcp address.  When empty the API is disabled.
+	APIAddress string `toml:"api_address"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -208,6 +247,67 @@ func (c *Config) OutputNames() []string {
 	return name
 }
 
+// CheckOutputRouting returns an error if a router output forwards to an
+// output that does not exist, is itself a router, or whose alias is not
+// unique.  The dead letter outputs are checked the same way.
+func (c *Config) CheckOutputRouting() error {
+	routed := make(map[string]bool)
+	for _, output := range c.Outputs {
+		for _, alias := range output.RoutedOutputs() {
+			routed[alias] = true
+		}
+		if output.Config.DeadLetterOutput != "" {
+			routed[output.Config.DeadLetterOutput] = true
+		}
+	}
+
+	byAlias := make(map[string]*models.RunningOutput)
+	for _, output := range c.Outputs {
+		alias := output.Config.Alias
+		if !routed[alias] {
+			continue
+		}
+		if _, ok := byAlias[alias]; ok {
+			return fmt.Errorf("more than one output with routed alias %q", alias)
+		}
+		byAlias[alias] = output
+	}
+
+	for _, router := range c.Outputs {
+		for _, alias := range router.RoutedOutputs() {
+			output, ok := byAlias[alias]
+			if !ok {
+				return fmt.Errorf("router %s: no output with alias %q",
+					router.LogName(), alias)
+			}
+			if output.IsRouter() {
+				return fmt.Errorf("router %s: output %q is a router",
+					router.LogName(), alias)
+			}
+		}
+	}
+
+	for _, output := range c.Outputs {
+		alias := output.Config.DeadLetterOutput
+		if alias == "" {
+			continue
+		}
+		target, ok := byAlias[alias]
+		switch {
+		case !ok:
+			return fmt.Errorf("output %s: no dead letter output with alias %q",
+				output.LogName(), alias)
+		case target == output:
+			return fmt.Errorf("output %s: dead letter output is the output itself",
+				output.LogName())
+		case target.IsRouter():
+			return fmt.Errorf("output %s: dead letter output %q is a router",
+				output.LogName(), alias)
+		}
+	}
+	return nil
+}
+
 // ListTags returns a string of tags specified in the config,
 // line-protocol style
 func (c *Config) ListTags() string {
@@ -266,6 +366,11 @@ var agentConfig = `
   ## cost of higher maximum memory usage.
   metric_buffer_limit = 10000
 
+  ## Directory used by outputs with buffer_strategy = "disk" to persist
+  ## unwritten metrics across restarts.  Each output uses a subdirectory named
+  ## after the plugin and its alias.
+  # buffer_directory = ""
+
   ## Collection jitter is used to jitter the collection by a random amount.
   ## Each plugin will sleep for a random time within jitter before collecting.
   ## This can be used to avoid many plugins querying things like sysfs at the
@@ -316,6 +421,13 @@ var agentConfig = `
   ## If set to -1, no archives are removed.
   # logfile_rotation_max_archives = 5
 
+  ## Address of the local management API, used to inspect the loaded plugins
+  ## and trigger a reload or flush.  The API is unauthenticated and only
+  ## listens on a unix socket or a loopback address.
+  ##   ex: api_address = "unix:///var/run/telegraf/telegraf.sock"
+  ##       api_address = "tcp://127.0.0.1:8089"
+  # api_address = ""
+
   ## Override default hostname, if empty use os.Hostname()
   hostname = ""
   ## If set to true, do no set the "host" tag in the telegraf agent.
@@ -409,6 +521,9 @@ func PrintSampleConfig(
 			for pname := range processors.Processors {
 				pnames = append(pnames, pname)
 			}
+			for pname := range processors.StreamingProcessors {
+				pnames = append(pnames, pname)
+			}
 			sort.Strings(pnames)
 			printFilteredProcessors(pnames, true)
 		}
@@ -463,11 +578,16 @@ func printFilteredProcessors(processorFilters []string, commented bool) {
 			pnames = append(pnames, pname)
 		}
 	}
+	for pname := range processors.StreamingProcessors {
+		if sliceContains(pname, processorFilters) {
+			pnames = append(pnames, pname)
+		}
+	}
 	sort.Strings(pnames)
 
 	// Print Outputs
 	for _, pname := range pnames {
-		creator := processors.Processors[pname]
+		creator, _ := processorCreator(pname)
 		output := creator()
 		printConfig(pname, output, "processors", commented)
 	}
@@ -619,6 +739,22 @@ func PrintOutputConfig(name string) error {
 }
 
 func (c *Config) LoadDirectory(path string) error {
+	files, err := ConfigFiles(path)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := c.LoadConfig(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigFiles returns the config files in the directory and its
+// subdirectories, in the order they are loaded by LoadDirectory.
+func ConfigFiles(path string) ([]string, error) {
+	var files []string
 	walkfn := func(thispath string, info os.FileInfo, _ error) error {
 		if info == nil {
 			log.Printf("W! Telegraf is not permitted to read %s", thispath)
@@ -637,13 +773,13 @@ func (c *Config) LoadDirectory(path string) error {
 		if len(name) < 6 || name[len(name)-5:] != ".conf" {
 			return nil
 		}
-		err := c.LoadConfig(thispath)
-		if err != nil {
-			return err
-		}
+		files = append(files, thispath)
 		return nil
 	}
-	return filepath.Walk(path, walkfn)
+	if err := filepath.Walk(path, walkfn); err != nil {
+		return nil, err
+	}
+	return files, nil
 }
 
 // Try to find a default config file at these locations (in order):
@@ -674,7 +810,7 @@ func getDefaultConfigPath() (string, error) {
 		" in $TELEGRAF_CONFIG_PATH, %s, or %s", homefile, etcfile)
 }
 
-// LoadConfig loads the given config file and applies it to c
+// LoadConfig loads the given config file or URL and applies it to c
 func (c *Config) LoadConfig(path string) error {
 	var err error
 	if path == "" {
@@ -682,11 +818,20 @@ func (c *Config) LoadConfig(path string) error {
 			return err
 		}
 	}
-	data, err := loadConfig(path)
+	if IsRemoteConfig(path) {
+		return c.loadRemoteConfig(path)
+	}
+
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("Error loading %s, %s", path, err)
 	}
+	return c.loadContents(path, data)
+}
 
+// loadContents parses the contents of the config file at path and applies it
+// to c.
+func (c *Config) loadContents(path string, data []byte) error {
 	tbl, err := parseConfig(data)
 	if err != nil {
 		return fmt.Errorf("Error parsing %s, %s", path, err)
@@ -731,6 +876,11 @@ func (c *Config) LoadConfig(path string) error {
 		c.Tags["host"] = c.Agent.Hostname
 	}
 
+	// Parse secret stores before the plugins referencing them:
+	if err = c.addSecretStores(path, tbl); err != nil {
+		return err
+	}
+
 	// Parse all the rest of the plugins:
 	for name, val := range tbl.Fields {
 		subTable, ok := val.(*ast.Table)
@@ -739,7 +889,7 @@ func (c *Config) LoadConfig(path string) error {
 		}
 
 		switch name {
-		case "agent", "global_tags", "tags":
+		case "agent", "global_tags", "tags", "secretstores":
 		case "outputs":
 			for pluginName, pluginVal := range subTable.Fields {
 				switch pluginSubTable := pluginVal.(type) {
@@ -817,11 +967,64 @@ func (c *Config) LoadConfig(path string) error {
 
 	if len(c.Processors) > 1 {
 		sort.Sort(c.Processors)
+		sort.Sort(c.AggProcessors)
 	}
 
 	return nil
 }
 
+// tableFingerprint returns a digest of the contents of a plugin table,
+// including any subtables.  Tables containing the same keys and values have
+// the same fingerprint regardless of formatting and key order.
+func tableFingerprint(tbl *ast.Table) string {
+	h := sha256.New()
+	writeTable(h, tbl)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeTable(w io.Writer, tbl *ast.Table) {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch node := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			fmt.Fprintf(w, "%q=", key)
+			writeValue(w, node.Value)
+			fmt.Fprintln(w)
+		case *ast.Table:
+			fmt.Fprintf(w, "[%q]\n", key)
+			writeTable(w, node)
+			fmt.Fprintln(w, "[]")
+		case []*ast.Table:
+			for _, t := range node {
+				fmt.Fprintf(w, "[[%q]]\n", key)
+				writeTable(w, t)
+				fmt.Fprintln(w, "[[]]")
+			}
+		}
+	}
+}
+
+func writeValue(w io.Writer, value ast.Value) {
+	switch v := value.(type) {
+	case *ast.String:
+		fmt.Fprintf(w, "%q", v.Value)
+	case *ast.Array:
+		fmt.Fprint(w, "[")
+		for _, elem := range v.Value {
+			writeValue(w, elem)
+			fmt.Fprint(w, ",")
+		}
+		fmt.Fprint(w, "]")
+	default:
+		fmt.Fprint(w, value.Source())
+	}
+}
+
 // trimBOM trims the Byte-Order-Marks from the beginning of the file.
 // this is for Windows compatibility only.
 // see https://github.com/influxdata/telegraf/issues/1378
@@ -835,50 +1038,26 @@ func escapeEnv(value string) string {
 }
 
 func loadConfig(config string) ([]byte, error) {
-	u, err := url.Parse(config)
-	if err != nil {
-		return nil, err
-	}
-
-	switch u.Scheme {
-	case "https", "http":
-		return fetchConfig(u)
-	default:
-		// If it isn't a https scheme, try it as a file.
+	if IsRemoteConfig(config) {
+		resp, err := getRemoteConfig(config).fetch()
+		if err != nil {
+			return nil, err
+		}
+		return resp.data, nil
 	}
 	return ioutil.ReadFile(config)
-
-}
-
-func fetchConfig(u *url.URL) ([]byte, error) {
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
-		req.Header.Add("Authorization", "Token "+v)
-	}
-	req.Header.Add("Accept", "application/toml")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
-	}
-
-	defer resp.Body.Close()
-	return ioutil.ReadAll(resp.Body)
 }
 
 // parseConfig loads a TOML configuration from a provided path and
 // returns the AST produced from the TOML parser. When loading the file, it
 // will find environment variables and replace them.
 func parseConfig(contents []byte) (*ast.Table, error) {
-	contents = trimBOM(contents)
+	return toml.Parse(expandEnvVars(trimBOM(contents)))
+}
 
+// expandEnvVars replaces the references to environment variables in the
+// contents of a config file with their values.
+func expandEnvVars(contents []byte) []byte {
 	parameters := envVarRe.FindAllSubmatch(contents, -1)
 	for _, parameter := range parameters {
 		if len(parameter) != 3 {
@@ -901,16 +1080,32 @@ func parseConfig(contents []byte) (*ast.Table, error) {
 		}
 	}
 
-	return toml.Parse(contents)
+	return contents
+}
+
+// undefinedPluginError is returned when a plugin of the configuration is not
+// compiled into Telegraf.
+type undefinedPluginError struct {
+	kind string
+	name string
+}
+
+func (e *undefinedPluginError) Error() string {
+	return fmt.Sprintf("Undefined but requested %s: %s", e.kind, e.name)
 }
 
 func (c *Config) addAggregator(name string, table *ast.Table) error {
 	creator, ok := aggregators.Aggregators[name]
 	if !ok {
-		return fmt.Errorf("Undefined but requested aggregator: %s", name)
+		return &undefinedPluginError{kind: "aggregator", name: name}
 	}
 	aggregator := creator()
 
+	if err := c.resolveSecrets(table); err != nil {
+		return fmt.Errorf("aggregator %s: %v", name, err)
+	}
+
+	fingerprint := tableFingerprint(table)
 	conf, err := buildAggregator(name, table)
 	if err != nil {
 		return err
@@ -920,42 +1115,147 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 		return err
 	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	ra := models.NewRunningAggregator(aggregator, conf)
+	ra.Fingerprint = fingerprint
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
 func (c *Config) addProcessor(name string, table *ast.Table) error {
-	creator, ok := processors.Processors[name]
+	creator, ok := processorCreator(name)
 	if !ok {
-		return fmt.Errorf("Undefined but requested processor: %s", name)
+		return &undefinedPluginError{kind: "processor", name: name}
+	}
+
+	if err := c.resolveSecrets(table); err != nil {
+		return fmt.Errorf("processor %s: %v", name, err)
 	}
-	processor := creator()
 
+	fingerprint := tableFingerprint(table)
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, processor); err != nil {
-		return err
+	// If the processor can parse or serialize metrics, such as the execd
+	// processor, build the configurations once for both instances.  Both share
+	// the data_format option.
+	var parserConfig *parsers.Config
+	var serializerConfig *serializers.Config
+	var probe interface{} = creator()
+	if p, ok := probe.(unwrappable); ok {
+		probe = p.Unwrap()
+	}
+	dataFormat := table.Fields["data_format"]
+	if _, ok := probe.(serializers.SerializerOutput); ok {
+		serializerConfig, err = getSerializerConfig(name, table)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := probe.(parsers.ParserInput); ok {
+		if dataFormat != nil {
+			table.Fields["data_format"] = dataFormat
+		}
+		parserConfig, err = getParserConfig(name, table)
+		if err != nil {
+			return err
+		}
 	}
 
-	rf := models.NewRunningProcessor(processor, processorConfig)
-
+	rf, err := newRunningProcessor(creator, processorConfig, parserConfig,
+		serializerConfig, table)
+	if err != nil {
+		return err
+	}
+	rf.Fingerprint = fingerprint
 	c.Processors = append(c.Processors, rf)
+
+	// Create a second instance for the metrics emitted by aggregators.
+	rf, err = newRunningProcessor(creator, processorConfig, parserConfig,
+		serializerConfig, table)
+	if err != nil {
+		return err
+	}
+	rf.Fingerprint = fingerprint
+	c.AggProcessors = append(c.AggProcessors, rf)
 	return nil
 }
 
+// processorCreator returns the creator of a registered processor.  Processors
+// that are not streaming processors are wrapped so that they can be run as a
+// telegraf.StreamingProcessor.
+func processorCreator(name string) (processors.StreamingCreator, bool) {
+	if creator, ok := processors.StreamingProcessors[name]; ok {
+		return creator, true
+	}
+	creator, ok := processors.Processors[name]
+	if !ok {
+		return nil, false
+	}
+	return func() telegraf.StreamingProcessor {
+		return processors.NewStreamingProcessorFromProcessor(creator())
+	}, true
+}
+
+// unwrappable is implemented by processors wrapping a telegraf.Processor.
+type unwrappable interface {
+	Unwrap() telegraf.Processor
+}
+
+func newRunningProcessor(
+	creator processors.StreamingCreator,
+	processorConfig *models.ProcessorConfig,
+	parserConfig *parsers.Config,
+	serializerConfig *serializers.Config,
+	table *ast.Table,
+) (*models.RunningProcessor, error) {
+	processor := creator()
+
+	var target interface{} = processor
+	if p, ok := processor.(unwrappable); ok {
+		target = p.Unwrap()
+	}
+
+	if t, ok := target.(parsers.ParserInput); ok && parserConfig != nil {
+		parser, err := parsers.NewParser(parserConfig)
+		if err != nil {
+			return nil, err
+		}
+		t.SetParser(parser)
+	}
+
+	if t, ok := target.(serializers.SerializerOutput); ok && serializerConfig != nil {
+		serializer, err := serializers.NewSerializer(serializerConfig)
+		if err != nil {
+			return nil, err
+		}
+		t.SetSerializer(serializer)
+	}
+
+	if err := toml.UnmarshalTable(table, target); err != nil {
+		return nil, err
+	}
+
+	return models.NewRunningProcessor(processor, processorConfig), nil
+}
+
 func (c *Config) addOutput(name string, table *ast.Table) error {
 	if len(c.OutputFilters) > 0 && !sliceContains(name, c.OutputFilters) {
 		return nil
 	}
 	creator, ok := outputs.Outputs[name]
 	if !ok {
-		return fmt.Errorf("Undefined but requested output: %s", name)
+		return &undefinedPluginError{kind: "output", name: name}
 	}
 	output := creator()
 
+	if err := c.resolveSecrets(table); err != nil {
+		return fmt.Errorf("output %s: %v", name, err)
+	}
+
+	fingerprint := tableFingerprint(table)
+
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
 	switch t := output.(type) {
@@ -972,12 +1272,37 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return err
 	}
 
+	if outputConfig.MaxConcurrentWrites > 1 {
+		if _, ok := output.(telegraf.ConcurrentOutput); !ok {
+			return fmt.Errorf("output %s does not support max_concurrent_writes", name)
+		}
+	}
+
+	if outputConfig.BufferStrategy == models.BufferStrategyDisk {
+		if c.Agent.BufferDirectory == "" {
+			return fmt.Errorf("buffer_directory must be set in the agent table to use the disk buffer strategy")
+		}
+
+		dir := name
+		if outputConfig.Alias != "" {
+			dir = name + "-" + outputConfig.Alias
+		}
+		outputConfig.BufferDirectory = filepath.Join(c.Agent.BufferDirectory, dir)
+
+		for _, ro := range c.Outputs {
+			if ro.Config.BufferDirectory == outputConfig.BufferDirectory {
+				return fmt.Errorf("outputs using the disk buffer strategy must have a unique alias: %s", name)
+			}
+		}
+	}
+
 	if err := toml.UnmarshalTable(table, output); err != nil {
 		return err
 	}
 
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
+	ro.Fingerprint = fingerprint
 	c.Outputs = append(c.Outputs, ro)
 	return nil
 }
@@ -993,10 +1318,16 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 
 	creator, ok := inputs.Inputs[name]
 	if !ok {
-		return fmt.Errorf("Undefined but requested input: %s", name)
+		return &undefinedPluginError{kind: "input", name: name}
 	}
 	input := creator()
 
+	if err := c.resolveSecrets(table); err != nil {
+		return fmt.Errorf("input %s: %v", name, err)
+	}
+
+	fingerprint := tableFingerprint(table)
+
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
 	switch t := input.(type) {
@@ -1029,6 +1360,7 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
+	rp.Fingerprint = fingerprint
 	rp.SetDefaultTags(c.Tags)
 	c.Inputs = append(c.Inputs, rp)
 	return nil
@@ -1043,6 +1375,8 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		Delay:  time.Millisecond * 100,
 		Period: time.Second * 30,
 		Grace:  time.Second * 0,
+
+		LateMetrics: models.LateMetricsDrop,
 	}
 
 	if node, ok := tbl.Fields["period"]; ok {
@@ -1083,6 +1417,47 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 			}
 		}
 	}
+
+	if node, ok := tbl.Fields["align_windows"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				conf.AlignWindows, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid align_windows %q", b.Value)
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["window_grace"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				if dur < 0 {
+					return nil, fmt.Errorf("invalid window_grace %q", str.Value)
+				}
+
+				conf.WindowGrace = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["late_metrics"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				switch str.Value {
+				case models.LateMetricsDrop, models.LateMetricsCurrent:
+					conf.LateMetrics = str.Value
+				default:
+					return nil, fmt.Errorf("invalid late_metrics %q", str.Value)
+				}
+			}
+		}
+	}
 	if node, ok := tbl.Fields["drop_original"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if b, ok := kv.Value.(*ast.Boolean); ok {
@@ -1139,6 +1514,9 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	delete(tbl.Fields, "period")
 	delete(tbl.Fields, "delay")
 	delete(tbl.Fields, "grace")
+	delete(tbl.Fields, "align_windows")
+	delete(tbl.Fields, "window_grace")
+	delete(tbl.Fields, "late_metrics")
 	delete(tbl.Fields, "drop_original")
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
@@ -1309,6 +1687,15 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 			}
 		}
 	}
+
+	if node, ok := tbl.Fields["metricpass"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				f.MetricPass = str.Value
+			}
+		}
+	}
+
 	if err := f.Compile(); err != nil {
 		return f, err
 	}
@@ -1323,6 +1710,7 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 	delete(tbl.Fields, "tagpass")
 	delete(tbl.Fields, "tagexclude")
 	delete(tbl.Fields, "taginclude")
+	delete(tbl.Fields, "metricpass")
 	return f, nil
 }
 
@@ -1344,6 +1732,66 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["collection_jitter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.CollectionJitter = new(time.Duration)
+				*cp.CollectionJitter = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["collection_offset"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.CollectionOffset = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["schedule"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				schedule, err := cron.Parse(str.Value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid schedule: %v", err)
+				}
+
+				cp.Schedule = schedule
+			}
+		}
+	}
+
+	if cp.Schedule != nil && cp.CollectionOffset != 0 {
+		return nil, fmt.Errorf("collection_offset cannot be used with schedule")
+	}
+
+	var err error
+	cp.Precision, err = buildPrecision(tbl)
+	if err != nil {
+		return nil, err
+	}
+
+	cp.StartupErrorBehavior, err = buildStartupErrorBehavior(tbl)
+	if err != nil {
+		return nil, err
+	}
+
+	cp.Cardinality, err = buildCardinality(tbl)
+	if err != nil {
+		return nil, err
+	}
+
 	if node, ok := tbl.Fields["name_prefix"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1390,8 +1838,10 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
 	delete(tbl.Fields, "interval")
+	delete(tbl.Fields, "collection_jitter")
+	delete(tbl.Fields, "collection_offset")
+	delete(tbl.Fields, "schedule")
 	delete(tbl.Fields, "tags")
-	var err error
 	cp.Filter, err = buildFilter(tbl)
 	if err != nil {
 		return cp, err
@@ -1810,6 +2260,42 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["prometheus_metric_version"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.PrometheusMetricVersion = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml"]; ok {
+		if subtbls, ok := node.([]*ast.Table); ok {
+			for _, subtbl := range subtbls {
+				var xc xml.Config
+				if err := toml.UnmarshalTable(subtbl, &xc); err != nil {
+					return nil, fmt.Errorf("invalid xml configuration: %v", err)
+				}
+				c.XMLConfig = append(c.XMLConfig, xc)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_v2"]; ok {
+		if subtbls, ok := node.([]*ast.Table); ok {
+			for _, subtbl := range subtbls {
+				var jc json_v2.Config
+				if err := toml.UnmarshalTable(subtbl, &jc); err != nil {
+					return nil, fmt.Errorf("invalid json_v2 configuration: %v", err)
+				}
+				c.JSONV2Config = append(c.JSONV2Config, jc)
+			}
+		}
+	}
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
@@ -1853,6 +2339,9 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "csv_timestamp_format")
 	delete(tbl.Fields, "csv_trim_space")
 	delete(tbl.Fields, "form_urlencoded_tag_keys")
+	delete(tbl.Fields, "prometheus_metric_version")
+	delete(tbl.Fields, "xml")
+	delete(tbl.Fields, "json_v2")
 
 	return c, nil
 }
@@ -1861,6 +2350,14 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 // a serializers.Serializer object, and creates it, which can then be added onto
 // an Output object.
 func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error) {
+	config, err := getSerializerConfig(name, tbl)
+	if err != nil {
+		return nil, err
+	}
+	return serializers.NewSerializer(config)
+}
+
+func getSerializerConfig(name string, tbl *ast.Table) (*serializers.Config, error) {
 	c := &serializers.Config{TimestampUnits: time.Duration(1 * time.Second)}
 
 	if node, ok := tbl.Fields["data_format"]; ok {
@@ -2039,6 +2536,58 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["csv_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVColumns = append(c.CSVColumns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_separator"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVSeparator = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_header"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.CSVHeader, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_pivot"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.CSVPivot, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "influx_max_line_bytes")
 	delete(tbl.Fields, "influx_sort_fields")
 	delete(tbl.Fields, "influx_uint_support")
@@ -2054,7 +2603,12 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 	delete(tbl.Fields, "prometheus_export_timestamp")
 	delete(tbl.Fields, "prometheus_sort_metrics")
 	delete(tbl.Fields, "prometheus_string_as_label")
-	return serializers.NewSerializer(c)
+	delete(tbl.Fields, "csv_columns")
+	delete(tbl.Fields, "csv_separator")
+	delete(tbl.Fields, "csv_header")
+	delete(tbl.Fields, "csv_timestamp_format")
+	delete(tbl.Fields, "csv_pivot")
+	return c, nil
 }
 
 // buildOutput parses output specific items from the ast.Table,
@@ -2130,6 +2684,66 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["max_concurrent_writes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.MaxConcurrentWrites = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_backoff_initial"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				oc.RetryBackoffInitial = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_backoff_max"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				oc.RetryBackoffMax = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["dead_letter_file"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.DeadLetterFile = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["dead_letter_output"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.DeadLetterOutput = str.Value
+			}
+		}
+	}
+
+	if oc.DeadLetterFile != "" && oc.DeadLetterOutput != "" {
+		return nil, fmt.Errorf("dead_letter_file and dead_letter_output cannot both be set")
+	}
+
+	if oc.MaxConcurrentWrites < 0 {
+		return nil, fmt.Errorf("invalid max_concurrent_writes %d", oc.MaxConcurrentWrites)
+	}
+
 	if node, ok := tbl.Fields["alias"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -2138,11 +2752,145 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["buffer_strategy"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferStrategy = str.Value
+			}
+		}
+	}
+
+	switch oc.BufferStrategy {
+	case "", models.BufferStrategyMemory, models.BufferStrategyDisk:
+	default:
+		return nil, fmt.Errorf("invalid buffer_strategy %q", oc.BufferStrategy)
+	}
+
+	oc.Precision, err = buildPrecision(tbl)
+	if err != nil {
+		return nil, err
+	}
+
+	oc.StartupErrorBehavior, err = buildStartupErrorBehavior(tbl)
+	if err != nil {
+		return nil, err
+	}
+
+	oc.Cardinality, err = buildCardinality(tbl)
+	if err != nil {
+		return nil, err
+	}
+
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "flush_jitter")
 	delete(tbl.Fields, "metric_buffer_limit")
 	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "max_concurrent_writes")
+	delete(tbl.Fields, "retry_backoff_initial")
+	delete(tbl.Fields, "retry_backoff_max")
+	delete(tbl.Fields, "dead_letter_file")
+	delete(tbl.Fields, "dead_letter_output")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "buffer_strategy")
 
 	return oc, nil
 }
+
+// buildCardinality parses and removes the cardinality limit options of an
+// input or output.
+func buildCardinality(tbl *ast.Table) (models.CardinalityConfig, error) {
+	var cc models.CardinalityConfig
+	if node, ok := tbl.Fields["cardinality_limit"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return cc, err
+				}
+				cc.Limit = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["cardinality_limit_action"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cc.Action = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["cardinality_collapse_tags"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						cc.CollapseTags = append(cc.CollapseTags, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["cardinality_expiration"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return cc, err
+				}
+				cc.Expiration = dur
+			}
+		}
+	}
+
+	delete(tbl.Fields, "cardinality_limit")
+	delete(tbl.Fields, "cardinality_limit_action")
+	delete(tbl.Fields, "cardinality_collapse_tags")
+	delete(tbl.Fields, "cardinality_expiration")
+
+	return cc, cc.Validate()
+}
+
+// buildPrecision parses and removes the precision option of an input or
+// output.  A precision that is not a duration is left in the table, as some
+// plugins have a deprecated precision option set as a unit such as "s".
+func buildPrecision(tbl *ast.Table) (time.Duration, error) {
+	node, ok := tbl.Fields["precision"]
+	if !ok {
+		return 0, nil
+	}
+	kv, ok := node.(*ast.KeyValue)
+	if !ok || !isDuration(kv) {
+		return 0, nil
+	}
+	delete(tbl.Fields, "precision")
+
+	precision, _ := time.ParseDuration(kv.Value.(*ast.String).Value)
+	if precision < 0 {
+		return 0, fmt.Errorf("invalid precision %q", kv.Value.(*ast.String).Value)
+	}
+	return precision, nil
+}
+
+// buildStartupErrorBehavior parses and removes the startup_error_behavior
+// option of an input or output.
+func buildStartupErrorBehavior(tbl *ast.Table) (string, error) {
+	var behavior string
+	if node, ok := tbl.Fields["startup_error_behavior"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				behavior = str.Value
+			}
+		}
+	}
+	delete(tbl.Fields, "startup_error_behavior")
+
+	switch behavior {
+	case "", models.StartupErrorBehaviorError, models.StartupErrorBehaviorRetry,
+		models.StartupErrorBehaviorIgnore:
+		return behavior, nil
+	default:
+		return "", fmt.Errorf("invalid startup_error_behavior %q", behavior)
+	}
+}
@@ -1,19 +1,30 @@
 package config
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
+	_ "github.com/influxdata/telegraf/plugins/aggregators/minmax"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/exec"
 	"github.com/influxdata/telegraf/plugins/inputs/http_listener_v2"
 	"github.com/influxdata/telegraf/plugins/inputs/memcached"
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
+	_ "github.com/influxdata/telegraf/plugins/outputs/file"
 	httpOut "github.com/influxdata/telegraf/plugins/outputs/http"
+	_ "github.com/influxdata/telegraf/plugins/outputs/router"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
+	"github.com/influxdata/telegraf/plugins/parsers/xml"
+	_ "github.com/influxdata/telegraf/plugins/processors/override"
+	_ "github.com/influxdata/telegraf/plugins/processors/rename"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/os"
+	"github.com/influxdata/toml"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -187,6 +198,78 @@ func TestConfig_LoadDirectory(t *testing.T) {
 		"Merged Testdata did not produce correct procstat metadata.")
 }
 
+func TestConfig_XMLParser(t *testing.T) {
+	tbl, err := toml.Parse([]byte(`
+data_format = "xml"
+
+[[xml]]
+  metric_selection = "//Outlet"
+  [xml.tags]
+    outlet = "@name"
+  [xml.fields]
+    load = "number(Load)"
+
+[[xml]]
+  metric_name = "'ups'"
+  [xml.fields_int]
+    runtime = "//Runtime"
+`))
+	require.NoError(t, err)
+
+	c, err := getParserConfig("file", tbl)
+	require.NoError(t, err)
+	require.Equal(t, []xml.Config{
+		{
+			Selection: "//Outlet",
+			Tags:      map[string]string{"outlet": "@name"},
+			Fields:    map[string]string{"load": "number(Load)"},
+		},
+		{
+			MetricQuery: "'ups'",
+			FieldsInt:   map[string]string{"runtime": "//Runtime"},
+		},
+	}, c.XMLConfig)
+	require.NotContains(t, tbl.Fields, "xml")
+}
+
+func TestConfig_JSONV2Parser(t *testing.T) {
+	tbl, err := toml.Parse([]byte(`
+data_format = "json_v2"
+
+[[json_v2]]
+  measurement_name = "interface"
+  [[json_v2.tag]]
+    path = "host"
+  [[json_v2.field]]
+    path = "uptime"
+    type = "int"
+  [[json_v2.object]]
+    path = "interfaces"
+    tags = ["name"]
+    [json_v2.object.fields]
+      rx_bytes = "uint"
+`))
+	require.NoError(t, err)
+
+	c, err := getParserConfig("file", tbl)
+	require.NoError(t, err)
+	require.Equal(t, []json_v2.Config{
+		{
+			MeasurementName: "interface",
+			Tags:            []json_v2.DataSet{{Path: "host"}},
+			Fields:          []json_v2.DataSet{{Path: "uptime", Type: "int"}},
+			Objects: []json_v2.Object{
+				{
+					Path:   "interfaces",
+					Tags:   []string{"name"},
+					Fields: map[string]string{"rx_bytes": "uint"},
+				},
+			},
+		},
+	}, c.JSONV2Config)
+	require.NotContains(t, tbl.Fields, "json_v2")
+}
+
 func TestConfig_LoadSpecialTypes(t *testing.T) {
 	c := NewConfig()
 	err := c.LoadConfig("./testdata/special_types.toml")
@@ -257,3 +340,265 @@ func TestConfig_BadOrdering(t *testing.T) {
 	require.Error(t, err, "bad ordering")
 	assert.Equal(t, "Error parsing ./testdata/non_slice_slice.toml, line 4: cannot unmarshal TOML array into string (need slice)", err.Error())
 }
+
+func TestConfig_TableFingerprint(t *testing.T) {
+	parse := func(s string) string {
+		tbl, err := parseConfig([]byte(s))
+		require.NoError(t, err)
+		return tableFingerprint(tbl)
+	}
+
+	a := parse(`
+servers = ["localhost:11211"]
+interval = "10s"
+[tags]
+  dc = "us-east-1"
+`)
+	b := parse(`
+interval="10s"
+servers = [ "localhost:11211" ]  # comment
+  [tags]
+  dc = "us-east-1"
+`)
+	c := parse(`
+servers = ["localhost:11211"]
+interval = "10s"
+[tags]
+  dc = "us-west-1"
+`)
+	require.Equal(t, a, b)
+	require.NotEqual(t, a, c)
+}
+
+func TestConfig_Processors(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/processors.toml")
+	require.NoError(t, err)
+
+	require.Len(t, c.Processors, 2)
+	require.Len(t, c.AggProcessors, 2)
+	for i := range c.Processors {
+		require.Equal(t, c.Processors[i].Config, c.AggProcessors[i].Config)
+		require.Equal(t, c.Processors[i].Processor, c.AggProcessors[i].Processor)
+		require.False(t, c.Processors[i].Processor == c.AggProcessors[i].Processor,
+			"processors must not share an instance")
+	}
+	require.Equal(t, "override", c.Processors[0].Config.Name)
+	require.Equal(t, "rename", c.Processors[1].Config.Name)
+}
+
+func TestConfig_SecretStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyring := filepath.Join(dir, "keyring")
+	require.NoError(t, os.MkdirAll(filepath.Join(keyring, "telegraf"), 0700))
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(keyring, "telegraf", "password"), []byte("hunter2\n"), 0600))
+
+	path := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+[[secretstores.os]]
+  id = "keyring"
+  keyring_dir = "`+keyring+`"
+
+[[inputs.http_listener_v2]]
+  service_address = ":8080"
+  basic_username = "telegraf"
+  basic_password = "@{keyring:password}"
+  path = "/write/@@{keyring:password}"
+`), 0600))
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig(path))
+	require.Len(t, c.Inputs, 1)
+
+	input := c.Inputs[0].Input.(*http_listener_v2.HTTPListenerV2)
+	require.Equal(t, "telegraf", input.BasicUsername)
+	require.Equal(t, "hunter2", input.BasicPassword)
+	require.Equal(t, "/write/@{keyring:password}", input.Path)
+
+	// A rotated secret changes the fingerprint, restarting the plugin on
+	// reload.
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(keyring, "telegraf", "password"), []byte("hunter3\n"), 0600))
+
+	c2 := NewConfig()
+	require.NoError(t, c2.LoadConfig(path))
+	require.Equal(t, "hunter3",
+		c2.Inputs[0].Input.(*http_listener_v2.HTTPListenerV2).BasicPassword)
+	require.NotEqual(t, c.Inputs[0].Fingerprint, c2.Inputs[0].Fingerprint)
+}
+
+func TestConfig_SecretStoreErrors(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/secrets_unknown_store.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unknown secret store "vault"`)
+
+	c = NewConfig()
+	err = c.LoadConfig("./testdata/secrets_missing_id.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "id must be set")
+}
+
+func TestConfig_Scheduling(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/scheduling.toml"))
+	require.Len(t, c.Inputs, 2)
+
+	offset := c.Inputs[0].Config
+	require.Equal(t, time.Minute, offset.Interval)
+	require.NotNil(t, offset.CollectionJitter)
+	require.Equal(t, 5*time.Second, *offset.CollectionJitter)
+	require.Equal(t, 30*time.Second, offset.CollectionOffset)
+	require.Nil(t, offset.Schedule)
+
+	scheduled := c.Inputs[1].Config
+	require.Nil(t, scheduled.CollectionJitter)
+	require.NotNil(t, scheduled.Schedule)
+	require.Equal(t, "0 */6 * * *", scheduled.Schedule.String())
+	require.Equal(t,
+		time.Date(2020, 1, 1, 6, 0, 0, 0, time.UTC),
+		scheduled.Schedule.Next(time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)))
+}
+
+func TestConfig_StartupErrorBehavior(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/startup_error_behavior.toml"))
+	require.Len(t, c.Inputs, 1)
+	require.Len(t, c.Outputs, 1)
+	require.Equal(t, models.StartupErrorBehaviorRetry, c.Inputs[0].Config.StartupErrorBehavior)
+	require.Equal(t, models.StartupErrorBehaviorIgnore, c.Outputs[0].Config.StartupErrorBehavior)
+
+	c = NewConfig()
+	err := c.LoadConfig("./testdata/startup_error_behavior_invalid.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid startup_error_behavior "panic"`)
+}
+
+func TestConfig_Cardinality(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/cardinality.toml"))
+	require.Len(t, c.Inputs, 1)
+	require.Len(t, c.Outputs, 1)
+	require.Equal(t, models.CardinalityConfig{
+		Limit:        100,
+		Action:       models.CardinalityActionCollapse,
+		CollapseTags: []string{"server"},
+		Expiration:   10 * time.Minute,
+	}, c.Inputs[0].Config.Cardinality)
+	require.Equal(t, models.CardinalityConfig{
+		Limit: 1000,
+	}, c.Outputs[0].Config.Cardinality)
+
+	c = NewConfig()
+	err := c.LoadConfig("./testdata/cardinality_invalid.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cardinality_collapse_tags must be set")
+}
+
+func TestConfig_Precision(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/precision.toml"))
+	require.Len(t, c.Inputs, 1)
+	require.Len(t, c.Outputs, 2)
+	require.Equal(t, time.Millisecond, c.Inputs[0].Config.Precision)
+
+	for _, output := range c.Outputs {
+		switch output.Config.Name {
+		case "influxdb":
+			// The deprecated precision option of the plugin is not a duration.
+			require.Equal(t, time.Duration(0), output.Config.Precision)
+		case "file":
+			require.Equal(t, 10*time.Second, output.Config.Precision)
+		}
+	}
+}
+
+func TestConfig_MaxConcurrentWrites(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/concurrent_writes.toml"))
+	require.Len(t, c.Outputs, 1)
+	require.Equal(t, 4, c.Outputs[0].Config.MaxConcurrentWrites)
+
+	c = NewConfig()
+	err := c.LoadConfig("./testdata/concurrent_writes_unsupported.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not support max_concurrent_writes")
+}
+
+func TestConfig_AggregatorWindows(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/aggregator_windows.toml"))
+	require.Len(t, c.Aggregators, 1)
+	require.True(t, c.Aggregators[0].Config.AlignWindows)
+	require.Equal(t, 5*time.Minute, c.Aggregators[0].Config.WindowGrace)
+	require.Equal(t, models.LateMetricsCurrent, c.Aggregators[0].Config.LateMetrics)
+
+	c = NewConfig()
+	err := c.LoadConfig("./testdata/aggregator_windows_invalid.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid late_metrics "next"`)
+}
+
+func TestConfig_DeadLetter(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/dead_letter.toml"))
+	require.Len(t, c.Outputs, 2)
+
+	var output, deadLetter *models.RunningOutput
+	for _, o := range c.Outputs {
+		switch o.Config.Name {
+		case "influxdb":
+			output = o
+		case "file":
+			deadLetter = o
+		}
+	}
+	require.NotNil(t, output)
+	require.NotNil(t, deadLetter)
+	require.Equal(t, 10*time.Second, output.Config.RetryBackoffInitial)
+	require.Equal(t, 10*time.Minute, output.Config.RetryBackoffMax)
+	require.Equal(t, "rejected", output.Config.DeadLetterOutput)
+	require.NoError(t, c.CheckOutputRouting())
+
+	deadLetter.Config.Alias = "other"
+	err := c.CheckOutputRouting()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `no dead letter output with alias "rejected"`)
+
+	deadLetter.Config.Alias = "rejected"
+	output.Config.Alias = "rejected"
+	require.Error(t, c.CheckOutputRouting())
+}
+
+func TestConfig_OutputRouting(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/routing.toml"))
+	require.Len(t, c.Outputs, 3)
+
+	var router, missing *models.RunningOutput
+	for _, output := range c.Outputs {
+		switch {
+		case output.IsRouter():
+			router = output
+		case output.Config.Alias == "missing":
+			missing = output
+		}
+	}
+	require.NotNil(t, router)
+	require.NotNil(t, missing)
+	require.Equal(t, []string{"eu", "other"}, router.RoutedOutputs())
+
+	err := c.CheckOutputRouting()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `no output with alias "other"`)
+
+	missing.Config.Alias = "other"
+	require.NoError(t, c.CheckOutputRouting())
+
+	missing.Config.Alias = "eu"
+	require.Error(t, c.CheckOutputRouting())
+}
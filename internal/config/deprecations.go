@@ -0,0 +1,204 @@
+package config
+
+import (
+	"time"
+
+	"github.com/influxdata/toml/ast"
+)
+
+// migrateKind is how a deprecated plugin or option is migrated by
+// MigrateConfig.
+type migrateKind int
+
+const (
+	// migrateManual deprecations cannot be migrated automatically.
+	migrateManual migrateKind = iota
+	// migrateRename renames the plugin or option to the replacement.
+	migrateRename
+	// migrateRenameToArray renames the option to the replacement, which
+	// takes an array of the values of the deprecated option.
+	migrateRenameToArray
+	// migrateRemove removes the option, which has no effect.
+	migrateRemove
+	// migrateFunc migrates using the migrate function of the deprecation.
+	migrateFunc
+)
+
+// deprecation describes a deprecated plugin or option.
+type deprecation struct {
+	// Since is the version the plugin or option was deprecated in.
+	Since string
+	// Replacement is the name of the replacing plugin or option.
+	Replacement string
+	// Notice is additional guidance on replacing the plugin or option.
+	Notice string
+
+	kind    migrateKind
+	migrate func(m *migration, tbl *ast.Table)
+}
+
+// deprecatedPlugins are the deprecated plugins by full name, such as
+// "inputs.httpjson".
+var deprecatedPlugins = map[string]deprecation{
+	"inputs.cassandra": {
+		Since:       "1.7",
+		Replacement: "inputs.jolokia2",
+		Notice:      "see the Cassandra example of the jolokia2 plugin",
+	},
+	"inputs.http_listener": {
+		Since:       "1.9",
+		Replacement: "inputs.influxdb_listener",
+		kind:        migrateRename,
+	},
+	"inputs.httpjson": {
+		Since:       "1.6",
+		Replacement: "inputs.http",
+		Notice:      `use data_format = "json"; the "server" tag is replaced by the "url" tag`,
+		kind:        migrateFunc,
+		migrate:     migrateHTTPJSON,
+	},
+	"inputs.io": {
+		Since:       "0.10",
+		Replacement: "inputs.diskio",
+		kind:        migrateRename,
+	},
+	"inputs.jolokia": {
+		Since:       "1.5",
+		Replacement: "inputs.jolokia2",
+	},
+	"inputs.kafka_consumer_legacy": {
+		Since:       "1.4",
+		Replacement: "inputs.kafka_consumer",
+		Notice:      "connect to the brokers instead of zookeeper",
+	},
+	"inputs.snmp_legacy": {
+		Since:       "1.0",
+		Replacement: "inputs.snmp",
+	},
+	"inputs.tcp_listener": {
+		Since:       "1.3",
+		Replacement: "inputs.socket_listener",
+	},
+	"inputs.udp_listener": {
+		Since:       "1.3",
+		Replacement: "inputs.socket_listener",
+	},
+	"outputs.riemann_legacy": {
+		Since:       "1.3",
+		Replacement: "outputs.riemann",
+	},
+}
+
+// deprecatedOptions are the deprecated options by full plugin name.  Options
+// of the "*" entry apply to all plugins accepting them.
+var deprecatedOptions = map[string]map[string]deprecation{
+	"*": {
+		"ssl_ca":   {Since: "1.7", Replacement: "tls_ca", kind: migrateRename},
+		"ssl_cert": {Since: "1.7", Replacement: "tls_cert", kind: migrateRename},
+		"ssl_key":  {Since: "1.7", Replacement: "tls_key", kind: migrateRename},
+	},
+	"inputs.activemq": {
+		"server": {Since: "1.11", Replacement: "url"},
+		"port":   {Since: "1.11", Replacement: "url"},
+	},
+	"inputs.aerospike": {
+		"enable_ssl": {Since: "1.7", Replacement: "enable_tls", kind: migrateRename},
+	},
+	"inputs.amqp_consumer": {
+		"url": {Since: "1.7", Replacement: "brokers", kind: migrateRenameToArray},
+	},
+	"inputs.consul": {
+		"datacentre": {Since: "1.10", Replacement: "datacenter", kind: migrateRename},
+	},
+	"inputs.docker": {
+		"container_names": {Since: "1.4", Replacement: "container_name_include", kind: migrateRename},
+	},
+	"inputs.filecount": {
+		"directory": {Since: "1.9", Replacement: "directories", kind: migrateRenameToArray},
+	},
+	"inputs.http_response": {
+		"address": {Since: "1.12", Replacement: "urls", kind: migrateRenameToArray},
+	},
+	"inputs.openldap": {
+		"ssl":    {Since: "1.7", Replacement: "tls", kind: migrateRename},
+		"ssl_ca": {Since: "1.7", Replacement: "tls_ca", kind: migrateRename},
+	},
+	"inputs.statsd": {
+		"convert_names":       {Since: "1.10", Replacement: "metric_separator"},
+		"parse_data_dog_tags": {Since: "1.10", Replacement: "datadog_extensions", kind: migrateRename},
+		"udp_packet_size":     {Since: "0.12", kind: migrateRemove},
+	},
+	"inputs.zookeeper": {
+		"enable_ssl": {Since: "1.7", Replacement: "enable_tls", kind: migrateRename},
+	},
+	"outputs.amqp": {
+		"url":              {Since: "1.7", Replacement: "brokers", kind: migrateRenameToArray},
+		"database":         {Since: "1.7", Replacement: "headers"},
+		"retention_policy": {Since: "1.7", Replacement: "headers"},
+		"precision":        {Since: "1.2", kind: migrateRemove},
+	},
+	"outputs.influxdb": {
+		"url":       {Since: "0.1.9", Replacement: "urls", kind: migrateRenameToArray},
+		"precision": {Since: "1.0", kind: migrateRemove},
+	},
+	"outputs.kinesis": {
+		"partitionkey":            {Since: "1.5", Replacement: "partition"},
+		"use_random_partitionkey": {Since: "1.5", Replacement: "partition"},
+	},
+	"outputs.opentsdb": {
+		"httpBatchSize": {Since: "1.8", Replacement: "http_batch_size", kind: migrateRename},
+	},
+	"outputs.wavefront": {
+		"string_to_number": {Since: "1.9", Notice: "use the enum processor instead"},
+	},
+}
+
+// lookupDeprecatedOption returns the deprecation of the option of the plugin.
+func lookupDeprecatedOption(plugin, option string, kv *ast.KeyValue) (deprecation, bool) {
+	// The precision option of the plugins, set as a duration, replaced the
+	// deprecated precision options set as a unit such as "s".
+	if option == "precision" && isDuration(kv) {
+		return deprecation{}, false
+	}
+
+	if d, ok := deprecatedOptions[plugin][option]; ok {
+		return d, true
+	}
+	d, ok := deprecatedOptions["*"][option]
+	return d, ok
+}
+
+func isDuration(kv *ast.KeyValue) bool {
+	if str, ok := kv.Value.(*ast.String); ok {
+		_, err := time.ParseDuration(str.Value)
+		return err == nil
+	}
+	return false
+}
+
+// pluginMessage returns the warning for a deprecated plugin.
+func (d deprecation) pluginMessage() string {
+	msg := "plugin is deprecated since " + d.Since
+	if d.Replacement != "" {
+		msg += ", use " + d.Replacement + " instead"
+	}
+	if d.Notice != "" {
+		msg += "; " + d.Notice
+	}
+	return msg
+}
+
+// optionMessage returns the warning for a deprecated option.
+func (d deprecation) optionMessage(option string) string {
+	msg := "option \"" + option + "\" is deprecated since " + d.Since
+	switch {
+	case d.kind == migrateRemove:
+		msg += " and has no effect"
+	case d.Replacement != "":
+		msg += ", use \"" + d.Replacement + "\" instead"
+	}
+	if d.Notice != "" {
+		msg += "; " + d.Notice
+	}
+	return msg
+}
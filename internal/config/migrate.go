@@ -0,0 +1,245 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// MigrateConfig replaces the deprecated plugins and options of the config
+// file, editing the original text so that comments and formatting are kept.
+// It returns the migrated contents, or nil if nothing was migrated, and the
+// deprecations that must be migrated by hand.
+func MigrateConfig(path string) ([]byte, []Problem, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error loading %s, %s", path, err)
+	}
+	data = trimBOM(data)
+
+	// Line numbers are taken from the parsed contents, environment variables
+	// are kept unexpanded in the migrated file.
+	contents := expandEnvVars(data)
+	tbl, err := toml.Parse(contents)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing %s, %s", path, err)
+	}
+
+	m := &migration{
+		file:    path,
+		lines:   strings.Split(string(data), "\n"),
+		index:   newLineIndex(contents),
+		inserts: make(map[int][]string),
+	}
+	eachPlugin(tbl, m.migratePlugin)
+
+	if !m.changed {
+		return nil, m.manual, nil
+	}
+	return m.bytes(), m.manual, nil
+}
+
+type migration struct {
+	file    string
+	lines   []string
+	index   lineIndex
+	inserts map[int][]string // lines to insert after the line number
+	changed bool
+	manual  []Problem
+}
+
+func (m *migration) migratePlugin(kind, name string, t *ast.Table) {
+	plugin := kind + "." + name
+	if d, ok := deprecatedPlugins[plugin]; ok {
+		switch d.kind {
+		case migrateRename:
+			m.renameTable(t, plugin, d.Replacement)
+		case migrateFunc:
+			d.migrate(m, t)
+		default:
+			m.addManual(t.Line, plugin, d.pluginMessage())
+		}
+	}
+
+	for _, key := range sortedKeys(t) {
+		kv, ok := t.Fields[key].(*ast.KeyValue)
+		if !ok {
+			continue
+		}
+		d, ok := lookupDeprecatedOption(plugin, key, kv)
+		if !ok {
+			continue
+		}
+
+		line := m.index.keyLine(kv)
+		if _, ok := t.Fields[d.Replacement]; ok && d.Replacement != "" {
+			m.addManual(line, plugin, fmt.Sprintf("%s; %q is also set",
+				d.optionMessage(key), d.Replacement))
+			continue
+		}
+
+		switch d.kind {
+		case migrateRename:
+			m.renameKey(kv, key, d.Replacement)
+		case migrateRenameToArray:
+			if !m.renameKeyToArray(kv, key, d.Replacement) {
+				m.addManual(line, plugin, d.optionMessage(key))
+			}
+		case migrateRemove:
+			m.commentOut(line, m.index.line(kv.Value.End()-1))
+		default:
+			m.addManual(line, plugin, d.optionMessage(key))
+		}
+	}
+}
+
+func (m *migration) addManual(line int, plugin, msg string) {
+	m.manual = append(m.manual, Problem{
+		File:     m.file,
+		Line:     line,
+		Plugin:   plugin,
+		Severity: SeverityWarning,
+		Message:  msg,
+	})
+}
+
+// renameTable renames the plugin in the header of the table and its
+// subtables, such as [[inputs.httpjson]] and [inputs.httpjson.headers].
+func (m *migration) renameTable(t *ast.Table, from, to string) {
+	re := regexp.MustCompile(`^(\s*\[\[?\s*)` + regexp.QuoteMeta(from) + `(\s*[\].])`)
+	m.renameTableHeaders(t, re, to)
+}
+
+func (m *migration) renameTableHeaders(t *ast.Table, re *regexp.Regexp, to string) {
+	m.replaceLine(m.index.line(t.Position.Begin), re, "${1}"+to+"${2}")
+	for _, node := range t.Fields {
+		switch node := node.(type) {
+		case *ast.Table:
+			m.renameTableHeaders(node, re, to)
+		case []*ast.Table:
+			for _, sub := range node {
+				m.renameTableHeaders(sub, re, to)
+			}
+		}
+	}
+}
+
+func (m *migration) renameKey(kv *ast.KeyValue, from, to string) {
+	re := regexp.MustCompile(`^(\s*)` + regexp.QuoteMeta(from) + `(\s*=)`)
+	m.replaceLine(m.index.keyLine(kv), re, "${1}"+to+"${2}")
+}
+
+// renameKeyToArray renames the key and wraps its string value in an array,
+// it returns false if the value is not a string on a single line.
+func (m *migration) renameKeyToArray(kv *ast.KeyValue, from, to string) bool {
+	str, ok := kv.Value.(*ast.String)
+	line := m.index.keyLine(kv)
+	if !ok || line != m.index.line(kv.Value.End()-1) {
+		return false
+	}
+	m.replaceKeyValue(kv, to, "["+str.Source()+"]")
+	return true
+}
+
+// replaceKeyValue replaces the line of a single line key/value pair, keeping
+// the indentation.
+func (m *migration) replaceKeyValue(kv *ast.KeyValue, key, value string) {
+	line := m.index.keyLine(kv)
+	text := m.lines[line-1]
+	indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+	m.lines[line-1] = indent + key + " = " + value
+	m.changed = true
+}
+
+func (m *migration) replaceLine(line int, re *regexp.Regexp, repl string) {
+	text := m.lines[line-1]
+	if replaced := re.ReplaceAllString(text, repl); replaced != text {
+		m.lines[line-1] = replaced
+		m.changed = true
+	}
+}
+
+// commentOut comments out the lines from first to last.
+func (m *migration) commentOut(first, last int) {
+	for line := first; line <= last; line++ {
+		text := m.lines[line-1]
+		trimmed := strings.TrimLeft(text, " \t")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		m.lines[line-1] = text[:len(text)-len(trimmed)] + "# " + trimmed
+		m.changed = true
+	}
+}
+
+// insertAfterHeader adds a key/value pair at the top of the table.
+func (m *migration) insertAfterHeader(t *ast.Table, text string) {
+	line := m.index.line(t.Position.Begin)
+	m.inserts[line] = append(m.inserts[line], "  "+text)
+	m.changed = true
+}
+
+func (m *migration) bytes() []byte {
+	var b strings.Builder
+	for i, text := range m.lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(text)
+		for _, insert := range m.inserts[i+1] {
+			b.WriteString("\n")
+			b.WriteString(insert)
+		}
+	}
+	return []byte(b.String())
+}
+
+// migrateHTTPJSON migrates the httpjson input to the http input with the json
+// data format.
+func migrateHTTPJSON(m *migration, t *ast.Table) {
+	const plugin = "inputs.httpjson"
+
+	m.renameTable(t, plugin, "inputs.http")
+
+	if kv, ok := t.Fields["servers"].(*ast.KeyValue); ok {
+		m.renameKey(kv, "servers", "urls")
+	}
+	if kv, ok := t.Fields["response_timeout"].(*ast.KeyValue); ok {
+		m.renameKey(kv, "response_timeout", "timeout")
+	}
+
+	// The measurement name of httpjson is "httpjson" or "httpjson_<name>".
+	_, hasOverride := t.Fields["name_override"]
+	if kv, ok := t.Fields["name"].(*ast.KeyValue); ok {
+		line := m.index.keyLine(kv)
+		str, isString := kv.Value.(*ast.String)
+		switch {
+		case hasOverride:
+			m.commentOut(line, line)
+		case isString && line == m.index.line(kv.Value.End()-1):
+			m.replaceKeyValue(kv, "name_override", strconv.Quote("httpjson_"+str.Value))
+		default:
+			m.addManual(line, plugin, `replace "name" with "name_override"`)
+		}
+	} else if !hasOverride {
+		m.insertAfterHeader(t, `name_override = "httpjson"`)
+	}
+
+	if _, ok := t.Fields["data_format"]; !ok {
+		m.insertAfterHeader(t, `data_format = "json"`)
+	}
+
+	if params, ok := t.Fields["parameters"].(*ast.Table); ok {
+		line := m.index.line(params.Position.Begin)
+		m.commentOut(line, m.index.line(params.Position.End-1))
+		m.addManual(line, plugin, "parameters are not supported, add them to "+
+			"the query string of the urls or to the body for POST requests")
+	}
+
+	m.addManual(t.Line, plugin, `the "server" tag is replaced by the "url" tag`)
+}
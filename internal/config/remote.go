@@ -0,0 +1,300 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// remoteConfigTimeout limits the time taken by a request for a remote
+// configuration, so that a stalled server cannot block loading or polling.
+var remoteConfigTimeout = 30 * time.Second
+
+var (
+	remoteConfigsMu sync.Mutex
+	// remoteConfigs holds the state of the configurations loaded from URLs
+	// by URL.  It is shared by the configs created on reload so that
+	// unchanged configurations are not downloaded again.
+	remoteConfigs = map[string]*remoteConfig{}
+)
+
+// remoteConfig is a configuration served over HTTP(S).  It holds the last
+// accepted contents along with the validators used to make conditional
+// requests.
+type remoteConfig struct {
+	url string
+
+	mu           sync.Mutex
+	data         []byte
+	etag         string
+	lastModified string
+}
+
+// remoteResponse is the result of a request for a remote configuration.
+type remoteResponse struct {
+	data         []byte
+	etag         string
+	lastModified string
+	notModified  bool
+}
+
+// IsRemoteConfig returns true if the configuration at path is loaded over
+// HTTP(S).
+func IsRemoteConfig(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+func getRemoteConfig(u string) *remoteConfig {
+	remoteConfigsMu.Lock()
+	defer remoteConfigsMu.Unlock()
+
+	r, ok := remoteConfigs[u]
+	if !ok {
+		r = &remoteConfig{url: u}
+		remoteConfigs[u] = r
+	}
+	return r
+}
+
+// fetch requests the configuration, conditionally on it being modified
+// since it was last accepted.  If it was not modified the accepted contents
+// are returned.
+func (r *remoteConfig) fetch() (*remoteResponse, error) {
+	req, err := http.NewRequest("GET", r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
+		req.Header.Add("Authorization", "Token "+v)
+	}
+	req.Header.Add("Accept", "application/toml")
+
+	r.mu.Lock()
+	accepted := &remoteResponse{
+		data:         r.data,
+		etag:         r.etag,
+		lastModified: r.lastModified,
+		notModified:  true,
+	}
+	r.mu.Unlock()
+
+	if accepted.data != nil {
+		if accepted.etag != "" {
+			req.Header.Set("If-None-Match", accepted.etag)
+		}
+		if accepted.lastModified != "" {
+			req.Header.Set("If-Modified-Since", accepted.lastModified)
+		}
+	}
+
+	client := &http.Client{Timeout: remoteConfigTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if accepted.data == nil {
+			return nil, errors.New("failed to retrieve remote config: unexpected 304 Not Modified")
+		}
+		return accepted, nil
+	default:
+		return nil, fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remoteResponse{
+		data:         data,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		notModified:  accepted.data != nil && bytes.Equal(data, accepted.data),
+	}, nil
+}
+
+// accept records a response whose contents were loaded successfully and
+// stores a copy of it in the cache directory, if set.
+func (r *remoteConfig) accept(resp *remoteResponse, cacheDir string) {
+	r.mu.Lock()
+	r.data = resp.data
+	r.etag = resp.etag
+	r.lastModified = resp.lastModified
+	r.mu.Unlock()
+
+	if cacheDir == "" || resp.notModified {
+		return
+	}
+
+	if err := r.writeCache(cacheDir, resp.data); err != nil {
+		log.Printf("W! [config] Unable to cache %s: %v", r.url, err)
+	}
+}
+
+// lastGood returns the accepted contents, or the cached copy if the
+// configuration has not been loaded yet by this process.
+func (r *remoteConfig) lastGood(cacheDir string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.data != nil {
+		return r.data, nil
+	}
+	if cacheDir == "" {
+		return nil, errors.New("no cached copy")
+	}
+
+	data, err := ioutil.ReadFile(r.cachePath(cacheDir))
+	if err != nil {
+		return nil, err
+	}
+	// The cached copy carries no validators, the next request downloads
+	// the configuration again and compares the contents.
+	r.data = data
+	return data, nil
+}
+
+// cachePath returns the path of the cached copy, named after a digest of
+// the URL so that it is safe to use as a file name.
+func (r *remoteConfig) cachePath(cacheDir string) string {
+	sum := sha256.Sum256([]byte(r.url))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".conf")
+}
+
+// writeCache replaces the cached copy.  The contents are written to a
+// temporary file first so that a partial write never replaces a good copy.
+func (r *remoteConfig) writeCache(cacheDir string, data []byte) error {
+	if err := os.MkdirAll(cacheDir, 0750); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(cacheDir, ".telegraf-config-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0640)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), r.cachePath(cacheDir))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// loadRemoteConfig loads the configuration at the URL u.  If the server
+// cannot be reached, returns an error or serves an invalid configuration, the
+// last accepted or cached copy is loaded instead.
+func (c *Config) loadRemoteConfig(u string) error {
+	r := getRemoteConfig(u)
+	c.remoteConfigs = append(c.remoteConfigs, u)
+
+	resp, err := r.fetch()
+	if err != nil {
+		data, cerr := r.lastGood(c.ConfigCacheDirectory)
+		if cerr != nil {
+			return fmt.Errorf("Error loading %s, %s", u, err)
+		}
+		log.Printf("W! [config] Error loading %s, using last good copy: %s", u, err)
+		return c.loadContents(u, data)
+	}
+
+	// A server serving an invalid configuration should not prevent the agent
+	// from starting, so the contents are checked before loading them.
+	if err := c.checkContents(u, resp.data); err != nil {
+		data, cerr := r.lastGood(c.ConfigCacheDirectory)
+		if cerr != nil {
+			return err
+		}
+		log.Printf("W! [config] Error loading %s, using last good copy: %s", u, err)
+		return c.loadContents(u, data)
+	}
+
+	if err := c.loadContents(u, resp.data); err != nil {
+		return err
+	}
+	if c.pendingRemoteConfigs == nil {
+		c.pendingRemoteConfigs = make(map[string]*remoteResponse)
+	}
+	c.pendingRemoteConfigs[u] = resp
+	return nil
+}
+
+// AcceptRemoteConfigs accepts the configurations loaded over HTTP(S) and
+// caches them.  It must be called once the configuration is applied, so
+// that a configuration failing to apply is not kept as the last good copy.
+func (c *Config) AcceptRemoteConfigs() {
+	for u, resp := range c.pendingRemoteConfigs {
+		getRemoteConfig(u).accept(resp, c.ConfigCacheDirectory)
+	}
+	c.pendingRemoteConfigs = nil
+}
+
+// RemoteConfigs returns the URLs of the configurations loaded over HTTP(S).
+func (c *Config) RemoteConfigs() []string {
+	return c.remoteConfigs
+}
+
+// CheckRemoteConfig requests the configuration at the URL u and returns true
+// if it changed since it was last accepted.  The new configuration is
+// validated, an error is returned if it cannot be retrieved or is invalid,
+// in which case the current configuration should be kept.  It is accepted
+// by AcceptRemoteConfigs once reloaded.
+func (c *Config) CheckRemoteConfig(u string) (bool, error) {
+	r := getRemoteConfig(u)
+	resp, err := r.fetch()
+	if err != nil {
+		return false, err
+	}
+	if resp.notModified {
+		// Keep any new validators so the next request is conditional.
+		r.accept(resp, c.ConfigCacheDirectory)
+		return false, nil
+	}
+
+	if err := c.checkContents(u, resp.data); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkContents loads the contents of the configuration at the URL u into a
+// new config, returning an error if they are invalid.
+func (c *Config) checkContents(u string, data []byte) error {
+	// The secret stores of the other sources may be referenced.
+	nc := NewConfig()
+	nc.InputFilters = c.InputFilters
+	nc.OutputFilters = c.OutputFilters
+	for id, store := range c.SecretStores {
+		if c.secretStorePaths[id] != u {
+			nc.SecretStores[id] = store
+		}
+	}
+	return nc.loadContents(u, data)
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// configServer serves a config, replying 304 Not Modified to requests with
+// the current ETag.
+type configServer struct {
+	sync.Mutex
+	config   string
+	etag     string
+	status   int
+	requests int
+	notMod   int
+}
+
+func (s *configServer) set(config, etag string) {
+	s.Lock()
+	defer s.Unlock()
+	s.config = config
+	s.etag = etag
+}
+
+func (s *configServer) setStatus(status int) {
+	s.Lock()
+	defer s.Unlock()
+	s.status = status
+}
+
+func (s *configServer) counts() (requests, notModified int) {
+	s.Lock()
+	defer s.Unlock()
+	return s.requests, s.notMod
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.requests++
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	if s.etag != "" && r.Header.Get("If-None-Match") == s.etag {
+		s.notMod++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	w.Write([]byte(s.config))
+}
+
+const (
+	remoteConfigA = `
+[[inputs.memcached]]
+  servers = ["localhost"]
+`
+	remoteConfigB = `
+[[inputs.memcached]]
+  servers = ["localhost", "example.org"]
+`
+	remoteConfigInvalid = `
+[[inputs.memcached]]
+  servers = 42
+`
+)
+
+func TestConfig_RemoteConfig(t *testing.T) {
+	cs := &configServer{}
+	cs.set(remoteConfigA, `"1"`)
+	ts := httptest.NewServer(cs)
+	defer ts.Close()
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig(ts.URL))
+	require.Len(t, c.Inputs, 1)
+	require.Equal(t, []string{ts.URL}, c.RemoteConfigs())
+	c.AcceptRemoteConfigs()
+
+	// Unchanged configs are requested conditionally.
+	changed, err := c.CheckRemoteConfig(ts.URL)
+	require.NoError(t, err)
+	require.False(t, changed)
+	_, notModified := cs.counts()
+	require.Equal(t, 1, notModified)
+
+	// Invalid configs are not accepted.
+	cs.set(remoteConfigInvalid, `"2"`)
+	changed, err = c.CheckRemoteConfig(ts.URL)
+	require.Error(t, err)
+	require.False(t, changed)
+
+	// Changed configs are not accepted before they are reloaded.
+	cs.set(remoteConfigB, `"3"`)
+	changed, err = c.CheckRemoteConfig(ts.URL)
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = c.CheckRemoteConfig(ts.URL)
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	c = NewConfig()
+	require.NoError(t, c.LoadConfig(ts.URL))
+	require.Len(t, c.Inputs, 1)
+	c.AcceptRemoteConfigs()
+
+	// Checking the accepted config does not download it again.
+	changed, err = c.CheckRemoteConfig(ts.URL)
+	require.NoError(t, err)
+	require.False(t, changed)
+	requests, notModified := cs.counts()
+	require.Equal(t, 7, requests)
+	require.Equal(t, 2, notModified)
+}
+
+func TestConfig_RemoteConfigSecretStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyring := filepath.Join(dir, "keyring")
+	require.NoError(t, os.MkdirAll(filepath.Join(keyring, "telegraf"), 0700))
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(keyring, "telegraf", "password"), []byte("hunter2\n"), 0600))
+
+	path := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+[[secretstores.os]]
+  id = "keyring"
+  keyring_dir = "`+keyring+`"
+`), 0600))
+
+	remoteConfig := `
+[[secretstores.os]]
+  id = "remote"
+  keyring_dir = "` + keyring + `"
+
+[[inputs.http_listener_v2]]
+  service_address = ":8080"
+  basic_username = "@{remote:password}"
+  basic_password = "@{keyring:password}"
+`
+	cs := &configServer{}
+	cs.set(remoteConfig, `"1"`)
+	ts := httptest.NewServer(cs)
+	defer ts.Close()
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig(path))
+	require.NoError(t, c.LoadConfig(ts.URL))
+	c.AcceptRemoteConfigs()
+
+	// The changed config is validated with its secret stores and those of
+	// the other files.
+	cs.set(remoteConfig+`  path = "/metrics"`, `"2"`)
+	changed, err := c.CheckRemoteConfig(ts.URL)
+	require.NoError(t, err)
+	require.True(t, changed)
+}
+
+func TestConfig_RemoteConfigCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-config-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cs := &configServer{}
+	cs.set(remoteConfigA, "")
+	ts := httptest.NewServer(cs)
+	defer ts.Close()
+
+	c := NewConfig()
+	c.ConfigCacheDirectory = dir
+	require.NoError(t, c.LoadConfig(ts.URL))
+	c.AcceptRemoteConfigs()
+
+	// Forget the state of this process to load the config as on startup.
+	remoteConfigsMu.Lock()
+	delete(remoteConfigs, ts.URL)
+	remoteConfigsMu.Unlock()
+
+	cs.setStatus(http.StatusInternalServerError)
+
+	c = NewConfig()
+	require.Error(t, c.LoadConfig(ts.URL))
+
+	remoteConfigsMu.Lock()
+	delete(remoteConfigs, ts.URL)
+	remoteConfigsMu.Unlock()
+
+	c = NewConfig()
+	c.ConfigCacheDirectory = dir
+	require.NoError(t, c.LoadConfig(ts.URL))
+	require.Len(t, c.Inputs, 1)
+	require.Equal(t, []string{ts.URL}, c.RemoteConfigs())
+
+	// The cached copy is replaced once the server recovers, an identical
+	// config is not reported as changed.
+	cs.setStatus(0)
+	changed, err := c.CheckRemoteConfig(ts.URL)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// The cached copy is replaced once the changed config is reloaded.
+	cs.set(remoteConfigB, "")
+	changed, err = c.CheckRemoteConfig(ts.URL)
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	data, err := ioutil.ReadFile(getRemoteConfig(ts.URL).cachePath(dir))
+	require.NoError(t, err)
+	require.Equal(t, remoteConfigA, string(data))
+
+	c = NewConfig()
+	c.ConfigCacheDirectory = dir
+	require.NoError(t, c.LoadConfig(ts.URL))
+	c.AcceptRemoteConfigs()
+
+	data, err = ioutil.ReadFile(getRemoteConfig(ts.URL).cachePath(dir))
+	require.NoError(t, err)
+	require.Equal(t, remoteConfigB, string(data))
+}
+
+func TestConfig_RemoteConfigInvalidOnStartup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-config-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cs := &configServer{}
+	cs.set(remoteConfigA, "")
+	ts := httptest.NewServer(cs)
+	defer ts.Close()
+
+	c := NewConfig()
+	c.ConfigCacheDirectory = dir
+	require.NoError(t, c.LoadConfig(ts.URL))
+	c.AcceptRemoteConfigs()
+
+	// Forget the state of this process to load the config as on startup.
+	remoteConfigsMu.Lock()
+	delete(remoteConfigs, ts.URL)
+	remoteConfigsMu.Unlock()
+
+	cs.set(remoteConfigInvalid, "")
+
+	c = NewConfig()
+	c.ConfigCacheDirectory = dir
+	require.NoError(t, c.LoadConfig(ts.URL))
+	require.Len(t, c.Inputs, 1)
+
+	// Without a cached copy the error is returned.
+	remoteConfigsMu.Lock()
+	delete(remoteConfigs, ts.URL)
+	remoteConfigsMu.Unlock()
+
+	c = NewConfig()
+	require.Error(t, c.LoadConfig(ts.URL))
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+var (
+	// secretRefRe is a regex to find references to secrets in string values,
+	// such as "@{vault:influxdb_password}", along with escaped references
+	// such as "@@{vault:influxdb_password}".
+	secretRefRe = regexp.MustCompile(`@?@\{(\w+):([^}]+)\}`)
+
+	secretStoreIDRe = regexp.MustCompile(`^\w+$`)
+)
+
+// LoadSecretStores loads only the secret stores of the given config file,
+// without resolving the secrets referenced by other plugins.
+func (c *Config) LoadSecretStores(path string) error {
+	var err error
+	if path == "" {
+		if path, err = getDefaultConfigPath(); err != nil {
+			return err
+		}
+	}
+	data, err := loadConfig(path)
+	if err != nil {
+		return fmt.Errorf("Error loading %s, %s", path, err)
+	}
+
+	tbl, err := parseConfig(data)
+	if err != nil {
+		return fmt.Errorf("Error parsing %s, %s", path, err)
+	}
+
+	return c.addSecretStores(path, tbl)
+}
+
+func (c *Config) addSecretStores(path string, tbl *ast.Table) error {
+	val, ok := tbl.Fields["secretstores"]
+	if !ok {
+		return nil
+	}
+
+	subTable, ok := val.(*ast.Table)
+	if !ok {
+		return fmt.Errorf("%s: invalid configuration", path)
+	}
+	for pluginName, pluginVal := range subTable.Fields {
+		switch pluginSubTable := pluginVal.(type) {
+		case []*ast.Table:
+			for _, t := range pluginSubTable {
+				if err := c.addSecretStore(path, pluginName, t); err != nil {
+					return fmt.Errorf("Error parsing %s, %s", path, err)
+				}
+			}
+		default:
+			return fmt.Errorf("Unsupported config format: %s, file %s",
+				pluginName, path)
+		}
+	}
+	return nil
+}
+
+func (c *Config) addSecretStore(path, name string, table *ast.Table) error {
+	creator, ok := secretstores.SecretStores[name]
+	if !ok {
+		return &undefinedPluginError{kind: "secret store", name: name}
+	}
+	store := creator()
+
+	var id string
+	if node, ok := table.Fields["id"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				id = str.Value
+			}
+		}
+	}
+	if !secretStoreIDRe.MatchString(id) {
+		return fmt.Errorf("secret store %s: id must be set and contain only letters, digits and underscores", name)
+	}
+	if _, ok := c.SecretStores[id]; ok {
+		return fmt.Errorf("secret store %s: duplicate id %q", name, id)
+	}
+
+	if err := toml.UnmarshalTable(table, store); err != nil {
+		return err
+	}
+
+	if p, ok := store.(telegraf.Initializer); ok {
+		if err := p.Init(); err != nil {
+			return fmt.Errorf("could not initialize secret store %s: %v", id, err)
+		}
+	}
+
+	c.SecretStores[id] = store
+	c.secretStorePaths[id] = path
+	return nil
+}
+
+// resolveSecrets replaces the references to secrets in the string values of a
+// plugin table, including any subtables, with the values of the secrets.
+// Escaped references, starting with "@@{", are replaced with the reference.
+//
+// Errors never include the value of a secret.
+func (c *Config) resolveSecrets(tbl *ast.Table) error {
+	for _, node := range tbl.Fields {
+		switch node := node.(type) {
+		case *ast.KeyValue:
+			if err := c.resolveSecretsValue(node.Value); err != nil {
+				return fmt.Errorf("line %d: %v", node.Line, err)
+			}
+		case *ast.Table:
+			if err := c.resolveSecrets(node); err != nil {
+				return err
+			}
+		case []*ast.Table:
+			for _, t := range node {
+				if err := c.resolveSecrets(t); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) resolveSecretsValue(value ast.Value) error {
+	switch v := value.(type) {
+	case *ast.String:
+		var err error
+		v.Value = secretRefRe.ReplaceAllStringFunc(v.Value, func(ref string) string {
+			if err != nil {
+				return ref
+			}
+			if strings.HasPrefix(ref, "@@") {
+				return ref[1:]
+			}
+			var secret string
+			secret, err = c.lookupSecret(ref)
+			return secret
+		})
+		return err
+	case *ast.Array:
+		for _, elem := range v.Value {
+			if err := c.resolveSecretsValue(elem); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) lookupSecret(ref string) (string, error) {
+	match := secretRefRe.FindStringSubmatch(ref)
+	id, key := match[1], match[2]
+
+	store, ok := c.SecretStores[id]
+	if !ok {
+		return "", fmt.Errorf("unknown secret store %q in %s", id, ref)
+	}
+
+	secret, err := store.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %v", ref, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,265 @@
+// Package cron parses cron expressions and computes the times they match.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr string
+
+	second bits
+	minute bits
+	hour   bits
+	dom    bits
+	month  bits
+	dow    bits
+
+	// domStar and dowStar are set if the day of month or the day of week
+	// field is unrestricted.  If both are restricted a day matches if
+	// either field matches.
+	domStar bool
+	dowStar bool
+}
+
+type bits uint64
+
+func (b bits) has(n int) bool {
+	return b&(1<<uint(n)) != 0
+}
+
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	secondField = field{name: "second", min: 0, max: 59}
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Both 0 and 7 are Sunday.
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression.  The expression has the five standard
+// fields "minute hour day-of-month month day-of-week", optionally preceded
+// by a seconds field, or is one of the descriptors @yearly, @annually,
+// @monthly, @weekly, @daily, @midnight or @hourly.
+//
+// Each field is a comma separated list of "*", a value or a range "a-b",
+// each optionally followed by a step "/n".  Months and days of the week can
+// be given by their three letter English names.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		s, ok := descriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown descriptor %q", spec)
+		}
+		spec = s
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("expected 5 or 6 fields, found %d in %q", len(fields), expr)
+	}
+
+	s := &Schedule{
+		expr:    expr,
+		domStar: fields[3] == "*",
+		dowStar: fields[5] == "*",
+	}
+
+	var err error
+	for i, f := range []struct {
+		field field
+		dst   *bits
+	}{
+		{secondField, &s.second},
+		{minuteField, &s.minute},
+		{hourField, &s.hour},
+		{domField, &s.dom},
+		{monthField, &s.month},
+		{dowField, &s.dow},
+	} {
+		*f.dst, err = parseField(fields[i], f.field)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.dow.has(7) {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+func parseField(s string, f field) (bits, error) {
+	var b bits
+	for _, part := range strings.Split(s, ",") {
+		r, err := parseRange(part, f)
+		if err != nil {
+			return 0, err
+		}
+		b |= r
+	}
+	return b, nil
+}
+
+func parseRange(s string, f field) (bits, error) {
+	rng, step := s, 1
+	if i := strings.Index(s, "/"); i >= 0 {
+		var err error
+		rng = s[:i]
+		step, err = strconv.Atoi(s[i+1:])
+		if err != nil || step < 1 {
+			return 0, fmt.Errorf("invalid step in %s field %q", f.name, s)
+		}
+	}
+
+	var start, end int
+	switch {
+	case rng == "*":
+		start, end = f.min, f.max
+	case strings.Contains(rng, "-"):
+		i := strings.Index(rng, "-")
+		var err error
+		start, err = parseValue(rng[:i], f)
+		if err != nil {
+			return 0, err
+		}
+		end, err = parseValue(rng[i+1:], f)
+		if err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range in %s field %q", f.name, s)
+		}
+	default:
+		var err error
+		start, err = parseValue(rng, f)
+		if err != nil {
+			return 0, err
+		}
+		end = start
+		// A single value with a step, such as "5/15", runs until the end
+		// of the range.
+		if step > 1 {
+			end = f.max
+		}
+	}
+
+	var b bits
+	for n := start; n <= end; n += step {
+		b |= 1 << uint(n)
+	}
+	return b, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	if n, ok := f.names[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, f.name)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s %d out of range [%d-%d]", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t matching the schedule, in the location
+// of t.  The zero time is returned if the schedule never matches, for
+// example on the 30th of February.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	limit := t.Year() + 5
+
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+
+	for !s.month.has(int(t.Month())) {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for !s.hour.has(t.Hour()) {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for !s.minute.has(t.Minute()) {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for !s.second.has(t.Second()) {
+		t = t.Truncate(time.Second).Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom.has(t.Day())
+	dow := s.dow.has(int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
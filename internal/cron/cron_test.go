@@ -0,0 +1,70 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@fortnightly",
+	} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		expr     string
+		now      string
+		expected string
+	}{
+		{"* * * * *", "2020-01-01T00:00:00Z", "2020-01-01T00:01:00Z"},
+		{"* * * * *", "2020-01-01T00:00:30.5Z", "2020-01-01T00:01:00Z"},
+		{"30 * * * * *", "2020-01-01T00:00:30Z", "2020-01-01T00:01:30Z"},
+		{"*/15 * * * *", "2020-01-01T00:14:59Z", "2020-01-01T00:15:00Z"},
+		{"5/15 * * * *", "2020-01-01T00:21:00Z", "2020-01-01T00:35:00Z"},
+		{"0 2 * * *", "2020-01-01T03:00:00Z", "2020-01-02T02:00:00Z"},
+		{"0 0 1,15 * *", "2020-01-02T00:00:00Z", "2020-01-15T00:00:00Z"},
+		{"0 0 * * mon-fri", "2020-01-03T12:00:00Z", "2020-01-06T00:00:00Z"},
+		{"0 0 * * 7", "2020-01-01T00:00:00Z", "2020-01-05T00:00:00Z"},
+		{"0 0 13 * fri", "2020-01-01T00:00:00Z", "2020-01-03T00:00:00Z"},
+		{"0 0 29 feb *", "2020-03-01T00:00:00Z", "2024-02-29T00:00:00Z"},
+		{"0 0 31 * *", "2020-04-01T00:00:00Z", "2020-05-31T00:00:00Z"},
+		{"@hourly", "2020-12-31T23:59:59Z", "2021-01-01T00:00:00Z"},
+		{"@weekly", "2020-01-01T00:00:00Z", "2020-01-05T00:00:00Z"},
+		{"0 0 30 feb *", "2020-01-01T00:00:00Z", "0001-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+
+			now, err := time.Parse(time.RFC3339Nano, tt.now)
+			require.NoError(t, err)
+			expected, err := time.Parse(time.RFC3339, tt.expected)
+			require.NoError(t, err)
+
+			next := s.Next(now)
+			if expected.IsZero() {
+				require.True(t, next.IsZero(), next.String())
+				return
+			}
+			require.Equal(t, expected, next)
+		})
+	}
+}
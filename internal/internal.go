@@ -275,6 +275,26 @@ func AlignTime(tm time.Time, interval time.Duration) time.Time {
 	return truncated.Add(interval)
 }
 
+// RoundTime returns the result of rounding tm to the nearest multiple of
+// precision since the Unix epoch, halfway values are rounded up.  Unlike
+// time.Round the result is aligned to the epoch for any precision.  As with
+// time.Round the monotonic clock reading is stripped.
+func RoundTime(tm time.Time, precision time.Duration) time.Time {
+	tm = tm.Round(0)
+	if precision <= time.Nanosecond {
+		return tm
+	}
+
+	r := time.Duration(tm.UnixNano() % int64(precision))
+	if r < 0 {
+		r += precision
+	}
+	if r+r < precision {
+		return tm.Add(-r)
+	}
+	return tm.Add(precision - r)
+}
+
 // Exit status takes the error from exec.Command
 // and returns the exit status and true
 // if error is not exit status, will return 0 and false
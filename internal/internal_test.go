@@ -364,6 +364,59 @@ func TestAlignTime(t *testing.T) {
 	}
 }
 
+func TestRoundTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		tm        time.Time
+		precision time.Duration
+		expected  time.Time
+	}{
+		{
+			name:      "nanosecond",
+			tm:        time.Unix(42, 123456789),
+			precision: time.Nanosecond,
+			expected:  time.Unix(42, 123456789),
+		},
+		{
+			name:      "zero precision",
+			tm:        time.Unix(42, 123456789),
+			precision: 0,
+			expected:  time.Unix(42, 123456789),
+		},
+		{
+			name:      "round down",
+			tm:        time.Unix(42, 123456789),
+			precision: time.Millisecond,
+			expected:  time.Unix(42, 123000000),
+		},
+		{
+			name:      "round up",
+			tm:        time.Unix(42, 500000000),
+			precision: time.Second,
+			expected:  time.Unix(43, 0),
+		},
+		{
+			name:      "aligned to epoch",
+			tm:        time.Unix(15, 0),
+			precision: 7 * time.Second,
+			expected:  time.Unix(14, 0),
+		},
+		{
+			name:      "before epoch",
+			tm:        time.Unix(-2, -400000000),
+			precision: time.Second,
+			expected:  time.Unix(-2, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := RoundTime(tt.tm, tt.precision)
+			require.True(t, tt.expected.Equal(actual), "expected %v, got %v", tt.expected, actual)
+		})
+	}
+}
+
 func TestParseTimestamp(t *testing.T) {
 	rfc3339 := func(value string) time.Time {
 		tm, err := time.Parse(time.RFC3339Nano, value)
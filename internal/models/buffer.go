@@ -24,6 +24,8 @@ type Buffer struct {
 	batchFirst int // index of the first metric in the batch
 	batchSize  int // number of metrics currently in the batch
 
+	wal *bufferLog // write-ahead log, nil unless the buffer is disk backed
+
 	MetricsAdded   selfstat.Stat
 	MetricsWritten selfstat.Stat
 	MetricsDropped selfstat.Stat
@@ -83,19 +85,28 @@ func (b *Buffer) length() int {
 	return min(b.size+b.batchSize, b.cap)
 }
 
-func (b *Buffer) metricAdded() {
+func (b *Buffer) metricAdded(metric telegraf.Metric) {
 	b.MetricsAdded.Incr(1)
+	if b.wal != nil {
+		b.wal.append(metric)
+	}
 }
 
 func (b *Buffer) metricWritten(metric telegraf.Metric) {
 	AgentMetricsWritten.Incr(1)
 	b.MetricsWritten.Incr(1)
+	if b.wal != nil {
+		b.wal.remove(metric)
+	}
 	metric.Accept()
 }
 
 func (b *Buffer) metricDropped(metric telegraf.Metric) {
 	AgentMetricsDropped.Incr(1)
 	b.MetricsDropped.Incr(1)
+	if b.wal != nil {
+		b.wal.remove(metric)
+	}
 	metric.Reject()
 }
 
@@ -112,7 +123,7 @@ func (b *Buffer) add(m telegraf.Metric) int {
 		}
 	}
 
-	b.metricAdded()
+	b.metricAdded(m)
 
 	b.buf[b.last] = m
 	b.last = b.next(b.last)
@@ -125,8 +136,10 @@ func (b *Buffer) add(m telegraf.Metric) int {
 	return dropped
 }
 
-// Add adds metrics to the buffer and returns number of dropped metrics.
-func (b *Buffer) Add(metrics ...telegraf.Metric) int {
+// Add adds metrics to the buffer and returns number of dropped metrics.  An
+// error is returned if the metrics could not be written to the disk buffer,
+// they are added to the buffer nevertheless.
+func (b *Buffer) Add(metrics ...telegraf.Metric) (int, error) {
 	b.Lock()
 	defer b.Unlock()
 
@@ -136,14 +149,18 @@ func (b *Buffer) Add(metrics ...telegraf.Metric) int {
 			dropped += n
 		}
 	}
+	err := b.flushLog()
 
 	b.BufferSize.Set(int64(b.length()))
-	return dropped
+	return dropped, err
 }
 
 // Batch returns a slice containing up to batchSize of the most recently added
 // metrics.  Metrics are ordered from newest to oldest in the batch.  The
 // batch must not be modified by the client.
+//
+// The batch may be split into consecutive parts that are each passed to Accept
+// or Reject, in order from the newest to the oldest part.
 func (b *Buffer) Batch(batchSize int) []telegraf.Metric {
 	b.Lock()
 	defer b.Unlock()
@@ -170,27 +187,48 @@ func (b *Buffer) Batch(batchSize int) []telegraf.Metric {
 	return out
 }
 
-// Accept marks the batch, acquired from Batch(), as successfully written.
-func (b *Buffer) Accept(batch []telegraf.Metric) {
+// Accept marks the batch, or part of the batch, acquired from Batch(), as
+// successfully written.
+func (b *Buffer) Accept(batch []telegraf.Metric) error {
 	b.Lock()
 	defer b.Unlock()
 
 	for _, m := range batch {
 		b.metricWritten(m)
 	}
+	err := b.flushLog()
 
-	b.resetBatch()
+	b.batchDone(len(batch))
 	b.BufferSize.Set(int64(b.length()))
+	b.compact()
+	return err
 }
 
-// Reject returns the batch, acquired from Batch(), to the buffer and marks it
-// as unsent.
-func (b *Buffer) Reject(batch []telegraf.Metric) {
+// Drop removes the batch, or part of the batch, acquired from Batch(), from
+// the buffer without writing it.
+func (b *Buffer) Drop(batch []telegraf.Metric) error {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, m := range batch {
+		b.metricDropped(m)
+	}
+	err := b.flushLog()
+
+	b.batchDone(len(batch))
+	b.BufferSize.Set(int64(b.length()))
+	b.compact()
+	return err
+}
+
+// Reject returns the batch, or part of the batch, acquired from Batch(), to
+// the buffer and marks it as unsent.
+func (b *Buffer) Reject(batch []telegraf.Metric) error {
 	b.Lock()
 	defer b.Unlock()
 
 	if len(batch) == 0 {
-		return
+		return nil
 	}
 
 	older := b.dist(b.first, b.batchFirst)
@@ -227,9 +265,24 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 			b.metricDropped(batch[i])
 		}
 	}
+	err := b.flushLog()
 
-	b.resetBatch()
+	b.batchDone(len(batch))
 	b.BufferSize.Set(int64(b.length()))
+	b.compact()
+	return err
+}
+
+// Close releases any resources held by the buffer.  Metrics remaining in a
+// disk backed buffer are kept and will be restored when it is reopened.
+func (b *Buffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.wal == nil {
+		return nil
+	}
+	return b.wal.close()
 }
 
 // dist returns the distance between two indexes.  Because this data structure
@@ -268,6 +321,15 @@ func (b *Buffer) prev(index int) int {
 	return index
 }
 
+// batchDone removes count metrics from the batch, resetting it once all
+// metrics of the batch are accepted or rejected.
+func (b *Buffer) batchDone(count int) {
+	b.batchSize -= count
+	if b.batchSize <= 0 {
+		b.resetBatch()
+	}
+}
+
 func (b *Buffer) resetBatch() {
 	b.batchFirst = 0
 	b.batchSize = 0
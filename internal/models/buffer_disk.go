@@ -0,0 +1,387 @@
+package models
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	parser "github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+const (
+	// bufferLogFile is the name of the write-ahead log inside of the buffer
+	// directory.
+	bufferLogFile = "buffer.wal"
+
+	recordAdd    byte = '+'
+	recordRemove byte = '-'
+)
+
+// NewDiskBuffer returns a Buffer with the given capacity that is backed by a
+// write-ahead log stored in dir.  Any metrics left in the log from a previous
+// run are restored into the buffer, dropping the oldest metrics if there are
+// more than capacity.
+//
+// Metrics restored from disk are not counted as added, nor as dropped.
+func NewDiskBuffer(name string, alias string, capacity int, dir string) (*Buffer, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+
+	wal := &bufferLog{
+		logName:    logName("outputs", name, alias),
+		path:       filepath.Join(dir, bufferLogFile),
+		serializer: influx.NewSerializer(),
+		ids:        make(map[telegraf.Metric]uint64),
+	}
+	wal.serializer.SetFieldTypeSupport(influx.UintSupport)
+
+	metrics, err := wal.replay()
+	if err != nil {
+		return nil, err
+	}
+
+	b := NewBuffer(name, alias, capacity)
+
+	if n := len(metrics) - capacity; n > 0 {
+		for _, m := range metrics[:n] {
+			delete(wal.ids, m)
+			m.Reject()
+		}
+		metrics = metrics[n:]
+	}
+
+	for _, m := range metrics {
+		b.buf[b.last] = m
+		b.last = b.next(b.last)
+		b.size++
+	}
+	b.BufferSize.Set(int64(b.length()))
+
+	if err := wal.rewrite(); err != nil {
+		return nil, err
+	}
+	b.wal = wal
+
+	if len(metrics) > 0 {
+		log.Printf("I! [%s] Restored %d metrics from disk buffer", wal.logName, len(metrics))
+	}
+	return b, nil
+}
+
+// flushLog writes the records of the last change of the buffer to the
+// write-ahead log.
+func (b *Buffer) flushLog() error {
+	if b.wal == nil {
+		return nil
+	}
+
+	if err := b.wal.flush(); err != nil {
+		return fmt.Errorf("writing to disk buffer failed: %v", err)
+	}
+	return nil
+}
+
+// compact rewrites the write-ahead log once the number of records for metrics
+// that are no longer buffered grows past the buffer capacity.
+func (b *Buffer) compact() {
+	if b.wal == nil || b.wal.records-len(b.wal.ids) < b.cap {
+		return
+	}
+
+	if err := b.wal.rewrite(); err != nil {
+		log.Printf("E! [%s] Compacting disk buffer failed: %v", b.wal.logName, err)
+	}
+}
+
+// bufferLog is an append only log of the metrics added to and removed from a
+// Buffer.  Each record is keyed by a sequence number so that the live metrics
+// can be restored in their original order.
+//
+// The records of a change of the buffer are collected and written to the
+// file at once by flush, without syncing, so they survive a crash of the
+// process but not necessarily a crash of the host.
+type bufferLog struct {
+	logName    string
+	path       string
+	file       *os.File
+	serializer *influx.Serializer
+	pending    []byte // records not written yet
+	failed     bool   // a write failed, the file must be rewritten
+
+	seq     uint64                     // last sequence number issued
+	ids     map[telegraf.Metric]uint64 // sequence number of each live metric
+	records int                        // number of add records in the file
+}
+
+func (l *bufferLog) append(m telegraf.Metric) {
+	// The buffer takes ownership of its metrics, so a metric can only be
+	// logged once.
+	if _, ok := l.ids[m]; ok {
+		return
+	}
+
+	octets, err := l.serializer.Serialize(m)
+	if err != nil {
+		log.Printf("W! [%s] Metric could not be written to disk buffer: %v", l.logName, err)
+		return
+	}
+
+	l.seq++
+	l.pending = encodeAddRecord(l.pending, l.seq, m.Type(), octets)
+	l.ids[m] = l.seq
+	l.records++
+}
+
+func (l *bufferLog) remove(m telegraf.Metric) {
+	seq, ok := l.ids[m]
+	if !ok {
+		return
+	}
+	delete(l.ids, m)
+	l.pending = encodeRemoveRecord(l.pending, seq)
+}
+
+// flush writes the pending records.  If the write fails the file may end
+// with a partial record, so it is rewritten from the live metrics instead,
+// now or on a later flush if the rewrite fails as well.
+func (l *bufferLog) flush() error {
+	if l.file == nil {
+		return fmt.Errorf("disk buffer is closed")
+	}
+
+	if !l.failed {
+		if len(l.pending) == 0 {
+			return nil
+		}
+		_, err := l.file.Write(l.pending)
+		if err == nil {
+			l.pending = l.pending[:0]
+			return nil
+		}
+		log.Printf("W! [%s] Writing to disk buffer failed, rewriting it: %v", l.logName, err)
+		l.failed = true
+	}
+
+	return l.rewrite()
+}
+
+// replay reads the log and returns the metrics that were not removed, ordered
+// from oldest to newest.  A partially written record at the end of the log is
+// discarded.
+func (l *bufferLog) replay() ([]telegraf.Metric, error) {
+	type entry struct {
+		seq     uint64
+		tp      telegraf.ValueType
+		payload []byte
+	}
+
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	live := make(map[uint64]entry)
+	r := bufio.NewReader(f)
+	for {
+		op, seq, tp, payload, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			log.Printf("W! [%s] Discarding incomplete record at end of disk buffer", l.logName)
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case recordAdd:
+			live[seq] = entry{seq: seq, tp: tp, payload: payload}
+		case recordRemove:
+			delete(live, seq)
+		}
+		if seq > l.seq {
+			l.seq = seq
+		}
+	}
+
+	entries := make([]entry, 0, len(live))
+	for _, e := range live {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+
+	p := parser.NewParser(parser.NewMetricHandler())
+	metrics := make([]telegraf.Metric, 0, len(entries))
+	for _, e := range entries {
+		parsed, err := p.Parse(e.payload)
+		if err != nil || len(parsed) != 1 {
+			log.Printf("W! [%s] Discarding unreadable metric in disk buffer: %v", l.logName, err)
+			continue
+		}
+
+		m, err := metric.New(parsed[0].Name(), parsed[0].Tags(), parsed[0].Fields(), parsed[0].Time(), e.tp)
+		if err != nil {
+			log.Printf("W! [%s] Discarding unreadable metric in disk buffer: %v", l.logName, err)
+			continue
+		}
+
+		l.ids[m] = e.seq
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// rewrite replaces the log with one containing only the live metrics.
+func (l *bufferLog) rewrite() error {
+	type entry struct {
+		seq    uint64
+		metric telegraf.Metric
+	}
+
+	entries := make([]entry, 0, len(l.ids))
+	for m, seq := range l.ids {
+		entries = append(entries, entry{seq: seq, metric: m})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+
+	tmpPath := l.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+	var buf []byte
+	records := 0
+	for _, e := range entries {
+		octets, err := l.serializer.Serialize(e.metric)
+		if err != nil {
+			delete(l.ids, e.metric)
+			continue
+		}
+		buf = encodeAddRecord(buf[:0], e.seq, e.metric.Type(), octets)
+		if _, err := w.Write(buf); err != nil {
+			tmp.Close()
+			return err
+		}
+		records++
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// The new file is opened before it replaces the log, so that on failure
+	// the records keep being appended to the old file.
+	file, err := os.OpenFile(tmpPath, os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file = file
+	l.records = records
+	l.pending = l.pending[:0]
+	l.failed = false
+	return nil
+}
+
+func (l *bufferLog) close() error {
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// encodeAddRecord appends an add record to buf.  The record layout is the
+// operation byte, an 8 byte sequence number, the metric type, the 4 byte
+// length of the payload and the payload in line protocol.
+func encodeAddRecord(buf []byte, seq uint64, tp telegraf.ValueType, payload []byte) []byte {
+	var header [14]byte
+	header[0] = recordAdd
+	binary.BigEndian.PutUint64(header[1:9], seq)
+	header[9] = byte(tp)
+	binary.BigEndian.PutUint32(header[10:14], uint32(len(payload)))
+	buf = append(buf, header[:]...)
+	return append(buf, payload...)
+}
+
+// encodeRemoveRecord appends a remove record to buf.  The record layout is the
+// operation byte followed by the 8 byte sequence number.
+func encodeRemoveRecord(buf []byte, seq uint64) []byte {
+	var header [9]byte
+	header[0] = recordRemove
+	binary.BigEndian.PutUint64(header[1:9], seq)
+	return append(buf, header[:]...)
+}
+
+func readRecord(r io.Reader) (byte, uint64, telegraf.ValueType, []byte, error) {
+	var header [9]byte
+	n, err := io.ReadFull(r, header[:])
+	if err == io.EOF && n == 0 {
+		return 0, 0, 0, nil, io.EOF
+	}
+	if err == io.EOF {
+		return 0, 0, 0, nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+
+	op := header[0]
+	seq := binary.BigEndian.Uint64(header[1:9])
+
+	switch op {
+	case recordRemove:
+		return op, seq, 0, nil, nil
+	case recordAdd:
+		var meta [5]byte
+		if _, err := io.ReadFull(r, meta[:]); err != nil {
+			return 0, 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(meta[1:5]))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return 0, 0, 0, nil, io.ErrUnexpectedEOF
+		}
+		return op, seq, telegraf.ValueType(meta[0]), payload, nil
+	default:
+		return 0, 0, 0, nil, fmt.Errorf("corrupt disk buffer: unknown record type %q", op)
+	}
+}
@@ -0,0 +1,273 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskBuffer(t *testing.T, dir string, capacity int) *Buffer {
+	b, err := NewDiskBuffer("test", "", capacity, dir)
+	require.NoError(t, err)
+	return setup(b)
+}
+
+func TestDiskBuffer_RestoresMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	require.Equal(t, 3, b.Len())
+	require.Equal(t, int64(0), b.MetricsAdded.Get())
+
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(3), MetricTime(2), MetricTime(1)}, batch)
+}
+
+func TestDiskBuffer_AcceptedMetricsNotRestored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	b.Accept(b.Batch(2))
+	require.Equal(t, int64(2), b.MetricsWritten.Get())
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1)}, b.Batch(5))
+}
+
+func TestDiskBuffer_UnacknowledgedBatchRestored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	b.Batch(2)
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	require.Equal(t, 3, b.Len())
+}
+
+func TestDiskBuffer_DropsOldestOverCapacity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 3)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4))
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+	require.NoError(t, setup(b).Close())
+
+	// Metrics over capacity on restore are discarded without counting them
+	// as dropped.
+	agentDropped := AgentMetricsDropped.Get()
+	b, err = NewDiskBuffer("test", "", 2, dir)
+	require.NoError(t, err)
+	defer b.Close()
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+	require.Equal(t, agentDropped, AgentMetricsDropped.Get())
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(4), MetricTime(3)}, b.Batch(5))
+}
+
+func TestDiskBuffer_RejectDropsOldest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 3)
+	b.Add(MetricTime(1), MetricTime(2))
+	batch := b.Batch(2)
+	b.Add(MetricTime(3), MetricTime(4))
+	b.Reject(batch)
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 3)
+	defer b.Close()
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(4), MetricTime(3), MetricTime(2)}, b.Batch(5))
+}
+
+func TestDiskBuffer_PreservesTypes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m, err := metric.New(
+		"net",
+		map[string]string{"interface": "eth0"},
+		map[string]interface{}{
+			"bytes_recv": uint64(42),
+			"drop_in":    int64(-1),
+			"ratio":      0.5,
+			"up":         true,
+			"name":       "line\nbreak",
+		},
+		time.Unix(0, 1),
+		telegraf.Counter,
+	)
+	require.NoError(t, err)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(m.Copy())
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	batch := b.Batch(5)
+	require.Len(t, batch, 1)
+	testutil.RequireMetricEqual(t, m, batch[0])
+	require.Equal(t, telegraf.Counter, batch[0].Type())
+}
+
+func TestDiskBuffer_Compacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 2)
+	defer b.Close()
+	for i := 0; i < 10; i++ {
+		b.Add(MetricTime(int64(i)))
+		b.Accept(b.Batch(1))
+	}
+	require.Equal(t, 0, b.Len())
+
+	stat, err := os.Stat(filepath.Join(dir, bufferLogFile))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), stat.Size())
+}
+
+func TestDiskBuffer_WritesRecordsOfEachCall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, bufferLogFile)
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2))
+	require.Empty(t, b.wal.pending)
+	added, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NotZero(t, added.Size())
+
+	b.Accept(b.Batch(2))
+	require.Empty(t, b.wal.pending)
+	accepted, err := os.Stat(path)
+	require.NoError(t, err)
+	// Two remove records of 9 bytes each.
+	require.Equal(t, added.Size()+2*9, accepted.Size())
+}
+
+func TestDiskBuffer_RewritesAfterFailedWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	_, err = b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	require.NoError(t, err)
+	batch := b.Batch(2)
+
+	// The remove records can't be written, the log is rewritten instead.
+	require.NoError(t, b.wal.file.Close())
+	require.NoError(t, b.Accept(batch))
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1)}, b.Batch(5))
+}
+
+func TestDiskBuffer_KeepsLogIfRewriteFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	_, err = b.Add(MetricTime(1))
+	require.NoError(t, err)
+
+	// The temporary file of the rewrite can't be created.
+	tmpPath := filepath.Join(dir, bufferLogFile+".tmp")
+	require.NoError(t, os.Mkdir(tmpPath, 0750))
+	require.Error(t, b.wal.rewrite())
+	require.NoError(t, os.Remove(tmpPath))
+
+	_, err = b.Add(MetricTime(2))
+	require.NoError(t, err)
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(2), MetricTime(1)}, b.Batch(5))
+}
+
+func TestDiskBuffer_ReturnsWriteError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	tmpPath := filepath.Join(dir, bufferLogFile+".tmp")
+	require.NoError(t, os.Mkdir(tmpPath, 0750))
+	require.NoError(t, b.wal.file.Close())
+	_, err = b.Add(MetricTime(1))
+	require.Error(t, err)
+	require.Equal(t, 1, b.Len())
+
+	// The log is rewritten by the next call.
+	require.NoError(t, os.Remove(tmpPath))
+	_, err = b.Add(MetricTime(2))
+	require.NoError(t, err)
+	require.False(t, b.wal.failed)
+}
+
+func TestDiskBuffer_DiscardsIncompleteRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2))
+	require.NoError(t, b.Close())
+
+	path := filepath.Join(dir, bufferLogFile)
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, stat.Size()-3))
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1)}, b.Batch(5))
+}
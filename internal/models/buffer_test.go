@@ -726,3 +726,53 @@ func TestBuffer_RejectEmptyBatch(t *testing.T) {
 		require.NotNil(t, m)
 	}
 }
+
+func TestBuffer_RejectBatchParts(t *testing.T) {
+	b := setup(NewBuffer("test", "", 10))
+	b.Add(MetricTime(1))
+	b.Add(MetricTime(2))
+	b.Add(MetricTime(3))
+	b.Add(MetricTime(4))
+	b.Add(MetricTime(5))
+
+	batch := b.Batch(4)
+	b.Add(MetricTime(6))
+	b.Reject(batch[:2])
+	b.Reject(batch[2:])
+
+	require.Equal(t, 6, b.Len())
+	batch = b.Batch(10)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(6),
+			MetricTime(5),
+			MetricTime(4),
+			MetricTime(3),
+			MetricTime(2),
+			MetricTime(1),
+		}, batch)
+}
+
+func TestBuffer_AcceptAndRejectBatchParts(t *testing.T) {
+	b := setup(NewBuffer("test", "", 10))
+	b.Add(MetricTime(1))
+	b.Add(MetricTime(2))
+	b.Add(MetricTime(3))
+	b.Add(MetricTime(4))
+
+	batch := b.Batch(4)
+	b.Add(MetricTime(5))
+	b.Accept(batch[:2])
+	require.Equal(t, 3, b.Len())
+	b.Reject(batch[2:])
+
+	require.Equal(t, int64(2), b.MetricsWritten.Get())
+	require.Equal(t, 3, b.Len())
+	batch = b.Batch(10)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(5),
+			MetricTime(2),
+			MetricTime(1),
+		}, batch)
+}
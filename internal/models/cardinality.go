@@ -0,0 +1,260 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	// Actions selectable with cardinality_limit_action.
+	CardinalityActionDrop     = "drop"
+	CardinalityActionCollapse = "collapse"
+
+	// CollapsedTagValue replaces the value of the collapsed tags of a series
+	// over the cardinality limit.
+	CollapsedTagValue = "other"
+
+	// Default time after which a series that is no longer seen is forgotten.
+	DefaultCardinalityExpiration = time.Hour
+)
+
+// CardinalityConfig is the configuration of the cardinality guard of an input
+// or output.
+type CardinalityConfig struct {
+	// Limit is the maximum number of series per measurement, the guard is
+	// disabled if zero.
+	Limit int
+	// Action selects what happens to new series beyond the limit, one of the
+	// CardinalityAction constants.  Defaults to drop.
+	Action string
+	// CollapseTags are the tags whose values are replaced by
+	// CollapsedTagValue when collapsing a series.
+	CollapseTags []string
+	// Expiration is the time after which a series that is no longer seen
+	// stops counting towards the limit.  Defaults to
+	// DefaultCardinalityExpiration.
+	Expiration time.Duration
+}
+
+// Validate checks the configuration.
+func (c *CardinalityConfig) Validate() error {
+	if c.Limit < 0 {
+		return fmt.Errorf("invalid cardinality_limit %d", c.Limit)
+	}
+
+	switch c.Action {
+	case "", CardinalityActionDrop:
+	case CardinalityActionCollapse:
+		if len(c.CollapseTags) == 0 {
+			return fmt.Errorf("cardinality_collapse_tags must be set to collapse series")
+		}
+	default:
+		return fmt.Errorf("invalid cardinality_limit_action %q", c.Action)
+	}
+
+	if c.Expiration < 0 {
+		return fmt.Errorf("invalid cardinality_expiration %s", c.Expiration)
+	}
+	return nil
+}
+
+// CardinalityGuard tracks the unique series of each measurement passing
+// through a plugin and enforces the series limit.  Series are identified by
+// Metric.HashID.
+//
+// Series are kept in two generations which are rotated every expiration, a
+// series that is not seen is forgotten after between one and two times the
+// expiration.
+type CardinalityGuard struct {
+	config CardinalityConfig
+	tags   map[string]string
+	log    telegraf.Logger
+
+	mu           sync.Mutex
+	measurements map[string]*seriesSet
+	rotated      time.Time
+
+	Series    selfstat.Stat
+	Dropped   selfstat.Stat
+	Collapsed selfstat.Stat
+}
+
+// seriesSet holds the series of a measurement.
+type seriesSet struct {
+	current  map[uint64]bool
+	previous map[uint64]bool
+
+	// Statistics of the measurement, registered once it reaches the
+	// limit.
+	offender *offenderStats
+}
+
+type offenderStats struct {
+	series    selfstat.Stat
+	dropped   selfstat.Stat
+	collapsed selfstat.Stat
+}
+
+func (s *seriesSet) len() int {
+	return len(s.current) + len(s.previous)
+}
+
+// NewCardinalityGuard returns a guard for the plugin with the given selfstat
+// tags.  It returns nil if the limit is disabled.
+func NewCardinalityGuard(
+	config CardinalityConfig,
+	tags map[string]string,
+	log telegraf.Logger,
+) *CardinalityGuard {
+	if config.Limit == 0 {
+		return nil
+	}
+	if config.Action == "" {
+		config.Action = CardinalityActionDrop
+	}
+	if config.Expiration == 0 {
+		config.Expiration = DefaultCardinalityExpiration
+	}
+
+	return &CardinalityGuard{
+		config:       config,
+		tags:         tags,
+		log:          log,
+		measurements: make(map[string]*seriesSet),
+		rotated:      time.Now(),
+		Series:       selfstat.Register("cardinality", "series", tags),
+		Dropped:      selfstat.Register("cardinality", "metrics_dropped", tags),
+		Collapsed:    selfstat.Register("cardinality", "metrics_collapsed", tags),
+	}
+}
+
+// Check records the series of the metric and returns false if the metric must
+// be dropped.  Metrics of new series beyond the limit are either dropped or
+// collapsed in place.
+func (g *CardinalityGuard) Check(metric telegraf.Metric) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(g.rotated) >= g.config.Expiration {
+		g.rotate()
+		g.rotated = now
+	}
+
+	set, ok := g.measurements[metric.Name()]
+	if !ok {
+		set = &seriesSet{current: make(map[uint64]bool)}
+		g.measurements[metric.Name()] = set
+	}
+
+	id := metric.HashID()
+	if g.seen(set, id) {
+		return true
+	}
+
+	if set.len() < g.config.Limit {
+		g.add(set, id)
+		return true
+	}
+
+	stats := g.offender(metric.Name(), set)
+	if g.config.Action == CardinalityActionCollapse {
+		collapsed := false
+		for _, key := range g.config.CollapseTags {
+			if value, ok := metric.GetTag(key); ok && value != CollapsedTagValue {
+				metric.AddTag(key, CollapsedTagValue)
+				collapsed = true
+			}
+		}
+		if collapsed {
+			g.Collapsed.Incr(1)
+			stats.collapsed.Incr(1)
+
+			// The collapsed series are bounded by the tags that are not
+			// collapsed, so they are always accepted.
+			id = metric.HashID()
+			if !g.seen(set, id) {
+				g.add(set, id)
+				stats.series.Set(int64(set.len()))
+			}
+			return true
+		}
+	}
+
+	g.Dropped.Incr(1)
+	stats.dropped.Incr(1)
+	return false
+}
+
+// seen returns true if the series is known, moving it to the current
+// generation.
+func (g *CardinalityGuard) seen(set *seriesSet, id uint64) bool {
+	if set.current[id] {
+		return true
+	}
+	if set.previous[id] {
+		delete(set.previous, id)
+		set.current[id] = true
+		return true
+	}
+	return false
+}
+
+func (g *CardinalityGuard) add(set *seriesSet, id uint64) {
+	set.current[id] = true
+	g.Series.Incr(1)
+}
+
+// offender returns the statistics of a measurement that reached the limit,
+// registering them the first time.
+func (g *CardinalityGuard) offender(name string, set *seriesSet) *offenderStats {
+	if set.offender == nil {
+		g.log.Warnf("Measurement %q reached the limit of %d series, new series are %s",
+			name, g.config.Limit, pastTense(g.config.Action))
+
+		tags := make(map[string]string, len(g.tags)+1)
+		for k, v := range g.tags {
+			tags[k] = v
+		}
+		tags["measurement"] = name
+		set.offender = &offenderStats{
+			series:    selfstat.Register("cardinality_limited", "series", tags),
+			dropped:   selfstat.Register("cardinality_limited", "metrics_dropped", tags),
+			collapsed: selfstat.Register("cardinality_limited", "metrics_collapsed", tags),
+		}
+	}
+	set.offender.series.Set(int64(set.len()))
+	return set.offender
+}
+
+// rotate forgets the series that were not seen during the last generation.
+func (g *CardinalityGuard) rotate() {
+	var total int
+	for name, set := range g.measurements {
+		set.previous = set.current
+		set.current = make(map[uint64]bool)
+		if len(set.previous) == 0 {
+			if set.offender != nil {
+				set.offender.series.Set(0)
+			}
+			delete(g.measurements, name)
+			continue
+		}
+		total += set.len()
+		if set.offender != nil {
+			set.offender.series.Set(int64(set.len()))
+		}
+	}
+	g.Series.Set(int64(total))
+}
+
+func pastTense(action string) string {
+	if action == CardinalityActionCollapse {
+		return "collapsed"
+	}
+	return "dropped"
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func containerMetric(name, container string) telegraf.Metric {
+	return testutil.MustMetric(name,
+		map[string]string{"host": "a", "container_id": container},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0))
+}
+
+func TestCardinalityConfigValidate(t *testing.T) {
+	cc := CardinalityConfig{Limit: 10}
+	require.NoError(t, cc.Validate())
+
+	cc = CardinalityConfig{Limit: 10, Action: "truncate"}
+	require.Error(t, cc.Validate())
+
+	cc = CardinalityConfig{Limit: 10, Action: CardinalityActionCollapse}
+	require.Error(t, cc.Validate())
+
+	cc = CardinalityConfig{Limit: -1}
+	require.Error(t, cc.Validate())
+}
+
+func TestCardinalityGuardDisabled(t *testing.T) {
+	require.Nil(t, NewCardinalityGuard(CardinalityConfig{}, nil, testutil.Logger{}))
+}
+
+func TestCardinalityGuardDrop(t *testing.T) {
+	tags := map[string]string{"input": "TestCardinalityGuardDrop"}
+	g := NewCardinalityGuard(CardinalityConfig{
+		Limit:      2,
+		Action:     CardinalityActionDrop,
+		Expiration: time.Hour,
+	}, tags, testutil.Logger{})
+
+	require.True(t, g.Check(containerMetric("docker", "1")))
+	require.True(t, g.Check(containerMetric("docker", "2")))
+	require.False(t, g.Check(containerMetric("docker", "3")))
+
+	// Known series and other measurements are not limited.
+	require.True(t, g.Check(containerMetric("docker", "1")))
+	require.True(t, g.Check(containerMetric("cpu", "3")))
+
+	require.Equal(t, map[string]int64{
+		"series":            3,
+		"metrics_dropped":   1,
+		"metrics_collapsed": 0,
+	}, selfstat.Values("cardinality", tags))
+
+	tags["measurement"] = "docker"
+	require.Equal(t, map[string]int64{
+		"series":            2,
+		"metrics_dropped":   1,
+		"metrics_collapsed": 0,
+	}, selfstat.Values("cardinality_limited", tags))
+}
+
+func TestCardinalityGuardCollapse(t *testing.T) {
+	g := NewCardinalityGuard(CardinalityConfig{
+		Limit:        2,
+		Action:       CardinalityActionCollapse,
+		CollapseTags: []string{"container_id"},
+		Expiration:   time.Hour,
+	}, map[string]string{"input": "TestCardinalityGuardCollapse"}, testutil.Logger{})
+
+	require.True(t, g.Check(containerMetric("docker", "1")))
+	require.True(t, g.Check(containerMetric("docker", "2")))
+
+	for i := 3; i < 10; i++ {
+		m := containerMetric("docker", fmt.Sprint(i))
+		require.True(t, g.Check(m))
+		testutil.RequireMetricEqual(t, containerMetric("docker", CollapsedTagValue), m)
+	}
+	require.Equal(t, int64(7), g.Collapsed.Get())
+	require.Equal(t, int64(3), g.Series.Get())
+
+	// Metrics without the collapsed tags are dropped.
+	m := testutil.MustMetric("docker",
+		map[string]string{"host": "b"},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0))
+	require.False(t, g.Check(m))
+}
+
+func TestCardinalityGuardExpiration(t *testing.T) {
+	g := NewCardinalityGuard(CardinalityConfig{
+		Limit:      2,
+		Action:     CardinalityActionDrop,
+		Expiration: time.Hour,
+	}, map[string]string{"input": "TestCardinalityGuardExpiration"}, testutil.Logger{})
+
+	require.True(t, g.Check(containerMetric("docker", "1")))
+	require.True(t, g.Check(containerMetric("docker", "2")))
+	require.False(t, g.Check(containerMetric("docker", "3")))
+
+	// Series seen in the previous generation are kept.
+	g.rotated = g.rotated.Add(-time.Hour)
+	require.True(t, g.Check(containerMetric("docker", "1")))
+	require.False(t, g.Check(containerMetric("docker", "3")))
+
+	// Series not seen for a full generation are forgotten.
+	g.rotated = g.rotated.Add(-time.Hour)
+	require.True(t, g.Check(containerMetric("docker", "1")))
+	require.True(t, g.Check(containerMetric("docker", "3")))
+	require.Equal(t, int64(2), g.Series.Get())
+}
+
+func TestMakeMetricCardinalityLimit(t *testing.T) {
+	config := &InputConfig{
+		Name: "TestMakeMetricCardinalityLimit",
+		Cardinality: CardinalityConfig{
+			Limit:      1,
+			Action:     CardinalityActionDrop,
+			Expiration: time.Hour,
+		},
+	}
+	ri := NewRunningInput(&testInput{}, config)
+
+	require.NotNil(t, ri.MakeMetric(containerMetric("docker", "1")))
+	require.Nil(t, ri.MakeMetric(containerMetric("docker", "2")))
+	require.NotNil(t, ri.MakeMetric(containerMetric("docker", "1")))
+}
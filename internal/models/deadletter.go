@@ -0,0 +1,70 @@
+package models
+
+import (
+	"os"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// deadLetter receives the metrics an output rejected permanently.
+type deadLetter interface {
+	// add takes ownership of the metrics.
+	add(metrics []telegraf.Metric) error
+	close() error
+}
+
+// fileDeadLetter appends the metrics in line protocol to a file.
+type fileDeadLetter struct {
+	sync.Mutex
+	file       *os.File
+	serializer *influx.Serializer
+}
+
+func newFileDeadLetter(path string) (*fileDeadLetter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	serializer := influx.NewSerializer()
+	serializer.SetFieldSortOrder(influx.SortFields)
+	serializer.SetFieldTypeSupport(influx.UintSupport)
+	return &fileDeadLetter{file: file, serializer: serializer}, nil
+}
+
+func (d *fileDeadLetter) add(metrics []telegraf.Metric) error {
+	d.Lock()
+	defer d.Unlock()
+
+	octets, err := d.serializer.SerializeBatch(metrics)
+	for _, m := range metrics {
+		m.Drop()
+	}
+	if err != nil {
+		return err
+	}
+	_, err = d.file.Write(octets)
+	return err
+}
+
+func (d *fileDeadLetter) close() error {
+	return d.file.Close()
+}
+
+// outputDeadLetter adds the metrics to another output.
+type outputDeadLetter struct {
+	output *RunningOutput
+}
+
+func (d *outputDeadLetter) add(metrics []telegraf.Metric) error {
+	for _, m := range metrics {
+		d.output.AddMetric(m)
+	}
+	return nil
+}
+
+func (d *outputDeadLetter) close() error {
+	return nil
+}
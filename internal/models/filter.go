@@ -2,9 +2,11 @@ package models
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 // TagFilter is the name of a tag, and the values on which to filter
@@ -34,6 +36,17 @@ type Filter struct {
 	TagInclude []string
 	tagInclude filter.Filter
 
+	// MetricPass is an expression that must evaluate to true for the metric
+	// to pass, see filter.Expression.
+	MetricPass string
+	metricPass *filter.Expression
+
+	// metricPassErrors counts the metrics dropped because metricpass failed
+	// to evaluate, metricPassFailed is set once the first error is logged.
+	metricPassErrors selfstat.Stat
+	metricPassFailed int32
+	log              telegraf.Logger
+
 	isActive bool
 }
 
@@ -46,7 +59,8 @@ func (f *Filter) Compile() error {
 		len(f.TagInclude) == 0 &&
 		len(f.TagExclude) == 0 &&
 		len(f.TagPass) == 0 &&
-		len(f.TagDrop) == 0 {
+		len(f.TagDrop) == 0 &&
+		f.MetricPass == "" {
 		return nil
 	}
 
@@ -91,11 +105,19 @@ func (f *Filter) Compile() error {
 			return fmt.Errorf("Error compiling 'tagpass', %s", err)
 		}
 	}
+
+	if f.MetricPass != "" {
+		f.metricPass, err = filter.CompileExpression(f.MetricPass)
+		if err != nil {
+			return fmt.Errorf("Error compiling 'metricpass', %s", err)
+		}
+	}
 	return nil
 }
 
 // Select returns true if the metric matches according to the
-// namepass/namedrop and tagpass/tagdrop filters.  The metric is not modified.
+// namepass/namedrop, tagpass/tagdrop and metricpass filters.  The metric is
+// not modified.
 func (f *Filter) Select(metric telegraf.Metric) bool {
 	if !f.isActive {
 		return true
@@ -109,9 +131,48 @@ func (f *Filter) Select(metric telegraf.Metric) bool {
 		return false
 	}
 
+	if f.metricPass != nil {
+		ok, err := f.metricPass.Eval(metric)
+		if err != nil {
+			f.metricPassError(err)
+			return false
+		}
+		if !ok {
+			return false
+		}
+	}
+
 	return true
 }
 
+// setStats sets the logger and registers the stats of the plugin the filter
+// belongs to, used to report metricpass errors.
+func (f *Filter) setStats(tags map[string]string, log telegraf.Logger) {
+	if f.MetricPass == "" {
+		return
+	}
+	f.log = log
+	f.metricPassErrors = selfstat.Register("metricpass", "errors", tags)
+}
+
+// metricPassError counts a metric dropped because metricpass failed to
+// evaluate.  Such errors usually repeat for every metric, so only the first
+// one is logged as an error and the others at debug level.
+func (f *Filter) metricPassError(err error) {
+	if f.metricPassErrors != nil {
+		f.metricPassErrors.Incr(1)
+	}
+	if f.log == nil {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&f.metricPassFailed, 0, 1) {
+		f.log.Errorf("Error evaluating metricpass %q, dropping metric: %v "+
+			"(further errors are logged at debug level)", f.MetricPass, err)
+		return
+	}
+	f.log.Debugf("Error evaluating metricpass %q, dropping metric: %v", f.MetricPass, err)
+}
+
 // Modify removes any tags and fields from the metric according to the
 // fieldpass/fielddrop and taginclude/tagexclude filters.
 func (f *Filter) Modify(metric telegraf.Metric) {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -482,6 +483,95 @@ func TestFilter_FilterTagsPassAndDrop(t *testing.T) {
 
 }
 
+func TestFilter_MetricPass(t *testing.T) {
+	f := Filter{
+		NamePass:   []string{"cpu"},
+		MetricPass: `fields.usage_idle < 95 && tags.region.startsWith("us-")`,
+	}
+	require.NoError(t, f.Compile())
+	require.True(t, f.IsActive())
+
+	tests := []struct {
+		name     string
+		metric   telegraf.Metric
+		expected bool
+	}{
+		{
+			name: "pass",
+			metric: testutil.MustMetric("cpu",
+				map[string]string{"region": "us-east-1"},
+				map[string]interface{}{"usage_idle": 42.0},
+				time.Unix(0, 0)),
+			expected: true,
+		},
+		{
+			name: "field does not match",
+			metric: testutil.MustMetric("cpu",
+				map[string]string{"region": "us-east-1"},
+				map[string]interface{}{"usage_idle": 99.0},
+				time.Unix(0, 0)),
+		},
+		{
+			name: "tag missing",
+			metric: testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{"usage_idle": 42.0},
+				time.Unix(0, 0)),
+		},
+		{
+			name: "evaluation error",
+			metric: testutil.MustMetric("cpu",
+				map[string]string{"region": "us-east-1"},
+				map[string]interface{}{"usage_idle": "idle"},
+				time.Unix(0, 0)),
+		},
+		{
+			name: "namepass does not match",
+			metric: testutil.MustMetric("mem",
+				map[string]string{"region": "us-east-1"},
+				map[string]interface{}{"usage_idle": 42.0},
+				time.Unix(0, 0)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, f.Select(tt.metric))
+		})
+	}
+}
+
+func TestFilter_MetricPassErrors(t *testing.T) {
+	f := Filter{
+		MetricPass: `fields.usage_idle < 95`,
+	}
+	require.NoError(t, f.Compile())
+
+	tags := map[string]string{"input": "metricpass_errors_test"}
+	logger := &Logger{
+		Name: "inputs.metricpass_errors_test",
+		Errs: selfstat.Register("gather", "errors", tags),
+	}
+	f.setStats(tags, logger)
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage_idle": "idle"},
+		time.Unix(0, 0))
+	require.False(t, f.Select(m))
+	require.False(t, f.Select(m))
+
+	// Every error is counted but only the first is logged as an error.
+	require.Equal(t, int64(2), f.metricPassErrors.Get())
+	require.Equal(t, int64(1), logger.Errs.Get())
+}
+
+func TestFilter_MetricPassInvalid(t *testing.T) {
+	f := Filter{
+		MetricPass: `fields.usage_idle >`,
+	}
+	require.Error(t, f.Compile())
+}
+
 func BenchmarkFilter(b *testing.B) {
 	tests := []struct {
 		name   string
@@ -11,16 +11,26 @@ import (
 
 type RunningAggregator struct {
 	sync.Mutex
-	Aggregator  telegraf.Aggregator
-	Config      *AggregatorConfig
+	Aggregator telegraf.Aggregator
+	Config     *AggregatorConfig
+
+	// Fingerprint identifies the configuration the plugin was created from,
+	// plugins with the same fingerprint are configured identically.
+	Fingerprint string
+
 	periodStart time.Time
 	periodEnd   time.Time
 	log         telegraf.Logger
 
-	MetricsPushed   selfstat.Stat
-	MetricsFiltered selfstat.Stat
-	MetricsDropped  selfstat.Stat
-	PushTime        selfstat.Stat
+	// pending are the metrics of the next window received during the
+	// window grace period, added after the window is pushed.
+	pending []telegraf.Metric
+
+	MetricsPushed      selfstat.Stat
+	MetricsFiltered    selfstat.Stat
+	MetricsDropped     selfstat.Stat
+	MetricsDroppedLate selfstat.Stat
+	PushTime           selfstat.Stat
 }
 
 func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConfig) *RunningAggregator {
@@ -35,6 +45,7 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 	}
 
 	setLogIfExist(aggregator, logger)
+	config.Filter.setStats(tags, logger)
 
 	return &RunningAggregator{
 		Aggregator: aggregator,
@@ -54,6 +65,11 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 			"metrics_dropped",
 			tags,
 		),
+		MetricsDroppedLate: selfstat.Register(
+			"aggregate",
+			"metrics_dropped_late",
+			tags,
+		),
 		PushTime: selfstat.Register(
 			"aggregate",
 			"push_time_ns",
@@ -63,6 +79,14 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 	}
 }
 
+const (
+	// LateMetricsDrop drops the metrics older than the window.
+	LateMetricsDrop = "drop"
+	// LateMetricsCurrent adds the metrics older than the window to the
+	// current window.
+	LateMetricsCurrent = "current"
+)
+
 // AggregatorConfig is the common config for all aggregators.
 type AggregatorConfig struct {
 	Name         string
@@ -72,6 +96,17 @@ type AggregatorConfig struct {
 	Delay        time.Duration
 	Grace        time.Duration
 
+	// AlignWindows aligns the windows to the wall clock in the local time
+	// zone, regardless of the agent round_interval.
+	AlignWindows bool
+	// WindowGrace is how long a window is kept open after its end for late
+	// metrics, delaying its push.  Grace applies once the window is pushed,
+	// from the start of the following window.
+	WindowGrace time.Duration
+	// LateMetrics is how to handle metrics older than the window start minus
+	// Grace, either LateMetricsDrop or LateMetricsCurrent.
+	LateMetrics string
+
 	NameOverride      string
 	MeasurementPrefix string
 	MeasurementSuffix string
@@ -83,6 +118,16 @@ func (r *RunningAggregator) LogName() string {
 	return logName("aggregators", r.Config.Name, r.Config.Alias)
 }
 
+// Stats returns the current value of the internal statistics of the
+// aggregator.
+func (r *RunningAggregator) Stats() map[string]int64 {
+	tags := map[string]string{"aggregator": r.Config.Name}
+	if r.Config.Alias != "" {
+		tags["alias"] = r.Config.Alias
+	}
+	return selfstat.Values("aggregate", tags)
+}
+
 func (r *RunningAggregator) Init() error {
 	if p, ok := r.Aggregator.(telegraf.Initializer); ok {
 		err := p.Init()
@@ -101,6 +146,12 @@ func (r *RunningAggregator) EndPeriod() time.Time {
 	return r.periodEnd
 }
 
+// NextPush returns the time the current window is pushed, after the window
+// grace period.
+func (r *RunningAggregator) NextPush() time.Time {
+	return r.periodEnd.Add(r.Config.WindowGrace)
+}
+
 func (r *RunningAggregator) UpdateWindow(start, until time.Time) {
 	r.periodStart = start
 	r.periodEnd = until
@@ -147,15 +198,32 @@ func (r *RunningAggregator) Add(m telegraf.Metric) bool {
 	r.Lock()
 	defer r.Unlock()
 
-	if m.Time().Before(r.periodStart.Add(-r.Config.Grace)) || m.Time().After(r.periodEnd.Add(r.Config.Delay)) {
-		r.log.Debugf("Metric is outside aggregation window; discarding. %s: m: %s e: %s g: %s",
+	r.add(m)
+	return r.Config.DropOriginal
+}
+
+func (r *RunningAggregator) add(m telegraf.Metric) {
+	switch {
+	case m.Time().Before(r.periodStart.Add(-r.Config.Grace)):
+		if r.Config.LateMetrics == LateMetricsCurrent {
+			r.Aggregator.Add(m)
+			return
+		}
+		r.log.Debugf("Metric is older than aggregation window; discarding. %s: m: %s e: %s g: %s",
 			m.Time(), r.periodStart, r.periodEnd, r.Config.Grace)
 		r.MetricsDropped.Incr(1)
-		return r.Config.DropOriginal
+		r.MetricsDroppedLate.Incr(1)
+	case m.Time().After(r.periodEnd.Add(r.Config.Delay)):
+		if r.Config.WindowGrace > 0 && !m.Time().After(r.periodEnd.Add(r.Config.Period+r.Config.Delay)) {
+			r.pending = append(r.pending, m)
+			return
+		}
+		r.log.Debugf("Metric is newer than aggregation window; discarding. %s: m: %s e: %s g: %s",
+			m.Time(), r.periodStart, r.periodEnd, r.Config.Grace)
+		r.MetricsDropped.Incr(1)
+	default:
+		r.Aggregator.Add(m)
 	}
-
-	r.Aggregator.Add(m)
-	return r.Config.DropOriginal
 }
 
 func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
@@ -168,6 +236,12 @@ func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 
 	r.push(acc)
 	r.Aggregator.Reset()
+
+	pending := r.pending
+	r.pending = nil
+	for _, m := range pending {
+		r.add(m)
+	}
 }
 
 func (r *RunningAggregator) push(acc telegraf.Accumulator) {
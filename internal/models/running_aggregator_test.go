@@ -151,6 +151,90 @@ func TestAddMetricsOutsideCurrentPeriodWithGrace(t *testing.T) {
 	require.Equal(t, int64(203), acc.Metrics[0].Fields["sum"])
 }
 
+func TestAddLateMetrics(t *testing.T) {
+	tests := []struct {
+		name        string
+		lateMetrics string
+		sum         int64
+		droppedLate int64
+	}{
+		{
+			name:        "drop",
+			lateMetrics: LateMetricsDrop,
+			sum:         101,
+			droppedLate: 1,
+		},
+		{
+			name:        "current",
+			lateMetrics: LateMetricsCurrent,
+			sum:         201,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+				Name:        "TestRunningAggregator",
+				Alias:       "TestAddLateMetrics_" + tt.name,
+				Period:      time.Minute,
+				LateMetrics: tt.lateMetrics,
+			})
+			require.NoError(t, ra.Config.Filter.Compile())
+			acc := testutil.Accumulator{}
+
+			now := time.Now()
+			ra.UpdateWindow(now, now.Add(ra.Config.Period))
+
+			ra.Add(testutil.MustMetric("RITest",
+				map[string]string{},
+				map[string]interface{}{"value": int64(100)},
+				now.Add(-time.Hour)))
+			ra.Add(testutil.MustMetric("RITest",
+				map[string]string{},
+				map[string]interface{}{"value": int64(101)},
+				now))
+
+			ra.Push(&acc)
+			acc.AssertContainsFields(t, "TestMetric", map[string]interface{}{"sum": tt.sum})
+
+			stats := ra.Stats()
+			require.Equal(t, tt.droppedLate, stats["metrics_dropped"])
+			require.Equal(t, tt.droppedLate, stats["metrics_dropped_late"])
+		})
+	}
+}
+
+func TestAddWithWindowGrace(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:        "TestRunningAggregator",
+		Period:      time.Minute,
+		WindowGrace: 10 * time.Second,
+	})
+	require.NoError(t, ra.Config.Filter.Compile())
+
+	start := time.Unix(0, 0)
+	ra.UpdateWindow(start, start.Add(ra.Config.Period))
+	require.Equal(t, start.Add(70*time.Second), ra.NextPush())
+
+	// metric of the window, arriving late
+	ra.Add(testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{"value": int64(100)},
+		start.Add(50*time.Second)))
+	// metric of the next window, arriving during the window grace period
+	ra.Add(testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{"value": int64(101)},
+		start.Add(65*time.Second)))
+
+	acc := testutil.Accumulator{}
+	ra.Push(&acc)
+	acc.AssertContainsFields(t, "TestMetric", map[string]interface{}{"sum": int64(100)})
+
+	acc.ClearMetrics()
+	ra.Push(&acc)
+	acc.AssertContainsFields(t, "TestMetric", map[string]interface{}{"sum": int64(101)})
+}
+
 func TestAddAndPushOnePeriod(t *testing.T) {
 	a := &TestAggregator{}
 	ra := NewRunningAggregator(a, &AggregatorConfig{
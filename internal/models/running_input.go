@@ -1,9 +1,11 @@
 package models
 
 import (
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/cron"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -13,11 +15,29 @@ type RunningInput struct {
 	Input  telegraf.Input
 	Config *InputConfig
 
+	// Fingerprint identifies the configuration the plugin was created from,
+	// plugins with the same fingerprint are configured identically.
+	Fingerprint string
+
 	log         telegraf.Logger
 	defaultTags map[string]string
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
+
+	statusMu   sync.Mutex
+	lastGather GatherStatus
+
+	cardinality *CardinalityGuard
+
+	started bool
+}
+
+// GatherStatus describes the most recent call to Gather.
+type GatherStatus struct {
+	Time     time.Time
+	Duration time.Duration
+	Err      error
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
@@ -31,6 +51,7 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 		Errs: selfstat.Register("gather", "errors", tags),
 	}
 	setLogIfExist(input, logger)
+	config.Filter.setStats(tags, logger)
 
 	return &RunningInput{
 		Input:  input,
@@ -45,7 +66,8 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 			"gather_time_ns",
 			tags,
 		),
-		log: logger,
+		log:         logger,
+		cardinality: NewCardinalityGuard(config.Cardinality, tags, logger),
 	}
 }
 
@@ -55,6 +77,23 @@ type InputConfig struct {
 	Alias    string
 	Interval time.Duration
 
+	// CollectionJitter overrides the agent collection_jitter if set.
+	CollectionJitter *time.Duration
+	// CollectionOffset shifts the gather times by a fixed duration.
+	CollectionOffset time.Duration
+	// Schedule, if set, replaces the interval for the gather times.
+	Schedule *cron.Schedule
+	// Precision overrides the agent precision if set.  Unlike the agent
+	// precision it also applies to the metrics of service inputs.
+	Precision time.Duration
+
+	// StartupErrorBehavior selects what happens if a service input fails
+	// to start, one of the StartupErrorBehavior constants.
+	StartupErrorBehavior string
+
+	// Cardinality limits the number of series of each measurement.
+	Cardinality CardinalityConfig
+
 	NameOverride      string
 	MeasurementPrefix string
 	MeasurementSuffix string
@@ -80,6 +119,43 @@ func (r *RunningInput) Init() error {
 	return nil
 }
 
+// IsServiceInput returns true if the input must be started before gathering.
+func (r *RunningInput) IsServiceInput() bool {
+	_, ok := r.Input.(telegraf.ServiceInput)
+	return ok
+}
+
+// Start starts a service input.  It does nothing for other inputs.
+func (r *RunningInput) Start(acc telegraf.Accumulator) error {
+	si, ok := r.Input.(telegraf.ServiceInput)
+	if !ok || r.started {
+		return nil
+	}
+
+	if err := si.Start(acc); err != nil {
+		return err
+	}
+	r.started = true
+	return nil
+}
+
+// Started returns true if the input is not a service input or has been
+// started.
+func (r *RunningInput) Started() bool {
+	return !r.IsServiceInput() || r.started
+}
+
+// Stop stops a started service input.  It does nothing for other inputs.
+func (r *RunningInput) Stop() {
+	si, ok := r.Input.(telegraf.ServiceInput)
+	if !ok || !r.started {
+		return
+	}
+
+	si.Stop()
+	r.started = false
+}
+
 func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	if ok := r.Config.Filter.Select(metric); !ok {
 		r.metricFiltered(metric)
@@ -100,19 +176,62 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		return nil
 	}
 
+	// Metrics over the cardinality limit are only counted in the cardinality
+	// stats, they are not filtered.
+	if r.cardinality != nil && !r.cardinality.Check(m) {
+		m.Drop()
+		return nil
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return m
 }
 
 func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
+	r.statusMu.Lock()
+	r.lastGather.Err = nil
+	r.statusMu.Unlock()
+
 	start := time.Now()
 	err := r.Input.Gather(acc)
 	elapsed := time.Since(start)
 	r.GatherTime.Incr(elapsed.Nanoseconds())
+
+	r.statusMu.Lock()
+	r.lastGather.Time = start
+	r.lastGather.Duration = elapsed
+	if err != nil {
+		r.lastGather.Err = err
+	}
+	r.statusMu.Unlock()
 	return err
 }
 
+// SetLastError records an error added by the input to its accumulator.
+func (r *RunningInput) SetLastError(err error) {
+	r.statusMu.Lock()
+	r.lastGather.Err = err
+	r.statusMu.Unlock()
+}
+
+// LastGather returns the status of the most recent Gather.  The error is the
+// last one returned or added to the accumulator since that Gather started.
+func (r *RunningInput) LastGather() GatherStatus {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	return r.lastGather
+}
+
+// Stats returns the current value of the internal statistics of the input.
+func (r *RunningInput) Stats() map[string]int64 {
+	tags := map[string]string{"input": r.Config.Name}
+	if r.Config.Alias != "" {
+		tags["alias"] = r.Config.Alias
+	}
+	return selfstat.Values("gather", tags)
+}
+
 func (r *RunningInput) SetDefaultTags(tags map[string]string) {
 	r.defaultTags = tags
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -256,6 +257,32 @@ func TestMakeMetricNameSuffix(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestLastGather(t *testing.T) {
+	ri := NewRunningInput(&errorInput{}, &InputConfig{Name: "TestRunningInput"})
+	require.True(t, ri.LastGather().Time.IsZero())
+
+	acc := &testutil.Accumulator{}
+	err := ri.Gather(acc)
+	require.Error(t, err)
+
+	status := ri.LastGather()
+	require.False(t, status.Time.IsZero())
+	require.Equal(t, err, status.Err)
+
+	ri.SetLastError(errors.New("added"))
+	require.EqualError(t, ri.LastGather().Err, "added")
+
+	ri.Input = &testInput{}
+	require.NoError(t, ri.Gather(acc))
+	require.NoError(t, ri.LastGather().Err)
+}
+
+type errorInput struct{}
+
+func (t *errorInput) Description() string                   { return "" }
+func (t *errorInput) SampleConfig() string                  { return "" }
+func (t *errorInput) Gather(acc telegraf.Accumulator) error { return errors.New("failed") }
+
 type testInput struct{}
 
 func (t *testInput) Description() string                   { return "" }
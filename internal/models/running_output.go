@@ -1,11 +1,14 @@
 package models
 
 import (
+	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -15,6 +18,14 @@ const (
 
 	// Default number of metrics kept. It should be a multiple of batch size.
 	DEFAULT_METRIC_BUFFER_LIMIT = 10000
+
+	// DefaultRetryBackoffMax is the default maximum delay between retries of
+	// failed writes.
+	DefaultRetryBackoffMax = 5 * time.Minute
+
+	// Buffer strategies selectable with buffer_strategy.
+	BufferStrategyMemory = "memory"
+	BufferStrategyDisk   = "disk"
 )
 
 // OutputConfig containing name and filter
@@ -27,6 +38,37 @@ type OutputConfig struct {
 	FlushJitter       *time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+
+	// MaxConcurrentWrites is the number of batches written at once, only
+	// outputs implementing telegraf.ConcurrentOutput support more than one.
+	MaxConcurrentWrites int
+
+	// Precision, if set, rounds the timestamps of the metrics before they
+	// are buffered and serialized.
+	Precision time.Duration
+
+	// BufferStrategy selects where unwritten metrics are kept, either in
+	// memory or in a write-ahead log stored in BufferDirectory.
+	BufferStrategy  string
+	BufferDirectory string
+
+	// StartupErrorBehavior selects what happens if the output fails to
+	// connect, one of the StartupErrorBehavior constants.
+	StartupErrorBehavior string
+
+	// Cardinality limits the number of series of each measurement.
+	Cardinality CardinalityConfig
+
+	// RetryBackoffInitial, if set, delays the next write after a failed
+	// write.  The delay doubles with each consecutive failure up to
+	// RetryBackoffMax.
+	RetryBackoffInitial time.Duration
+	RetryBackoffMax     time.Duration
+
+	// DeadLetterFile and DeadLetterOutput, the alias of another output,
+	// receive the metrics the output rejects permanently.
+	DeadLetterFile   string
+	DeadLetterOutput string
 }
 
 // RunningOutput contains the output configuration
@@ -34,19 +76,39 @@ type RunningOutput struct {
 	// Must be 64-bit aligned
 	newMetricsCount int64
 	droppedMetrics  int64
+	disconnected    int32
 
 	Output            telegraf.Output
 	Config            *OutputConfig
 	MetricBufferLimit int
 	MetricBatchSize   int
 
+	// Fingerprint identifies the configuration the plugin was created from,
+	// plugins with the same fingerprint are configured identically.
+	Fingerprint string
+
 	MetricsFiltered selfstat.Stat
+	MetricsRejected selfstat.Stat
 	WriteTime       selfstat.Stat
 
 	BatchReady chan time.Time
 
-	buffer *Buffer
-	log    telegraf.Logger
+	buffer      *Buffer
+	log         telegraf.Logger
+	cardinality *CardinalityGuard
+
+	// retries is the number of consecutive failed writes, no writes are
+	// attempted before retryAt.
+	retries int
+	retryAt time.Time
+
+	// deadLetter is replaced on reloads while the output is flushing.
+	deadLetterMu sync.Mutex
+	deadLetter   deadLetter
+
+	// routes are the routes of a router output by name, set by Init.
+	routes          map[string]*outputRoute
+	metricsUnrouted selfstat.Stat
 
 	aggMutex sync.Mutex
 }
@@ -68,6 +130,7 @@ func NewRunningOutput(
 		Errs: selfstat.Register("write", "errors", tags),
 	}
 	setLogIfExist(output, logger)
+	config.Filter.setStats(tags, logger)
 
 	if config.MetricBufferLimit > 0 {
 		bufferLimit = config.MetricBufferLimit
@@ -83,7 +146,6 @@ func NewRunningOutput(
 	}
 
 	ro := &RunningOutput{
-		buffer:            NewBuffer(config.Name, config.Alias, bufferLimit),
 		BatchReady:        make(chan time.Time, 1),
 		Output:            output,
 		Config:            config,
@@ -94,17 +156,34 @@ func NewRunningOutput(
 			"metrics_filtered",
 			tags,
 		),
+		MetricsRejected: selfstat.Register(
+			"write",
+			"metrics_rejected",
+			tags,
+		),
 		WriteTime: selfstat.RegisterTiming(
 			"write",
 			"write_time_ns",
 			tags,
 		),
-		log: logger,
+		log:         logger,
+		cardinality: NewCardinalityGuard(config.Cardinality, tags, logger),
+	}
+
+	// The disk buffer is opened by Init since it may fail.
+	if config.BufferStrategy != BufferStrategyDisk {
+		ro.buffer = NewBuffer(config.Name, config.Alias, bufferLimit)
 	}
 
 	return ro
 }
 
+// outputRoute is a route of a router output.
+type outputRoute struct {
+	outputs []string
+	routed  selfstat.Stat
+}
+
 func (r *RunningOutput) LogName() string {
 	return logName("outputs", r.Config.Name, r.Config.Alias)
 }
@@ -122,6 +201,139 @@ func (r *RunningOutput) Init() error {
 		}
 
 	}
+
+	if r.Config.BufferStrategy == BufferStrategyDisk && r.buffer == nil {
+		buffer, err := NewDiskBuffer(r.Config.Name, r.Config.Alias,
+			r.MetricBufferLimit, r.Config.BufferDirectory)
+		if err != nil {
+			return fmt.Errorf("could not open disk buffer: %v", err)
+		}
+		r.buffer = buffer
+	}
+
+	r.deadLetterMu.Lock()
+	defer r.deadLetterMu.Unlock()
+	if r.Config.DeadLetterFile != "" && r.deadLetter == nil {
+		deadLetter, err := newFileDeadLetter(r.Config.DeadLetterFile)
+		if err != nil {
+			return fmt.Errorf("could not open dead letter file: %v", err)
+		}
+		r.deadLetter = deadLetter
+	}
+
+	if router, ok := r.Output.(telegraf.RouterOutput); ok {
+		r.initRoutes(router)
+	}
+	return nil
+}
+
+// SetDeadLetterOutput sets the output receiving the metrics rejected
+// permanently by this output, or detaches it if output is nil.  Once it
+// returns no more metrics are added to the previous output.
+func (r *RunningOutput) SetDeadLetterOutput(output *RunningOutput) {
+	r.deadLetterMu.Lock()
+	defer r.deadLetterMu.Unlock()
+	if output == nil {
+		r.deadLetter = nil
+		return
+	}
+	r.deadLetter = &outputDeadLetter{output: output}
+}
+
+func (r *RunningOutput) initRoutes(router telegraf.RouterOutput) {
+	tags := map[string]string{"output": r.Config.Name}
+	if r.Config.Alias != "" {
+		tags["alias"] = r.Config.Alias
+	}
+	r.metricsUnrouted = selfstat.Register("router", "metrics_unrouted", tags)
+
+	r.routes = make(map[string]*outputRoute)
+	for name, outputs := range router.Routes() {
+		routeTags := map[string]string{"route": name}
+		for k, v := range tags {
+			routeTags[k] = v
+		}
+		r.routes[name] = &outputRoute{
+			outputs: outputs,
+			routed:  selfstat.Register("router", "metrics_routed", routeTags),
+		}
+	}
+}
+
+// IsRouter returns true if the output forwards metrics to other outputs
+// instead of writing them.
+func (r *RunningOutput) IsRouter() bool {
+	_, ok := r.Output.(telegraf.RouterOutput)
+	return ok
+}
+
+// RoutedOutputs returns the aliases of all outputs a router output forwards
+// metrics to.
+func (r *RunningOutput) RoutedOutputs() []string {
+	router, ok := r.Output.(telegraf.RouterOutput)
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var aliases []string
+	for _, outputs := range router.Routes() {
+		for _, alias := range outputs {
+			if !seen[alias] {
+				seen[alias] = true
+				aliases = append(aliases, alias)
+			}
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// Route returns the aliases of the outputs a router output forwards the
+// metric to.  The metric is not modified and remains owned by the caller.
+func (r *RunningOutput) Route(metric telegraf.Metric) []string {
+	if ok := r.Config.Filter.Select(metric); !ok {
+		r.MetricsFiltered.Incr(1)
+		return nil
+	}
+
+	var aliases []string
+	for _, name := range r.Output.(telegraf.RouterOutput).Route(metric) {
+		route, ok := r.routes[name]
+		if !ok {
+			continue
+		}
+		route.routed.Incr(1)
+		aliases = append(aliases, route.outputs...)
+	}
+	if len(aliases) == 0 {
+		r.metricsUnrouted.Incr(1)
+	}
+	return aliases
+}
+
+// RetryConnect marks the output as not connected, the next writes try to
+// connect the output first and keep the metrics in the buffer until the
+// connection succeeds.
+func (r *RunningOutput) RetryConnect() {
+	atomic.StoreInt32(&r.disconnected, 1)
+}
+
+// Connected returns false if the output is waiting for a connection retry.
+func (r *RunningOutput) Connected() bool {
+	return atomic.LoadInt32(&r.disconnected) == 0
+}
+
+func (r *RunningOutput) connect() error {
+	if r.Connected() {
+		return nil
+	}
+
+	if err := r.Output.Connect(); err != nil {
+		return fmt.Errorf("not connected: %v", err)
+	}
+	r.log.Infof("Successfully connected")
+	atomic.StoreInt32(&r.disconnected, 0)
 	return nil
 }
 
@@ -140,6 +352,15 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 		return
 	}
 
+	if ro.cardinality != nil && !ro.cardinality.Check(metric) {
+		metric.Drop()
+		return
+	}
+
+	if ro.Config.Precision > 0 {
+		metric.SetTime(internal.RoundTime(metric.Time(), ro.Config.Precision))
+	}
+
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		output.Add(metric)
@@ -147,8 +368,11 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 		return
 	}
 
-	dropped := ro.buffer.Add(metric)
+	dropped, err := ro.buffer.Add(metric)
 	atomic.AddInt64(&ro.droppedMetrics, int64(dropped))
+	if err != nil {
+		ro.log.Errorf("Error buffering metric: %v", err)
+	}
 
 	count := atomic.AddInt64(&ro.newMetricsCount, 1)
 	if count == int64(ro.MetricBatchSize) {
@@ -161,59 +385,247 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 }
 
 // Write writes all metrics to the output, stopping when all have been sent on
-// or error.
+// or error.  No metrics are written while backing off after a failed write.
 func (ro *RunningOutput) Write() error {
+	return ro.writeAll(false)
+}
+
+// WriteFinal writes all metrics to the output ignoring the retry backoff, it
+// is used for the last write before the output is closed.
+func (ro *RunningOutput) WriteFinal() error {
+	return ro.writeAll(true)
+}
+
+func (ro *RunningOutput) writeAll(ignoreBackoff bool) error {
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		metrics := output.Push()
-		ro.buffer.Add(metrics...)
+		if _, err := ro.buffer.Add(metrics...); err != nil {
+			ro.log.Errorf("Error buffering metrics: %v", err)
+		}
 		output.Reset()
 		ro.aggMutex.Unlock()
 	}
 
 	atomic.StoreInt64(&ro.newMetricsCount, 0)
 
+	if !ignoreBackoff && ro.backingOff() {
+		return nil
+	}
+
+	if err := ro.connect(); err != nil {
+		return err
+	}
+
 	// Only process the metrics in the buffer now.  Metrics added while we are
 	// writing will be sent on the next call.
+	concurrency := ro.concurrency()
 	nBuffer := ro.buffer.Len()
 	nBatches := nBuffer/ro.MetricBatchSize + 1
-	for i := 0; i < nBatches; i++ {
-		batch := ro.buffer.Batch(ro.MetricBatchSize)
-		if len(batch) == 0 {
-			break
-		}
-
-		err := ro.write(batch)
+	for i := 0; i < nBatches; i += concurrency {
+		ok, err := ro.writeBatches(concurrency)
 		if err != nil {
-			ro.buffer.Reject(batch)
 			return err
 		}
-		ro.buffer.Accept(batch)
+		if !ok {
+			break
+		}
 	}
 	return nil
 }
 
-// WriteBatch writes a single batch of metrics to the output.
+// WriteBatch writes a single batch of metrics to the output, or up to
+// max_concurrent_writes batches at once.
 func (ro *RunningOutput) WriteBatch() error {
-	batch := ro.buffer.Batch(ro.MetricBatchSize)
-	if len(batch) == 0 {
+	if ro.backingOff() {
 		return nil
 	}
 
-	err := ro.write(batch)
-	if err != nil {
-		ro.buffer.Reject(batch)
+	if err := ro.connect(); err != nil {
 		return err
 	}
-	ro.buffer.Accept(batch)
 
-	return nil
+	_, err := ro.writeBatches(ro.concurrency())
+	return err
+}
+
+func (ro *RunningOutput) concurrency() int {
+	if ro.Config.MaxConcurrentWrites > 1 {
+		return ro.Config.MaxConcurrentWrites
+	}
+	return 1
+}
+
+// backingOff returns true if no write should be attempted yet after a failed
+// write.
+func (ro *RunningOutput) backingOff() bool {
+	if ro.retries == 0 || !time.Now().Before(ro.retryAt) {
+		return false
+	}
+	ro.log.Debugf("Backing off after %d failed writes, retrying in %s",
+		ro.retries, time.Until(ro.retryAt).Round(time.Millisecond))
+	return true
+}
+
+// updateBackoff records the result of writing a group of batches.
+func (ro *RunningOutput) updateBackoff(failed bool) {
+	if !failed {
+		ro.retries = 0
+		return
+	}
+	if ro.Config.RetryBackoffInitial <= 0 {
+		return
+	}
+
+	maxDelay := ro.Config.RetryBackoffMax
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryBackoffMax
+	}
+
+	ro.retries++
+	delay := ro.Config.RetryBackoffInitial
+	for i := 1; i < ro.retries && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	ro.retryAt = time.Now().Add(delay)
+}
+
+// writeBatches writes up to count batches from the buffer to the output
+// concurrently.  It returns false if the buffer was empty.
+func (ro *RunningOutput) writeBatches(count int) (bool, error) {
+	metrics := ro.buffer.Batch(ro.MetricBatchSize * count)
+	if len(metrics) == 0 {
+		return false, nil
+	}
+
+	var batches [][]telegraf.Metric
+	for len(metrics) > 0 {
+		n := ro.MetricBatchSize
+		if n > len(metrics) {
+			n = len(metrics)
+		}
+		batches = append(batches, metrics[:n])
+		metrics = metrics[n:]
+	}
+
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []telegraf.Metric) {
+			defer wg.Done()
+			errs[i] = ro.write(batch)
+		}(i, batch)
+	}
+	wg.Wait()
+
+	// The batches are ordered from newest to oldest, as required to return
+	// the rejected metrics to the buffer in their original order.
+	var err error
+	for i, batch := range batches {
+		batchErr := errs[i]
+		if perr, ok := batchErr.(*telegraf.PartialWriteError); ok {
+			batch = ro.rejectMetrics(batch, perr.MetricsReject)
+			batchErr = perr.Err
+		}
+
+		if batchErr != nil {
+			if rerr := ro.buffer.Reject(batch); rerr != nil {
+				ro.log.Errorf("Error returning metrics to buffer: %v", rerr)
+			}
+			if err == nil {
+				err = batchErr
+			}
+			continue
+		}
+		if aerr := ro.buffer.Accept(batch); aerr != nil {
+			ro.log.Errorf("Error removing written metrics from buffer: %v", aerr)
+		}
+	}
+	ro.updateBackoff(err != nil)
+	return true, err
+}
+
+// rejectMetrics removes the metrics at the rejected indexes from the buffer,
+// sending them to the dead letter destination, and returns the remaining
+// metrics of the batch.
+func (ro *RunningOutput) rejectMetrics(batch []telegraf.Metric, rejected []int) []telegraf.Metric {
+	isRejected := make(map[int]bool, len(rejected))
+	for _, i := range rejected {
+		if i >= 0 && i < len(batch) {
+			isRejected[i] = true
+		}
+	}
+	if len(isRejected) == 0 {
+		return batch
+	}
+
+	// The lock is held until the metrics are added, so that a detached
+	// dead letter output can be stopped safely.
+	ro.deadLetterMu.Lock()
+	defer ro.deadLetterMu.Unlock()
+
+	var remaining, dropped, copies []telegraf.Metric
+	for i, m := range batch {
+		if !isRejected[i] {
+			remaining = append(remaining, m)
+			continue
+		}
+		dropped = append(dropped, m)
+		if ro.deadLetter != nil {
+			copies = append(copies, m.Copy())
+		}
+	}
+
+	ro.MetricsRejected.Incr(int64(len(dropped)))
+	if err := ro.buffer.Drop(dropped); err != nil {
+		ro.log.Errorf("Error removing rejected metrics from buffer: %v", err)
+	}
+
+	if ro.deadLetter == nil {
+		ro.log.Warnf("Output rejected %d metrics permanently, dropping them", len(dropped))
+		return remaining
+	}
+
+	ro.log.Warnf("Output rejected %d metrics permanently, sending them to the dead letter destination",
+		len(dropped))
+	if err := ro.deadLetter.add(copies); err != nil {
+		ro.log.Errorf("Error writing to dead letter destination: %v", err)
+	}
+	return remaining
 }
 
 func (r *RunningOutput) Close() {
-	err := r.Output.Close()
-	if err != nil {
-		r.log.Errorf("Error closing output: %v", err)
+	if r.Connected() {
+		err := r.Output.Close()
+		if err != nil {
+			r.log.Errorf("Error closing output: %v", err)
+		}
+	}
+
+	r.CloseBuffer()
+
+	r.deadLetterMu.Lock()
+	defer r.deadLetterMu.Unlock()
+	if r.deadLetter != nil {
+		err := r.deadLetter.close()
+		if err != nil {
+			r.log.Errorf("Error closing dead letter destination: %v", err)
+		}
+	}
+}
+
+// CloseBuffer closes the buffer without closing the output, for outputs that
+// were never connected.
+func (r *RunningOutput) CloseBuffer() {
+	if r.buffer != nil {
+		err := r.buffer.Close()
+		if err != nil {
+			r.log.Errorf("Error closing buffer: %v", err)
+		}
 	}
 }
 
@@ -235,6 +647,30 @@ func (r *RunningOutput) write(metrics []telegraf.Metric) error {
 	return err
 }
 
+// BufferLength returns the number of metrics waiting to be written.
+func (r *RunningOutput) BufferLength() int {
+	if r.buffer == nil {
+		return 0
+	}
+	return r.buffer.Len()
+}
+
+// Stats returns the current value of the internal statistics of the output.
+func (r *RunningOutput) Stats() map[string]int64 {
+	tags := map[string]string{"output": r.Config.Name}
+	if r.Config.Alias != "" {
+		tags["alias"] = r.Config.Alias
+	}
+	stats := selfstat.Values("write", tags)
+
+	// The buffer always registers its stats with an alias tag.
+	tags["alias"] = r.Config.Alias
+	for k, v := range selfstat.Values("write", tags) {
+		stats[k] = v
+	}
+	return stats
+}
+
 func (r *RunningOutput) LogBufferStatus() {
 	nBuffer := r.buffer.Len()
 	r.log.Debugf("Buffer fullness: %d / %d metrics", nBuffer, r.MetricBufferLimit)
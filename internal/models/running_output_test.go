@@ -2,8 +2,12 @@ package models
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
@@ -132,6 +136,26 @@ func TestRunningOutput_PassFilter(t *testing.T) {
 	assert.Len(t, m.Metrics(), 10)
 }
 
+// Test that the timestamps are rounded to the output precision.
+func TestRunningOutput_Precision(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:    Filter{},
+		Precision: time.Second,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	ro.AddMetric(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(42, 700000000)))
+	require.NoError(t, ro.Write())
+
+	require.Len(t, m.Metrics(), 1)
+	require.Equal(t, time.Unix(43, 0), m.Metrics()[0].Time())
+}
+
 // Test that tags are properly included
 func TestRunningOutput_TagIncludeNoMatch(t *testing.T) {
 	conf := &OutputConfig{
@@ -270,6 +294,36 @@ func TestRunningOutputWriteFail(t *testing.T) {
 	assert.Len(t, m.Metrics(), 10)
 }
 
+func TestRunningOutputRetryConnect(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	m.failConnect = true
+	ro := NewRunningOutput("test", m, conf, 4, 12)
+	ro.RetryConnect()
+	require.False(t, ro.Connected())
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// metrics are kept while the output cannot connect
+	require.Error(t, ro.Write())
+	require.Error(t, ro.WriteBatch())
+	require.False(t, ro.Connected())
+	assert.Len(t, m.Metrics(), 0)
+	assert.Equal(t, 5, ro.BufferLength())
+
+	m.Lock()
+	m.failConnect = false
+	m.Unlock()
+	require.NoError(t, ro.Write())
+	require.True(t, ro.Connected())
+	assert.Len(t, m.Metrics(), 5)
+}
+
 // Verify that the order of points is preserved during a write failure.
 func TestRunningOutputWriteFailOrder(t *testing.T) {
 	conf := &OutputConfig{
@@ -412,6 +466,206 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+// Test that batches are written concurrently and failed batches are returned
+// to the buffer in order.
+func TestRunningOutputConcurrentWrites(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		MaxConcurrentWrites: 2,
+	}
+
+	m := &concurrentOutput{started: make(chan struct{}, 10), release: make(chan struct{})}
+	ro := NewRunningOutput("test", m, conf, 2, 1000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// The first batch fails.
+	m.fail = first5[4]
+
+	done := make(chan error)
+	go func() {
+		done <- ro.Write()
+	}()
+
+	// Both batches of the first group are in flight before either
+	// completes.
+	<-m.started
+	<-m.started
+	close(m.release)
+	require.Error(t, <-done)
+	require.Equal(t, []telegraf.Metric{first5[2], first5[1]}, m.Metrics())
+
+	// The batches are written in any order.
+	m.fail = nil
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 5)
+	require.ElementsMatch(t, []telegraf.Metric{
+		first5[4], first5[3], first5[0],
+	}, m.Metrics()[2:])
+}
+
+// Test that no writes are attempted while backing off after a failed write.
+func TestRunningOutputRetryBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		RetryBackoffInitial: time.Hour,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	ro.AddMetric(first5[0])
+	require.Error(t, ro.Write())
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	require.NoError(t, ro.WriteBatch())
+	require.Len(t, m.Metrics(), 0)
+	require.Equal(t, 1, ro.BufferLength())
+
+	require.NoError(t, ro.WriteFinal())
+	require.Len(t, m.Metrics(), 1)
+}
+
+// Test that the retry backoff doubles up to the maximum.
+func TestRunningOutputRetryBackoffMax(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		RetryBackoffInitial: time.Minute,
+		RetryBackoffMax:     3 * time.Minute,
+	}
+	ro := NewRunningOutput("test", &mockOutput{}, conf, 1000, 10000)
+
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		ro.updateBackoff(true)
+		require.WithinDuration(t, time.Now().Add(expected), ro.retryAt, time.Second)
+	}
+
+	ro.updateBackoff(false)
+	require.False(t, ro.backingOff())
+}
+
+// Test that metrics rejected permanently are not retried and are sent to the
+// dead letter output.
+func TestRunningOutputDeadLetterOutput(t *testing.T) {
+	m := &rejectingOutput{reject: first5[1]}
+	ro := NewRunningOutput("test", m, &OutputConfig{Filter: Filter{}}, 1000, 10000)
+
+	dlq := &mockOutput{}
+	dl := NewRunningOutput("test", dlq, &OutputConfig{Filter: Filter{}}, 1000, 10000)
+	ro.SetDeadLetterOutput(dl)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 4)
+	require.Equal(t, 0, ro.BufferLength())
+	require.Equal(t, int64(1), ro.MetricsRejected.Get())
+
+	require.NoError(t, dl.Write())
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{first5[1]}, dlq.Metrics())
+}
+
+// Test that no metrics are sent to a detached dead letter output.
+func TestRunningOutputDeadLetterOutputDetached(t *testing.T) {
+	m := &rejectingOutput{reject: first5[1]}
+	ro := NewRunningOutput("test", m, &OutputConfig{Filter: Filter{}}, 1000, 10000)
+
+	dlq := &mockOutput{}
+	dl := NewRunningOutput("test", dlq, &OutputConfig{Filter: Filter{}}, 1000, 10000)
+	ro.SetDeadLetterOutput(dl)
+	ro.SetDeadLetterOutput(nil)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 4)
+	require.Equal(t, 0, dl.BufferLength())
+}
+
+// Test that metrics rejected permanently are written to the dead letter file
+// and the remaining metrics are retried.
+func TestRunningOutputDeadLetterFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:         Filter{},
+		DeadLetterFile: filepath.Join(dir, "dead_letter.influx"),
+	}
+	m := &rejectingOutput{reject: first5[1], err: fmt.Errorf("Failed Write!")}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	require.NoError(t, ro.Init())
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	require.Equal(t, 4, ro.BufferLength())
+	ro.Close()
+
+	octets, err := ioutil.ReadFile(conf.DeadLetterFile)
+	require.NoError(t, err)
+	require.Equal(t, "metric2,tag1=value1 value=101i 1257894000000000000\n", string(octets))
+}
+
+// rejectingOutput rejects a metric permanently, failing the write of the
+// remaining metrics with err.
+type rejectingOutput struct {
+	mockOutput
+
+	reject telegraf.Metric
+	err    error
+}
+
+func (m *rejectingOutput) Write(metrics []telegraf.Metric) error {
+	var remaining []telegraf.Metric
+	perr := &telegraf.PartialWriteError{Err: m.err}
+	for i, metric := range metrics {
+		if metric == m.reject {
+			perr.MetricsReject = append(perr.MetricsReject, i)
+			continue
+		}
+		remaining = append(remaining, metric)
+	}
+
+	if m.err != nil {
+		return perr
+	}
+	if err := m.mockOutput.Write(remaining); err != nil {
+		return err
+	}
+	return perr
+}
+
+type concurrentOutput struct {
+	mockOutput
+
+	fail    telegraf.Metric
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *concurrentOutput) SupportsConcurrentWrites() {}
+
+func (m *concurrentOutput) Write(metrics []telegraf.Metric) error {
+	m.started <- struct{}{}
+	<-m.release
+
+	for _, metric := range metrics {
+		if metric == m.fail {
+			return fmt.Errorf("Failed Write!")
+		}
+	}
+	return m.mockOutput.Write(metrics)
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -419,9 +673,17 @@ type mockOutput struct {
 
 	// if true, mock a write failure
 	failWrite bool
+
+	// if true, mock a connect failure
+	failConnect bool
 }
 
 func (m *mockOutput) Connect() error {
+	m.Lock()
+	defer m.Unlock()
+	if m.failConnect {
+		return fmt.Errorf("Failed Connect!")
+	}
 	return nil
 }
 
@@ -10,8 +10,12 @@ import (
 type RunningProcessor struct {
 	sync.Mutex
 	log       telegraf.Logger
-	Processor telegraf.Processor
+	Processor telegraf.StreamingProcessor
 	Config    *ProcessorConfig
+
+	// Fingerprint identifies the configuration the plugin was created from,
+	// plugins with the same fingerprint are configured identically.
+	Fingerprint string
 }
 
 type RunningProcessors []*RunningProcessor
@@ -28,7 +32,12 @@ type ProcessorConfig struct {
 	Filter Filter
 }
 
-func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig) *RunningProcessor {
+// unwrappable is implemented by processors wrapping a telegraf.Processor.
+type unwrappable interface {
+	Unwrap() telegraf.Processor
+}
+
+func NewRunningProcessor(processor telegraf.StreamingProcessor, config *ProcessorConfig) *RunningProcessor {
 	tags := map[string]string{"processor": config.Name}
 	if config.Alias != "" {
 		tags["alias"] = config.Alias
@@ -38,7 +47,12 @@ func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig)
 		Name: logName("processors", config.Name, config.Alias),
 		Errs: selfstat.Register("process", "errors", tags),
 	}
-	setLogIfExist(processor, logger)
+	if p, ok := processor.(unwrappable); ok {
+		setLogIfExist(p.Unwrap(), logger)
+	} else {
+		setLogIfExist(processor, logger)
+	}
+	config.Filter.setStats(tags, logger)
 
 	return &RunningProcessor{
 		Processor: processor,
@@ -47,6 +61,16 @@ func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig)
 	}
 }
 
+// Stats returns the current value of the internal statistics of the
+// processor.
+func (rp *RunningProcessor) Stats() map[string]int64 {
+	tags := map[string]string{"processor": rp.Config.Name}
+	if rp.Config.Alias != "" {
+		tags["alias"] = rp.Config.Alias
+	}
+	return selfstat.Values("process", tags)
+}
+
 func (rp *RunningProcessor) metricFiltered(metric telegraf.Metric) {
 	metric.Drop()
 }
@@ -70,30 +94,39 @@ func (r *RunningProcessor) Init() error {
 	return nil
 }
 
-func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
-	rp.Lock()
-	defer rp.Unlock()
+func (rp *RunningProcessor) LogName() string {
+	return logName("processors", rp.Config.Name, rp.Config.Alias)
+}
 
-	ret := []telegraf.Metric{}
+// MakeMetric passes metrics emitted by the processor downstream unchanged.
+func (rp *RunningProcessor) MakeMetric(metric telegraf.Metric) telegraf.Metric {
+	return metric
+}
 
-	for _, metric := range in {
-		// In processors when a filter selects a metric it is sent through the
-		// processor.  Otherwise the metric continues downstream unmodified.
-		if ok := rp.Config.Filter.Select(metric); !ok {
-			ret = append(ret, metric)
-			continue
-		}
+func (rp *RunningProcessor) Start(acc telegraf.Accumulator) error {
+	return rp.Processor.Start(acc)
+}
 
-		rp.Config.Filter.Modify(metric)
-		if len(metric.FieldList()) == 0 {
-			rp.metricFiltered(metric)
-			continue
-		}
+// Add passes the metric to the processor if it is selected by the filter,
+// otherwise the metric continues downstream unmodified.
+func (rp *RunningProcessor) Add(metric telegraf.Metric, acc telegraf.Accumulator) error {
+	rp.Lock()
+	defer rp.Unlock()
+
+	if ok := rp.Config.Filter.Select(metric); !ok {
+		acc.AddMetric(metric)
+		return nil
+	}
 
-		// This metric should pass through the filter, so call the filter Apply
-		// function and append results to the output slice.
-		ret = append(ret, rp.Processor.Apply(metric)...)
+	rp.Config.Filter.Modify(metric)
+	if len(metric.FieldList()) == 0 {
+		rp.metricFiltered(metric)
+		return nil
 	}
 
-	return ret
+	return rp.Processor.Add(metric, acc)
+}
+
+func (rp *RunningProcessor) Stop() error {
+	return rp.Processor.Stop()
 }
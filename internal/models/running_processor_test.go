@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/require"
@@ -153,12 +154,19 @@ func TestRunningProcessor_Apply(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			rp := &RunningProcessor{
-				Processor: tt.args.Processor,
+				Processor: processors.NewStreamingProcessorFromProcessor(tt.args.Processor),
 				Config:    tt.args.Config,
 			}
 			rp.Config.Filter.Compile()
 
-			actual := rp.Apply(tt.input...)
+			acc := &testutil.Accumulator{}
+			require.NoError(t, rp.Start(acc))
+			for _, m := range tt.input {
+				require.NoError(t, rp.Add(m, acc))
+			}
+			require.NoError(t, rp.Stop())
+
+			actual := acc.GetTelegrafMetrics()
 			require.Equal(t, tt.expected, actual)
 		})
 	}
@@ -0,0 +1,13 @@
+package models
+
+// Behaviors selectable with startup_error_behavior when an output fails to
+// connect or a service input fails to start.
+const (
+	// StartupErrorBehaviorError stops Telegraf.
+	StartupErrorBehaviorError = "error"
+	// StartupErrorBehaviorRetry keeps the plugin and retries in the
+	// background.
+	StartupErrorBehaviorRetry = "retry"
+	// StartupErrorBehaviorIgnore removes the plugin.
+	StartupErrorBehaviorIgnore = "ignore"
+)
@@ -0,0 +1,238 @@
+// Package process runs a long-running child process, restarting it when it
+// exits unexpectedly.
+package process
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Process is a long-running process manager that will restart processes if
+// they stop.
+type Process struct {
+	// ReadStdoutFn and ReadStderrFn are called with the output of each
+	// started process and should read until EOF.
+	ReadStdoutFn func(io.Reader)
+	ReadStderrFn func(io.Reader)
+
+	// RestartDelay is the time to wait before restarting an exited process.
+	RestartDelay time.Duration
+
+	Log telegraf.Logger
+
+	name string
+	args []string
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	readers sync.WaitGroup // readers of stdout and stderr of cmd
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New returns a Process for the command, the first element being the program
+// to run.
+func New(command []string) (*Process, error) {
+	if len(command) == 0 {
+		return nil, errors.New("no command")
+	}
+
+	p := &Process{
+		RestartDelay: 5 * time.Second,
+		name:         command[0],
+		args:         command[1:],
+	}
+	return p, nil
+}
+
+// Start starts the process and monitors it, restarting it if it exits before
+// Stop is called.  A Process can only be started once.
+func (p *Process) Start() error {
+	if p.ReadStdoutFn == nil {
+		p.ReadStdoutFn = p.logPipe("stdout")
+	}
+	if p.ReadStderrFn == nil {
+		p.ReadStderrFn = p.logPipe("stderr")
+	}
+
+	if err := p.cmdStart(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := p.cmdLoop(ctx); err != nil {
+			p.Log.Errorf("Process quit with message: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop closes stdin of the process, giving it the chance to shut down, and
+// terminates it if it has not exited after a grace period.
+func (p *Process) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+
+	p.mu.Lock()
+	if p.stdin != nil {
+		p.stdin.Close()
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// Write writes to stdin of the currently running process.
+func (p *Process) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stdin == nil {
+		return 0, errors.New("process is not running")
+	}
+	return p.stdin.Write(b)
+}
+
+// Signal sends a signal to the currently running process.
+func (p *Process) Signal(sig os.Signal) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil || p.cmd.Process == nil {
+		return errors.New("process is not running")
+	}
+	return p.cmd.Process.Signal(sig)
+}
+
+// Pid returns the process id of the currently running process.
+func (p *Process) Pid() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil || p.cmd.Process == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
+func (p *Process) cmdStart() error {
+	cmd := exec.Command(p.name, p.args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdin pipe: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdout pipe: %v", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stderr pipe: %v", err)
+	}
+
+	p.Log.Infof("Starting process: %s %s", p.name, p.args)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting process: %v", err)
+	}
+
+	p.mu.Lock()
+	p.cmd = cmd
+	p.stdin = stdin
+	p.mu.Unlock()
+
+	p.readers.Add(2)
+	go func() {
+		defer p.readers.Done()
+		p.ReadStdoutFn(stdout)
+	}()
+	go func() {
+		defer p.readers.Done()
+		p.ReadStderrFn(stderr)
+	}()
+
+	return nil
+}
+
+// cmdLoop waits for the running process to exit and restarts it until the
+// context is done.
+func (p *Process) cmdLoop(ctx context.Context) error {
+	for {
+		err := p.cmdWait(ctx)
+		if ctx.Err() != nil {
+			p.Log.Infof("Process %s shut down", p.name)
+			return nil
+		}
+
+		if err != nil {
+			p.Log.Errorf("Process %s exited: %v", p.name, err)
+		} else {
+			p.Log.Errorf("Process %s exited", p.name)
+		}
+		p.Log.Infof("Restarting in %s...", p.RestartDelay)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(p.RestartDelay):
+		}
+
+		if err := p.cmdStart(); err != nil {
+			return err
+		}
+	}
+}
+
+// cmdWait waits for the running process to exit, terminating it when the
+// context is done.
+func (p *Process) cmdWait(ctx context.Context) error {
+	p.mu.Lock()
+	cmd := p.cmd
+	p.mu.Unlock()
+
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			gracefulStop(cmd, exited, 5*time.Second, p.Log)
+		case <-exited:
+		}
+	}()
+
+	// All output must be read before calling Wait, which closes the pipes.
+	p.readers.Wait()
+	err := cmd.Wait()
+	close(exited)
+
+	p.mu.Lock()
+	p.stdin = nil
+	p.mu.Unlock()
+	return err
+}
+
+func (p *Process) logPipe(name string) func(io.Reader) {
+	return func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			p.Log.Errorf("%s: %s", name, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			p.Log.Errorf("Error reading %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// +build !windows
+
+package process
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// gracefulStop waits for the process to exit after its stdin was closed,
+// sending SIGTERM if it has not exited after the timeout and killing it if it
+// still has not exited after a second timeout.
+func gracefulStop(cmd *exec.Cmd, exited <-chan struct{}, timeout time.Duration, log telegraf.Logger) {
+	select {
+	case <-exited:
+		return
+	case <-time.After(timeout):
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			log.Errorf("Error terminating process: %v", err)
+		}
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		if err := cmd.Process.Kill(); err != nil {
+			log.Errorf("Error killing process: %v", err)
+		}
+	}
+}
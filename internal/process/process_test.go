@@ -0,0 +1,75 @@
+// +build !windows
+
+package process
+
+import (
+	"bufio"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartOnExit(t *testing.T) {
+	p, err := New([]string{"sh", "-c", "echo started"})
+	require.NoError(t, err)
+
+	var starts int64
+	p.Log = testutil.Logger{}
+	p.RestartDelay = 10 * time.Millisecond
+	p.ReadStdoutFn = func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			atomic.AddInt64(&starts, 1)
+		}
+	}
+
+	require.NoError(t, p.Start())
+	for atomic.LoadInt64(&starts) < 3 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	p.Stop()
+}
+
+func TestStopClosesStdin(t *testing.T) {
+	p, err := New([]string{"cat"})
+	require.NoError(t, err)
+
+	lines := make(chan string, 1)
+	p.Log = testutil.Logger{}
+	p.RestartDelay = time.Hour
+	p.ReadStdoutFn = func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}
+
+	require.NoError(t, p.Start())
+	_, err = p.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", <-lines)
+
+	done := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("process did not stop")
+	}
+
+	_, err = p.Write([]byte("world\n"))
+	require.Error(t, err)
+}
+
+func TestNoCommand(t *testing.T) {
+	_, err := New(nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,22 @@
+// +build windows
+
+package process
+
+import (
+	"os/exec"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// gracefulStop kills the process if it has not exited after the timeout,
+// processes cannot be terminated gracefully on Windows.
+func gracefulStop(cmd *exec.Cmd, exited <-chan struct{}, timeout time.Duration, log telegraf.Logger) {
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		if err := cmd.Process.Kill(); err != nil {
+			log.Errorf("Error killing process: %v", err)
+		}
+	}
+}
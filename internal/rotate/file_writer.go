@@ -88,6 +88,13 @@ func (w *FileWriter) Write(p []byte) (n int, err error) {
 	return n, nil
 }
 
+// Stat returns the FileInfo of the current file.
+func (w *FileWriter) Stat() (os.FileInfo, error) {
+	w.Lock()
+	defer w.Unlock()
+	return w.current.Stat()
+}
+
 // Close closes the current file.  Writer is unusable after this
 // is called.
 func (w *FileWriter) Close() (err error) {
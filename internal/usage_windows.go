@@ -11,11 +11,24 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+  config check        check the configuration for unknown plugins and keys,
+                      invalid values, and deprecated plugins and options
+  config migrate      write the configuration with deprecated plugins and
+                      options replaced to <file>.migrated
   version             print the version to stdout
+  secrets set <id> <key>
+                      store a secret read from stdin in the secret store
+                      with the given id
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
-  --config <file>                configuration file to load
+  --config <file>                configuration file or URL to load, may be repeated
+  --config-cache-directory <directory>
+                                 directory to store the last good copy of
+                                 configuration URLs
   --config-directory <directory> directory containing additional *.conf files
+  --config-url-watch-interval <duration>
+                                 interval to poll configuration URLs for
+                                 changes, not polled if zero
   --debug                        turn on debug logging
   --input-filter <filter>        filter the inputs to enable, separator is :
   --input-list                   print available input plugins.
@@ -55,6 +68,9 @@ Examples:
   # run telegraf with all plugins defined in config file
   telegraf --config telegraf.conf
 
+  # run telegraf with a local and a remote config file, reloading on changes
+  telegraf --config telegraf.conf --config https://example.org/telegraf.conf --config-url-watch-interval 1m
+
   # run telegraf, enabling the cpu & memory input, and influxdb output plugins
   telegraf --config telegraf.conf --input-filter cpu:mem --output-filter influxdb
 
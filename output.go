@@ -1,5 +1,7 @@
 package telegraf
 
+import "fmt"
+
 type Output interface {
 	// Connect to the Output
 	Connect() error
@@ -26,3 +28,44 @@ type AggregatingOutput interface {
 	// Reset signals the the aggregator period is completed.
 	Reset()
 }
+
+// RouterOutput is an Output that forwards metrics to other outputs, identified
+// by their alias, instead of writing them.  The outputs of a router only
+// receive the metrics routed to them.
+type RouterOutput interface {
+	Output
+
+	// Routes returns the aliases of the outputs of each route by route name.
+	// It may be called before Init.
+	Routes() map[string][]string
+	// Route returns the names of the routes the metric is forwarded to.
+	Route(in Metric) []string
+}
+
+// ConcurrentOutput is an Output whose Write function may be called
+// concurrently, allowing several batches to be written at once when the
+// max_concurrent_writes option is set.
+type ConcurrentOutput interface {
+	Output
+
+	// SupportsConcurrentWrites is only used to mark the Output.
+	SupportsConcurrentWrites()
+}
+
+// PartialWriteError is returned by the Write function of an Output if some of
+// the metrics can never be written, for example because of a field type
+// conflict.  These metrics are not retried.
+type PartialWriteError struct {
+	// Err is the error writing the remaining metrics, which are retried.  If
+	// nil the remaining metrics were written.
+	Err error
+	// MetricsReject are the indexes of the metrics that can never be written.
+	MetricsReject []int
+}
+
+func (e *PartialWriteError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d metrics rejected: %v", len(e.MetricsReject), e.Err)
+	}
+	return fmt.Sprintf("%d metrics rejected", len(e.MetricsReject))
+}
@@ -0,0 +1,225 @@
+package derivative
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Suffix to append to the name of the derivative fields.
+  # suffix = "_rate"
+
+  ## Time unit of the rates, such as "1s" for per-second rates or "1m" for
+  ## per-minute rates.
+  # unit = "1s"
+
+  ## Field to derive by instead of the time of the metrics.  The derivative
+  ## fields are named "<field>_by_<variable>" and the suffix is not used.
+  # variable = ""
+
+  ## If true, a decrease of a field is handled as a counter reset and the
+  ## value after the reset is counted as the increase.  Disable to compute
+  ## the derivative of fields that can decrease, such as gauges.
+  # detect_resets = true
+
+  ## The last value of each series is used as the first value of the next
+  ## period, so a series updated once per period has a derivative.  This is
+  ## the number of periods without values the last value is kept.
+  # max_roll_over = 10
+`
+
+type Derivative struct {
+	Suffix       string            `toml:"suffix"`
+	Unit         internal.Duration `toml:"unit"`
+	Variable     string            `toml:"variable"`
+	DetectResets bool              `toml:"detect_resets"`
+	MaxRollOver  uint              `toml:"max_roll_over"`
+	Log          telegraf.Logger   `toml:"-"`
+
+	cache map[uint64]*aggregate
+}
+
+type aggregate struct {
+	name     string
+	tags     map[string]string
+	fields   map[string]*series
+	rollOver uint
+}
+
+// series tracks the values of a field from the first to the last sample of
+// the period.
+type series struct {
+	first    sample
+	last     sample
+	increase float64
+	samples  int
+}
+
+type sample struct {
+	value    float64
+	time     time.Time
+	variable float64
+}
+
+func NewDerivative() *Derivative {
+	return &Derivative{
+		Suffix:       "_rate",
+		Unit:         internal.Duration{Duration: time.Second},
+		DetectResets: true,
+		MaxRollOver:  10,
+		cache:        make(map[uint64]*aggregate),
+	}
+}
+
+func (*Derivative) SampleConfig() string {
+	return sampleConfig
+}
+
+func (*Derivative) Description() string {
+	return "Calculate the derivative of each field, such as the rate of counters."
+}
+
+func (d *Derivative) Init() error {
+	if d.Unit.Duration <= 0 {
+		d.Unit.Duration = time.Second
+	}
+	return nil
+}
+
+func (d *Derivative) Add(in telegraf.Metric) {
+	var variable float64
+	if d.Variable != "" {
+		v, ok := in.GetField(d.Variable)
+		if !ok {
+			return
+		}
+		if variable, ok = convert(v); !ok {
+			return
+		}
+	}
+
+	id := in.HashID()
+	a, ok := d.cache[id]
+	if !ok {
+		a = &aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]*series),
+		}
+		d.cache[id] = a
+	}
+	a.rollOver = 0
+
+	for _, field := range in.FieldList() {
+		if field.Key == d.Variable {
+			continue
+		}
+		value, ok := convert(field.Value)
+		if !ok {
+			continue
+		}
+
+		current := sample{value: value, time: in.Time(), variable: variable}
+		s, ok := a.fields[field.Key]
+		if !ok {
+			a.fields[field.Key] = &series{first: current, last: current, samples: 1}
+			continue
+		}
+
+		if current.time.Before(s.last.time) {
+			d.Log.Debugf("Ignoring out of order value of field %q of %q", field.Key, in.Name())
+			continue
+		}
+
+		if d.DetectResets && value < s.last.value {
+			// The counter was reset, it increased from zero to the value.
+			s.increase += value
+		} else {
+			s.increase += value - s.last.value
+		}
+		s.last = current
+		s.samples++
+	}
+}
+
+func (d *Derivative) Push(acc telegraf.Accumulator) {
+	for _, a := range d.cache {
+		fields := make(map[string]interface{})
+		for key, s := range a.fields {
+			if s.samples < 2 {
+				continue
+			}
+
+			var denominator float64
+			if d.Variable != "" {
+				denominator = s.last.variable - s.first.variable
+			} else {
+				denominator = float64(s.last.time.Sub(s.first.time)) / float64(d.Unit.Duration)
+			}
+			if denominator == 0 {
+				continue
+			}
+
+			fields[d.fieldName(key)] = s.increase / denominator
+		}
+
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+func (d *Derivative) fieldName(key string) string {
+	if d.Variable != "" {
+		return key + "_by_" + d.Variable
+	}
+	return key + d.Suffix
+}
+
+// Reset starts the next period from the last value of each series, so the
+// derivative can be computed if a series is updated once per period.
+func (d *Derivative) Reset() {
+	for id, a := range d.cache {
+		if a.rollOver > d.MaxRollOver {
+			delete(d.cache, id)
+			continue
+		}
+		a.rollOver++
+
+		for _, s := range a.fields {
+			s.first = s.last
+			s.increase = 0
+			s.samples = 1
+		}
+	}
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("derivative", func() telegraf.Aggregator {
+		return NewDerivative()
+	})
+}
@@ -0,0 +1,143 @@
+package derivative
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newDerivative() *Derivative {
+	d := NewDerivative()
+	d.Log = testutil.Logger{}
+	return d
+}
+
+func counter(fields map[string]interface{}, sec int64) telegraf.Metric {
+	return testutil.MustMetric("net",
+		map[string]string{"interface": "eth0"},
+		fields,
+		time.Unix(sec, 0))
+}
+
+func TestRate(t *testing.T) {
+	d := newDerivative()
+	require.NoError(t, d.Init())
+
+	d.Add(counter(map[string]interface{}{"bytes_recv": int64(100), "up": true}, 0))
+	d.Add(counter(map[string]interface{}{"bytes_recv": int64(300)}, 10))
+	d.Add(counter(map[string]interface{}{"bytes_recv": int64(600)}, 20))
+
+	acc := testutil.Accumulator{}
+	d.Push(&acc)
+
+	expected := []telegraf.Metric{
+		counter(map[string]interface{}{"bytes_recv_rate": 25.0}, 0),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestRateUnitAndSuffix(t *testing.T) {
+	d := newDerivative()
+	d.Suffix = "_per_minute"
+	d.Unit = internal.Duration{Duration: time.Minute}
+	require.NoError(t, d.Init())
+
+	d.Add(counter(map[string]interface{}{"packets": uint64(0)}, 0))
+	d.Add(counter(map[string]interface{}{"packets": uint64(30)}, 30))
+
+	acc := testutil.Accumulator{}
+	d.Push(&acc)
+	acc.AssertContainsFields(t, "net", map[string]interface{}{"packets_per_minute": 60.0})
+}
+
+func TestCounterReset(t *testing.T) {
+	tests := []struct {
+		name         string
+		detectResets bool
+		rate         float64
+	}{
+		{
+			name:         "detect resets",
+			detectResets: true,
+			rate:         3.0,
+		},
+		{
+			name:         "gauge",
+			detectResets: false,
+			rate:         -4.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDerivative()
+			d.DetectResets = tt.detectResets
+			require.NoError(t, d.Init())
+
+			d.Add(counter(map[string]interface{}{"value": 50.0}, 0))
+			d.Add(counter(map[string]interface{}{"value": 70.0}, 5))
+			// reset
+			d.Add(counter(map[string]interface{}{"value": 10.0}, 10))
+
+			acc := testutil.Accumulator{}
+			d.Push(&acc)
+			acc.AssertContainsFields(t, "net", map[string]interface{}{"value_rate": tt.rate})
+		})
+	}
+}
+
+func TestVariable(t *testing.T) {
+	d := newDerivative()
+	d.Variable = "requests"
+	require.NoError(t, d.Init())
+
+	d.Add(counter(map[string]interface{}{"errors": int64(1), "requests": int64(100)}, 0))
+	// metrics without the variable are ignored
+	d.Add(counter(map[string]interface{}{"errors": int64(1000)}, 5))
+	d.Add(counter(map[string]interface{}{"errors": int64(6), "requests": int64(200)}, 10))
+
+	acc := testutil.Accumulator{}
+	d.Push(&acc)
+
+	expected := []telegraf.Metric{
+		counter(map[string]interface{}{"errors_by_requests": 0.05}, 0),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestRollOver(t *testing.T) {
+	d := newDerivative()
+	d.MaxRollOver = 1
+	require.NoError(t, d.Init())
+
+	acc := testutil.Accumulator{}
+
+	// a single value per period
+	d.Add(counter(map[string]interface{}{"value": 0.0}, 0))
+	d.Push(&acc)
+	require.Empty(t, acc.Metrics)
+	d.Reset()
+
+	d.Add(counter(map[string]interface{}{"value": 10.0}, 10))
+	d.Push(&acc)
+	acc.AssertContainsFields(t, "net", map[string]interface{}{"value_rate": 1.0})
+	d.Reset()
+
+	// the series is kept for max_roll_over periods without values
+	d.Reset()
+	d.Add(counter(map[string]interface{}{"value": 30.0}, 20))
+	acc.ClearMetrics()
+	d.Push(&acc)
+	acc.AssertContainsFields(t, "net", map[string]interface{}{"value_rate": 2.0})
+	d.Reset()
+
+	d.Reset()
+	d.Reset()
+	d.Add(counter(map[string]interface{}{"value": 40.0}, 30))
+	acc.ClearMetrics()
+	d.Push(&acc)
+	require.Empty(t, acc.Metrics)
+}
@@ -0,0 +1,342 @@
+package quantile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/caio/go-tdigest"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+const (
+	algorithmTDigest = "t-digest"
+	algorithmExact   = "exact"
+
+	// sketchSuffix is the suffix of the fields containing a serialized
+	// t-digest.
+	sketchSuffix = "_tdigest"
+)
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Quantiles to output in the range [0,1]
+  # quantiles = [0.25, 0.5, 0.75]
+
+  ## Quantiles of specific fields, overriding quantiles.
+  # [aggregators.quantile.field_quantiles]
+  #   latency = [0.5, 0.95, 0.99]
+
+  ## Algorithm used to estimate the quantiles, either "t-digest" or "exact".
+  ## The exact algorithm keeps all values of the period in memory.
+  # algorithm = "t-digest"
+
+  ## Compression of the t-digest, a higher compression is more accurate but
+  ## uses more memory.
+  # compression = 100
+
+  ## If true, the t-digest of each field is added as a base64 encoded
+  ## "<field>_tdigest" field, so sketches from many hosts can be merged by
+  ## another quantile aggregator.
+  # sketch = false
+`
+
+type Quantile struct {
+	Quantiles      []float64            `toml:"quantiles"`
+	FieldQuantiles map[string][]float64 `toml:"field_quantiles"`
+	Algorithm      string               `toml:"algorithm"`
+	Compression    uint32               `toml:"compression"`
+	Sketch         bool                 `toml:"sketch"`
+	Log            telegraf.Logger      `toml:"-"`
+
+	cache map[uint64]aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]estimator
+}
+
+// estimator estimates the quantiles of the values of a field.
+type estimator interface {
+	Add(value float64)
+	Quantile(q float64) float64
+}
+
+type tdigestEstimator struct {
+	digest *tdigest.TDigest
+}
+
+func (e *tdigestEstimator) Add(value float64) {
+	// Add only fails for NaN and infinite values, skipped by convert.
+	e.digest.Add(value)
+}
+
+func (e *tdigestEstimator) Quantile(q float64) float64 {
+	return e.digest.Quantile(q)
+}
+
+// exactEstimator keeps all values and interpolates linearly between the
+// closest ranks.
+type exactEstimator struct {
+	values []float64
+	sorted bool
+}
+
+func (e *exactEstimator) Add(value float64) {
+	e.values = append(e.values, value)
+	e.sorted = false
+}
+
+func (e *exactEstimator) Quantile(q float64) float64 {
+	if !e.sorted {
+		sort.Float64s(e.values)
+		e.sorted = true
+	}
+
+	index := q * float64(len(e.values)-1)
+	lower := math.Floor(index)
+	upper := math.Ceil(index)
+	return e.values[int(lower)] + (index-lower)*(e.values[int(upper)]-e.values[int(lower)])
+}
+
+func NewQuantile() *Quantile {
+	return &Quantile{
+		Quantiles:   []float64{0.25, 0.5, 0.75},
+		Algorithm:   algorithmTDigest,
+		Compression: 100,
+		cache:       make(map[uint64]aggregate),
+	}
+}
+
+func (*Quantile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (*Quantile) Description() string {
+	return "Keep the aggregate quantiles of each metric passing through."
+}
+
+func (q *Quantile) Init() error {
+	switch q.Algorithm {
+	case algorithmTDigest:
+		if q.Compression == 0 {
+			return fmt.Errorf("compression must be positive")
+		}
+	case algorithmExact:
+		if q.Sketch {
+			return fmt.Errorf("sketch requires the %q algorithm", algorithmTDigest)
+		}
+	default:
+		return fmt.Errorf("unknown algorithm %q", q.Algorithm)
+	}
+
+	if err := checkQuantiles(q.Quantiles); err != nil {
+		return err
+	}
+	for _, quantiles := range q.FieldQuantiles {
+		if err := checkQuantiles(quantiles); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkQuantiles(quantiles []float64) error {
+	for _, q := range quantiles {
+		if q < 0 || q > 1 {
+			return fmt.Errorf("quantile %v out of range [0,1]", q)
+		}
+	}
+	return nil
+}
+
+func (q *Quantile) Add(in telegraf.Metric) {
+	id := in.HashID()
+	a, ok := q.cache[id]
+	if !ok {
+		a = aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]estimator),
+		}
+		q.cache[id] = a
+	}
+
+	sketched := make(map[string]bool)
+	for _, field := range in.FieldList() {
+		if sketch, ok := field.Value.(string); ok && strings.HasSuffix(field.Key, sketchSuffix) {
+			key := strings.TrimSuffix(field.Key, sketchSuffix)
+			q.merge(a, key, sketch)
+			sketched[key] = true
+		}
+	}
+
+	for _, field := range in.FieldList() {
+		// The quantiles sent along with a sketch are not values of the
+		// field.
+		if isQuantileOf(field.Key, sketched) {
+			continue
+		}
+
+		value, ok := convert(field.Value)
+		if !ok {
+			continue
+		}
+		e, err := q.estimator(a, field.Key)
+		if err != nil {
+			q.Log.Errorf("Creating estimator for field %q failed: %v", field.Key, err)
+			continue
+		}
+		e.Add(value)
+	}
+}
+
+// merge merges a serialized t-digest into the t-digest of the field.
+func (q *Quantile) merge(a aggregate, key string, sketch string) {
+	if q.Algorithm != algorithmTDigest {
+		return
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(sketch)
+	if err != nil {
+		q.Log.Errorf("Decoding t-digest of field %q failed: %v", key, err)
+		return
+	}
+	digest, err := tdigest.FromBytes(bytes.NewReader(buf))
+	if err != nil {
+		q.Log.Errorf("Decoding t-digest of field %q failed: %v", key, err)
+		return
+	}
+
+	e, err := q.estimator(a, key)
+	if err != nil {
+		q.Log.Errorf("Creating estimator for field %q failed: %v", key, err)
+		return
+	}
+	if err := e.(*tdigestEstimator).digest.Merge(digest); err != nil {
+		q.Log.Errorf("Merging t-digest of field %q failed: %v", key, err)
+	}
+}
+
+// estimator returns the estimator of the field, creating it if needed.
+func (q *Quantile) estimator(a aggregate, key string) (estimator, error) {
+	if e, ok := a.fields[key]; ok {
+		return e, nil
+	}
+
+	var e estimator
+	switch q.Algorithm {
+	case algorithmExact:
+		e = &exactEstimator{}
+	default:
+		digest, err := tdigest.New(tdigest.Compression(q.Compression))
+		if err != nil {
+			return nil, err
+		}
+		e = &tdigestEstimator{digest: digest}
+	}
+	a.fields[key] = e
+	return e, nil
+}
+
+func (q *Quantile) Push(acc telegraf.Accumulator) {
+	for _, a := range q.cache {
+		fields := make(map[string]interface{})
+		for key, e := range a.fields {
+			if d, ok := e.(*tdigestEstimator); ok && d.digest.Count() == 0 {
+				continue
+			}
+
+			for _, quantile := range q.quantiles(key) {
+				fields[key+"_"+suffix(quantile)] = e.Quantile(quantile)
+			}
+
+			if q.Sketch {
+				buf, err := e.(*tdigestEstimator).digest.AsBytes()
+				if err != nil {
+					q.Log.Errorf("Encoding t-digest of field %q failed: %v", key, err)
+					continue
+				}
+				fields[key+sketchSuffix] = base64.StdEncoding.EncodeToString(buf)
+			}
+		}
+
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+// quantiles returns the quantiles to output for the field.
+func (q *Quantile) quantiles(key string) []float64 {
+	if quantiles, ok := q.FieldQuantiles[key]; ok {
+		return quantiles
+	}
+	return q.Quantiles
+}
+
+// isQuantileOf returns true if the field is a quantile of one of the fields,
+// named "<field>_p<percentile>".
+func isQuantileOf(key string, fields map[string]bool) bool {
+	i := strings.LastIndex(key, "_p")
+	if i < 0 || i+2 == len(key) || !fields[key[:i]] {
+		return false
+	}
+	for _, c := range key[i+2:] {
+		if (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// suffix returns the field suffix of a quantile, the percentile prefixed with
+// "p", such as "p50" or "p99_9".
+func suffix(quantile float64) string {
+	percentile := math.Round(quantile*1e6) / 1e4
+	return "p" + strings.Replace(strconv.FormatFloat(percentile, 'f', -1, 64), ".", "_", 1)
+}
+
+func (q *Quantile) Reset() {
+	q.cache = make(map[uint64]aggregate)
+}
+
+func convert(in interface{}) (float64, bool) {
+	var value float64
+	switch v := in.(type) {
+	case float64:
+		value = v
+	case int64:
+		value = float64(v)
+	case uint64:
+		value = float64(v)
+	default:
+		return 0, false
+	}
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
+
+func init() {
+	aggregators.Add("quantile", func() telegraf.Aggregator {
+		return NewQuantile()
+	})
+}
@@ -0,0 +1,169 @@
+package quantile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func addValues(q *Quantile, field string, values ...interface{}) {
+	for _, v := range values {
+		q.Add(testutil.MustMetric("m1",
+			map[string]string{"foo": "bar"},
+			map[string]interface{}{field: v},
+			time.Unix(0, 0)))
+	}
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin func(q *Quantile)
+		err    string
+	}{
+		{
+			name:   "unknown algorithm",
+			plugin: func(q *Quantile) { q.Algorithm = "median" },
+			err:    `unknown algorithm "median"`,
+		},
+		{
+			name:   "quantile out of range",
+			plugin: func(q *Quantile) { q.Quantiles = []float64{0.5, 1.5} },
+			err:    "quantile 1.5 out of range [0,1]",
+		},
+		{
+			name: "field quantile out of range",
+			plugin: func(q *Quantile) {
+				q.FieldQuantiles = map[string][]float64{"a": {-0.1}}
+			},
+			err: "quantile -0.1 out of range [0,1]",
+		},
+		{
+			name: "sketch with exact algorithm",
+			plugin: func(q *Quantile) {
+				q.Algorithm = algorithmExact
+				q.Sketch = true
+			},
+			err: `sketch requires the "t-digest" algorithm`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuantile()
+			tt.plugin(q)
+			err := q.Init()
+			require.Error(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestExact(t *testing.T) {
+	q := NewQuantile()
+	q.Algorithm = algorithmExact
+	q.Quantiles = []float64{0, 0.25, 0.5, 0.75, 1}
+	q.Log = testutil.Logger{}
+	require.NoError(t, q.Init())
+
+	addValues(q, "a", int64(4), 1.0, uint64(3), int64(2), "skipped", true)
+
+	acc := testutil.Accumulator{}
+	q.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("m1",
+			map[string]string{"foo": "bar"},
+			map[string]interface{}{
+				"a_p0":   1.0,
+				"a_p25":  1.75,
+				"a_p50":  2.5,
+				"a_p75":  3.25,
+				"a_p100": 4.0,
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestTDigest(t *testing.T) {
+	q := NewQuantile()
+	q.Quantiles = []float64{0.5, 0.999}
+	q.FieldQuantiles = map[string][]float64{"b": {0.9}}
+	q.Log = testutil.Logger{}
+	require.NoError(t, q.Init())
+
+	for i := 0; i <= 1000; i++ {
+		addValues(q, "a", float64(i))
+		addValues(q, "b", int64(i))
+	}
+
+	acc := testutil.Accumulator{}
+	q.Push(&acc)
+	require.Len(t, acc.Metrics, 1)
+
+	fields := acc.Metrics[0].Fields
+	require.Len(t, fields, 3)
+	require.InDelta(t, 500.0, fields["a_p50"], 10)
+	require.InDelta(t, 999.0, fields["a_p99_9"], 10)
+	require.InDelta(t, 900.0, fields["b_p90"], 10)
+
+	q.Reset()
+	acc.ClearMetrics()
+	q.Push(&acc)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestSketchMerge(t *testing.T) {
+	hosts := []*Quantile{NewQuantile(), NewQuantile()}
+	for i, q := range hosts {
+		q.Sketch = true
+		q.Log = testutil.Logger{}
+		require.NoError(t, q.Init())
+		for v := 0; v < 50; v++ {
+			addValues(q, "a", float64(i*50+v))
+		}
+	}
+
+	merged := NewQuantile()
+	merged.Quantiles = []float64{0.5}
+	merged.Log = testutil.Logger{}
+	require.NoError(t, merged.Init())
+
+	for _, q := range hosts {
+		acc := testutil.Accumulator{}
+		q.Push(&acc)
+		require.Len(t, acc.Metrics, 1)
+		require.Contains(t, acc.Metrics[0].Fields, "a_tdigest")
+
+		for _, m := range acc.GetTelegrafMetrics() {
+			merged.Add(m)
+		}
+	}
+
+	acc := testutil.Accumulator{}
+	merged.Push(&acc)
+	require.Len(t, acc.Metrics, 1)
+	require.Len(t, acc.Metrics[0].Fields, 1)
+	require.InDelta(t, 49.5, acc.Metrics[0].Fields["a_p50"], 1)
+}
+
+func TestSuffix(t *testing.T) {
+	require.Equal(t, "p0", suffix(0))
+	require.Equal(t, "p50", suffix(0.5))
+	require.Equal(t, "p99_9", suffix(0.999))
+	require.Equal(t, "p100", suffix(1))
+}
+
+func TestIsQuantileOf(t *testing.T) {
+	fields := map[string]bool{"a": true, "b_p": true}
+	require.True(t, isQuantileOf("a_p50", fields))
+	require.True(t, isQuantileOf("a_p99_9", fields))
+	require.True(t, isQuantileOf("b_p_p50", fields))
+	require.False(t, isQuantileOf("a", fields))
+	require.False(t, isQuantileOf("a_p", fields))
+	require.False(t, isQuantileOf("a_peak", fields))
+	require.False(t, isQuantileOf("c_p50", fields))
+}
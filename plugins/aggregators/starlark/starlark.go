@@ -0,0 +1,111 @@
+package starlark
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	common "github.com/influxdata/telegraf/plugins/common/starlark"
+)
+
+const (
+	description  = "Aggregate metrics using a Starlark script"
+	sampleConfig = `
+  ## The Starlark source can be set as a string in this configuration file, or
+  ## by referencing a file containing the script.  Only one source or script
+  ## should be set at once.
+  ##
+  ## Source of the Starlark script.
+  source = '''
+def add(metric):
+  state["last"] = metric
+
+def push():
+  return state.get("last")
+
+def reset():
+  state.clear()
+'''
+
+  ## File containing a Starlark script.
+  # script = "/usr/local/bin/myscript.star"
+`
+)
+
+type Starlark struct {
+	common.Common
+}
+
+func (s *Starlark) Description() string {
+	return description
+}
+
+func (s *Starlark) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Starlark) Init() error {
+	if err := s.Common.Init(); err != nil {
+		return err
+	}
+
+	for _, fn := range []struct {
+		name   string
+		params int
+	}{
+		{"add", 1},
+		{"push", 0},
+		{"reset", 0},
+	} {
+		if err := s.AddFunction(fn.name, fn.params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Starlark) Add(metric telegraf.Metric) {
+	// The aggregator does not own the metric, the script gets a copy that it
+	// can keep until push.
+	_, err := s.Call("add", common.NewMetric(metric.Copy()))
+	if err != nil {
+		s.Log.Errorf("Error calling add: %v", err)
+	}
+}
+
+func (s *Starlark) Push(acc telegraf.Accumulator) {
+	rv, err := s.Call("push")
+	if err != nil {
+		s.Log.Errorf("Error calling push: %v", err)
+		return
+	}
+
+	metrics, err := common.Metrics(rv)
+	if err != nil {
+		s.Log.Errorf("Error in push: %v", err)
+		return
+	}
+
+	// Always use nanosecond precision to avoid rounding metrics that were
+	// produced at a precision higher than the agent default.
+	acc.SetPrecision(time.Nanosecond)
+
+	// The metrics may be kept by the script after push, so each metric
+	// passed on is a copy.
+	for _, m := range metrics {
+		acc.AddMetric(m.Unwrap().Copy())
+	}
+}
+
+func (s *Starlark) Reset() {
+	_, err := s.Call("reset")
+	if err != nil {
+		s.Log.Errorf("Error calling reset: %v", err)
+	}
+}
+
+func init() {
+	aggregators.Add("starlark", func() telegraf.Aggregator {
+		return &Starlark{}
+	})
+}
@@ -0,0 +1,134 @@
+package starlark
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const minMax = `
+def add(metric):
+  for k, v in metric.fields.items():
+    key = metric.name + "." + k
+    if key not in state:
+      state[key] = {"name": metric.name, "field": k, "min": v, "max": v}
+    else:
+      agg = state[key]
+      agg["min"] = min(agg["min"], v)
+      agg["max"] = max(agg["max"], v)
+
+def push():
+  metrics = []
+  for agg in state.values():
+    m = Metric(agg["name"])
+    m.fields[agg["field"] + "_min"] = agg["min"]
+    m.fields[agg["field"] + "_max"] = agg["max"]
+    m.time = 0
+    metrics.append(m)
+  return metrics
+
+def reset():
+  state.clear()
+`
+
+func newStarlarkFromSource(source string) *Starlark {
+	s := &Starlark{}
+	s.Source = source
+	s.Log = testutil.Logger{}
+	return s
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{
+			name:   "push missing",
+			source: "def add(metric):\n  pass\ndef reset():\n  pass",
+		},
+		{
+			name:   "add with wrong arguments",
+			source: "def add():\n  pass\ndef push():\n  pass\ndef reset():\n  pass",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, newStarlarkFromSource(tt.source).Init())
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	plugin := newStarlarkFromSource(minMax)
+	require.NoError(t, plugin.Init())
+
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage": 42.0},
+		time.Unix(0, 0)))
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage": 10.0},
+		time.Unix(1, 0)))
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage": 50.0},
+		time.Unix(2, 0)))
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"usage_min": 10.0,
+				"usage_max": 50.0,
+			},
+			time.Unix(0, 0)),
+	}, acc.GetTelegrafMetrics())
+
+	plugin.Reset()
+	acc.ClearMetrics()
+	plugin.Push(&acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestAddDoesNotModifyMetric(t *testing.T) {
+	plugin := newStarlarkFromSource(`
+def add(metric):
+  metric.name = "modified"
+  state["last"] = metric
+
+def push():
+  return state.get("last")
+
+def reset():
+  state.clear()
+`)
+	require.NoError(t, plugin.Init())
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0))
+	plugin.Add(m)
+	require.Equal(t, "cpu", m.Name())
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	plugin.Push(&acc)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		testutil.MustMetric("modified",
+			map[string]string{},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0)),
+		testutil.MustMetric("modified",
+			map[string]string{},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0)),
+	}, acc.GetTelegrafMetrics())
+}
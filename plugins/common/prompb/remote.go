@@ -0,0 +1,53 @@
+// Package prompb contains the messages of the Prometheus remote write
+// protocol, compatible on the wire with the remote.proto and types.proto
+// definitions of Prometheus.
+//
+// The remote write body is a WriteRequest encoded as protocol buffer and
+// compressed with the snappy block format.
+package prompb
+
+import (
+	"github.com/gogo/protobuf/proto"
+)
+
+// WriteRequest is the body of a remote write request.
+type WriteRequest struct {
+	Timeseries []*TimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3" json:"timeseries,omitempty"`
+}
+
+func (m *WriteRequest) Reset()         { *m = WriteRequest{} }
+func (m *WriteRequest) String() string { return proto.CompactTextString(m) }
+func (*WriteRequest) ProtoMessage()    {}
+
+// TimeSeries is a series identified by its labels, the metric name being the
+// "__name__" label, and its samples in time order.
+type TimeSeries struct {
+	Labels  []*Label  `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty"`
+	Samples []*Sample `protobuf:"bytes,2,rep,name=samples,proto3" json:"samples,omitempty"`
+}
+
+func (m *TimeSeries) Reset()         { *m = TimeSeries{} }
+func (m *TimeSeries) String() string { return proto.CompactTextString(m) }
+func (*TimeSeries) ProtoMessage()    {}
+
+type Label struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *Label) Reset()         { *m = Label{} }
+func (m *Label) String() string { return proto.CompactTextString(m) }
+func (*Label) ProtoMessage()    {}
+
+// Sample is a value at a timestamp in milliseconds since the epoch.
+type Sample struct {
+	Value     float64 `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp int64   `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (m *Sample) Reset()         { *m = Sample{} }
+func (m *Sample) String() string { return proto.CompactTextString(m) }
+func (*Sample) ProtoMessage()    {}
+
+// MetricNameLabel is the name of the label containing the metric name.
+const MetricNameLabel = "__name__"
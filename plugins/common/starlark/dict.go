@@ -0,0 +1,240 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// dict contains the operations shared by TagDict and FieldDict, used to
+// implement their dict methods.
+type dict interface {
+	starlark.HasSetKey
+
+	keys() []string
+	lookup(key string) (starlark.Value, bool)
+	remove(key string)
+	checkMutable() error
+}
+
+var dictMethods = map[string]func(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error){
+	"clear":  dictClear,
+	"get":    dictGet,
+	"items":  dictItems,
+	"keys":   dictKeys,
+	"pop":    dictPop,
+	"update": dictUpdate,
+	"values": dictValues,
+}
+
+func dictAttrNames() []string {
+	names := make([]string, 0, len(dictMethods))
+	for name := range dictMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func dictAttr(d dict, name string) (starlark.Value, error) {
+	method, ok := dictMethods[name]
+	if !ok {
+		// Returning nil, nil indicates "no such field or method"
+		return nil, nil
+	}
+
+	builtin := func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		return method(d, b, args, kwargs)
+	}
+	return starlark.NewBuiltin(name, builtin).BindReceiver(d), nil
+}
+
+func dictString(d dict) string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, key := range d.keys() {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		value, _ := d.lookup(key)
+		sb.WriteString(starlark.String(key).String())
+		sb.WriteString(": ")
+		sb.WriteString(value.String())
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func dictItemsOf(d dict) []starlark.Tuple {
+	keys := d.keys()
+	items := make([]starlark.Tuple, 0, len(keys))
+	for _, key := range keys {
+		value, _ := d.lookup(key)
+		items = append(items, starlark.Tuple{starlark.String(key), value})
+	}
+	return items
+}
+
+func dictGetKey(d dict, k starlark.Value) (starlark.Value, bool, error) {
+	key, ok := k.(starlark.String)
+	if !ok {
+		return nil, false, errors.New("key must be of type 'str'")
+	}
+	v, found := d.lookup(key.GoString())
+	return v, found, nil
+}
+
+func dictClear(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	if err := d.checkMutable(); err != nil {
+		return nil, err
+	}
+	for _, key := range d.keys() {
+		d.remove(key)
+	}
+	return starlark.None, nil
+}
+
+func dictGet(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value = nil, starlark.None
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	v, found, err := dictGetKey(d, key)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return dflt, nil
+	}
+	return v, nil
+}
+
+func dictItems(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := dictItemsOf(d)
+	list := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+	return starlark.NewList(list), nil
+}
+
+func dictKeys(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	keys := d.keys()
+	list := make([]starlark.Value, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, starlark.String(key))
+	}
+	return starlark.NewList(list), nil
+}
+
+func dictValues(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	keys := d.keys()
+	list := make([]starlark.Value, 0, len(keys))
+	for _, key := range keys {
+		value, _ := d.lookup(key)
+		list = append(list, value)
+	}
+	return starlark.NewList(list), nil
+}
+
+func dictPop(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	if err := d.checkMutable(); err != nil {
+		return nil, err
+	}
+	v, found, err := dictGetKey(d, key)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if dflt != nil {
+			return dflt, nil
+		}
+		return nil, fmt.Errorf("%s: missing key %s", b.Name(), key)
+	}
+	d.remove(string(key.(starlark.String)))
+	return v, nil
+}
+
+func dictUpdate(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("%s: got %d arguments, want at most 1", b.Name(), len(args))
+	}
+	if err := d.checkMutable(); err != nil {
+		return nil, err
+	}
+
+	if len(args) == 1 {
+		switch updates := args[0].(type) {
+		case starlark.IterableMapping:
+			for _, item := range updates.Items() {
+				if err := d.SetKey(item[0], item[1]); err != nil {
+					return nil, err
+				}
+			}
+		case starlark.Iterable:
+			iter := updates.Iterate()
+			defer iter.Done()
+			var pair starlark.Value
+			for i := 0; iter.Next(&pair); i++ {
+				items, ok := pair.(starlark.Indexable)
+				if !ok || items.Len() != 2 {
+					return nil, fmt.Errorf("%s: element #%d is not a pair", b.Name(), i)
+				}
+				if err := d.SetKey(items.Index(0), items.Index(1)); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s: got %s, want iterable", b.Name(), args[0].Type())
+		}
+	}
+
+	for _, kwarg := range kwargs {
+		if err := d.SetKey(kwarg[0], kwarg[1]); err != nil {
+			return nil, err
+		}
+	}
+	return starlark.None, nil
+}
+
+// keyIterator iterates over a snapshot of the keys of a dict, the done
+// function is called when the iteration ends.
+type keyIterator struct {
+	keys []string
+	done func()
+}
+
+func (it *keyIterator) Next(p *starlark.Value) bool {
+	if len(it.keys) == 0 {
+		return false
+	}
+	*p = starlark.String(it.keys[0])
+	it.keys = it.keys[1:]
+	return true
+}
+
+func (it *keyIterator) Done() {
+	if it.done != nil {
+		it.done()
+		it.done = nil
+	}
+}
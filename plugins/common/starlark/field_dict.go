@@ -0,0 +1,162 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+
+	"go.starlark.net/starlark"
+)
+
+// FieldDict is a dict-like view of the fields of a Metric.  Keys are strings,
+// values are int, float, str or bool.
+type FieldDict struct {
+	metric *Metric
+}
+
+func (d *FieldDict) String() string {
+	return dictString(d)
+}
+
+func (d *FieldDict) Type() string {
+	return "Fields"
+}
+
+func (d *FieldDict) Freeze() {
+	d.metric.Freeze()
+}
+
+func (d *FieldDict) Truth() starlark.Bool {
+	return len(d.metric.metric.FieldList()) != 0
+}
+
+func (d *FieldDict) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+// AttrNames implements starlark.HasAttrs.
+func (d *FieldDict) AttrNames() []string {
+	return dictAttrNames()
+}
+
+// Attr implements starlark.HasAttrs.
+func (d *FieldDict) Attr(name string) (starlark.Value, error) {
+	return dictAttr(d, name)
+}
+
+// Get implements starlark.Mapping.
+func (d *FieldDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	return dictGetKey(d, key)
+}
+
+// SetKey implements starlark.HasSetKey.
+func (d *FieldDict) SetKey(k, v starlark.Value) error {
+	if err := d.checkMutable(); err != nil {
+		return err
+	}
+
+	key, ok := k.(starlark.String)
+	if !ok {
+		return errors.New("field key must be of type 'str'")
+	}
+	value, err := fieldValue(v)
+	if err != nil {
+		return err
+	}
+
+	d.metric.metric.AddField(key.GoString(), value)
+	return nil
+}
+
+// Items implements starlark.IterableMapping.
+func (d *FieldDict) Items() []starlark.Tuple {
+	return dictItemsOf(d)
+}
+
+// Iterate implements starlark.Iterable.
+func (d *FieldDict) Iterate() starlark.Iterator {
+	d.metric.fieldIterCount++
+	return &keyIterator{
+		keys: d.keys(),
+		done: func() { d.metric.fieldIterCount-- },
+	}
+}
+
+// Len implements starlark.Sequence.
+func (d *FieldDict) Len() int {
+	return len(d.metric.metric.FieldList())
+}
+
+func (d *FieldDict) keys() []string {
+	fields := d.metric.metric.FieldList()
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		keys = append(keys, field.Key)
+	}
+	return keys
+}
+
+func (d *FieldDict) lookup(key string) (starlark.Value, bool) {
+	v, ok := d.metric.metric.GetField(key)
+	if !ok {
+		return nil, false
+	}
+	sv, err := starlarkValue(v)
+	if err != nil {
+		return nil, false
+	}
+	return sv, true
+}
+
+func (d *FieldDict) remove(key string) {
+	d.metric.metric.RemoveField(key)
+}
+
+func (d *FieldDict) checkMutable() error {
+	if d.metric.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	if d.metric.fieldIterCount > 0 {
+		return errors.New("cannot modify fields during iteration")
+	}
+	return nil
+}
+
+// starlarkValue converts a field value to a Starlark value.
+func starlarkValue(v interface{}) (starlark.Value, error) {
+	switch v := v.(type) {
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case uint64:
+		return starlark.MakeUint64(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	case string:
+		return starlark.String(v), nil
+	case bool:
+		return starlark.Bool(v), nil
+	default:
+		return nil, fmt.Errorf("invalid field type %T", v)
+	}
+}
+
+// fieldValue converts a Starlark value to a field value.
+func fieldValue(v starlark.Value) (interface{}, error) {
+	switch v := v.(type) {
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		if u, ok := v.Uint64(); ok {
+			return u, nil
+		}
+		return nil, errors.New("field value out of range")
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.String:
+		return v.GoString(), nil
+	case starlark.Bool:
+		return bool(v), nil
+	default:
+		return nil, fmt.Errorf("field value must be of type 'int', 'float', 'str' or 'bool', not '%s'", v.Type())
+	}
+}
@@ -0,0 +1,183 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"go.starlark.net/starlark"
+)
+
+// Metric is the Starlark representation of a telegraf.Metric.  Its name,
+// tags, fields and time can be read and written.
+type Metric struct {
+	metric telegraf.Metric
+	frozen bool
+
+	// The number of active iterations over the tags or fields, during which
+	// the metric cannot be modified.
+	tagIterCount   int
+	fieldIterCount int
+}
+
+// NewMetric wraps the metric.
+func NewMetric(m telegraf.Metric) *Metric {
+	return &Metric{metric: m}
+}
+
+// Unwrap returns the wrapped metric.
+func (m *Metric) Unwrap() telegraf.Metric {
+	return m.metric
+}
+
+// String returns the metric in line protocol like form.
+func (m *Metric) String() string {
+	var sb strings.Builder
+	sb.WriteString("Metric(")
+	sb.WriteString(fmt.Sprintf("%q", m.metric.Name()))
+	sb.WriteString(", tags=")
+	sb.WriteString(m.tags().String())
+	sb.WriteString(", fields=")
+	sb.WriteString(m.fields().String())
+	sb.WriteString(", time=")
+	sb.WriteString(fmt.Sprintf("%d", m.metric.Time().UnixNano()))
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// Type implements starlark.Value.
+func (m *Metric) Type() string {
+	return "Metric"
+}
+
+// Freeze implements starlark.Value.
+func (m *Metric) Freeze() {
+	m.frozen = true
+}
+
+// Truth implements starlark.Value.
+func (m *Metric) Truth() starlark.Bool {
+	return true
+}
+
+// Hash implements starlark.Value.
+func (m *Metric) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+// AttrNames implements starlark.HasAttrs.
+func (m *Metric) AttrNames() []string {
+	return []string{"name", "tags", "fields", "time"}
+}
+
+// Attr implements starlark.HasAttrs.
+func (m *Metric) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "name":
+		return starlark.String(m.metric.Name()), nil
+	case "tags":
+		return m.tags(), nil
+	case "fields":
+		return m.fields(), nil
+	case "time":
+		return starlark.MakeInt64(m.metric.Time().UnixNano()), nil
+	default:
+		// Returning nil, nil indicates "no such field or method"
+		return nil, nil
+	}
+}
+
+// SetField implements starlark.HasSetField.
+func (m *Metric) SetField(name string, value starlark.Value) error {
+	if m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+
+	switch name {
+	case "name":
+		return m.setName(value)
+	case "time":
+		return m.setTime(value)
+	case "tags":
+		return m.setTags(value)
+	case "fields":
+		return m.setFields(value)
+	default:
+		return starlark.NoSuchAttrError(
+			fmt.Sprintf("cannot assign to field '%s'", name))
+	}
+}
+
+func (m *Metric) tags() *TagDict {
+	return &TagDict{metric: m}
+}
+
+func (m *Metric) fields() *FieldDict {
+	return &FieldDict{metric: m}
+}
+
+func (m *Metric) setName(value starlark.Value) error {
+	str, ok := value.(starlark.String)
+	if !ok {
+		return errors.New("type error: name must be a str")
+	}
+	m.metric.SetName(str.GoString())
+	return nil
+}
+
+func (m *Metric) setTime(value starlark.Value) error {
+	i, ok := value.(starlark.Int)
+	if !ok {
+		return errors.New("type error: time must be an int")
+	}
+	ns, ok := i.Int64()
+	if !ok {
+		return errors.New("type error: time out of range")
+	}
+	m.metric.SetTime(time.Unix(0, ns))
+	return nil
+}
+
+func (m *Metric) setTags(value starlark.Value) error {
+	items, ok := value.(starlark.IterableMapping)
+	if !ok {
+		return errors.New("type error: tags must be a dict")
+	}
+
+	tags := m.tags()
+	if err := tags.checkMutable(); err != nil {
+		return err
+	}
+	for _, key := range tags.keys() {
+		tags.remove(key)
+	}
+	for _, item := range items.Items() {
+		if err := tags.SetKey(item[0], item[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Metric) setFields(value starlark.Value) error {
+	items, ok := value.(starlark.IterableMapping)
+	if !ok {
+		return errors.New("type error: fields must be a dict")
+	}
+
+	fields := m.fields()
+	if err := fields.checkMutable(); err != nil {
+		return err
+	}
+	for _, key := range fields.keys() {
+		fields.remove(key)
+	}
+	for _, item := range items.Items() {
+		if err := fields.SetKey(item[0], item[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
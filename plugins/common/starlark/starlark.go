@@ -0,0 +1,171 @@
+// Package starlark contains the Starlark bindings shared by the starlark
+// processor and aggregator.
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+)
+
+func init() {
+	resolve.AllowFloat = true
+	resolve.AllowLambda = true
+	resolve.AllowNestedDef = true
+	resolve.AllowRecursion = true
+	resolve.AllowSet = true
+}
+
+// Common contains the options and the loaded program shared by the starlark
+// plugins.
+type Common struct {
+	Source string `toml:"source"`
+	Script string `toml:"script"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	thread    *starlark.Thread
+	globals   starlark.StringDict
+	functions map[string]*starlark.Function
+}
+
+// Init loads the program from the source or script option.  The program can
+// use the predeclared Metric and deepcopy functions, and the state dict which
+// keeps its contents between calls.
+func (s *Common) Init() error {
+	if s.Source == "" && s.Script == "" {
+		return errors.New("one of source or script must be set")
+	}
+	if s.Source != "" && s.Script != "" {
+		return errors.New("source and script cannot both be set")
+	}
+
+	filename := "<source>"
+	var src interface{} = s.Source
+	if s.Script != "" {
+		content, err := ioutil.ReadFile(s.Script)
+		if err != nil {
+			return fmt.Errorf("could not read script: %v", err)
+		}
+		filename = s.Script
+		src = content
+	}
+
+	s.thread = &starlark.Thread{
+		Name: "telegraf",
+		Print: func(_ *starlark.Thread, msg string) {
+			s.Log.Debug(msg)
+		},
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, errors.New("load is not supported")
+		},
+	}
+
+	predeclared := starlark.StringDict{
+		"Metric":   starlark.NewBuiltin("Metric", newMetric),
+		"deepcopy": starlark.NewBuiltin("deepcopy", deepcopy),
+		"state":    starlark.NewDict(0),
+	}
+
+	globals, err := starlark.ExecFile(s.thread, filename, src, predeclared)
+	if err != nil {
+		return formatError(err)
+	}
+	s.globals = globals
+	s.functions = make(map[string]*starlark.Function)
+	return nil
+}
+
+// AddFunction checks that the program defines the function name with the
+// given number of parameters, so that it can be called by Call.
+func (s *Common) AddFunction(name string, params int) error {
+	value, ok := s.globals[name]
+	if !ok {
+		return fmt.Errorf("%s is not defined", name)
+	}
+	fn, ok := value.(*starlark.Function)
+	if !ok {
+		return fmt.Errorf("%s is not a function", name)
+	}
+	if fn.NumParams() != params {
+		return fmt.Errorf("%s function must take %d parameter(s)", name, params)
+	}
+	s.functions[name] = fn
+	return nil
+}
+
+// Call calls a function added with AddFunction.
+func (s *Common) Call(name string, args ...starlark.Value) (starlark.Value, error) {
+	fn, ok := s.functions[name]
+	if !ok {
+		return nil, fmt.Errorf("function %s not added", name)
+	}
+
+	value, err := starlark.Call(s.thread, fn, starlark.Tuple(args), nil)
+	if err != nil {
+		return nil, formatError(err)
+	}
+	return value, nil
+}
+
+// Metrics returns the metrics of the value returned by a function, which
+// must be None, a Metric or a list of Metrics.
+func Metrics(value starlark.Value) ([]*Metric, error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *Metric:
+		return []*Metric{v}, nil
+	case *starlark.List:
+		metrics := make([]*Metric, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			m, ok := v.Index(i).(*Metric)
+			if !ok {
+				return nil, fmt.Errorf("list item %d is of type %s, not Metric", i, v.Index(i).Type())
+			}
+			metrics = append(metrics, m)
+		}
+		return metrics, nil
+	default:
+		return nil, fmt.Errorf("invalid type returned: %s", value.Type())
+	}
+}
+
+// formatError adds the Starlark backtrace to evaluation errors.
+func formatError(err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return errors.New(evalErr.Backtrace())
+	}
+	return err
+}
+
+// newMetric implements Metric(name), which returns a new metric with the
+// current time.
+func newMetric(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name starlark.String
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &name); err != nil {
+		return nil, err
+	}
+
+	m, err := metric.New(name.GoString(), nil, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return NewMetric(m), nil
+}
+
+// deepcopy implements deepcopy(metric), which returns an independent copy
+// of the metric.
+func deepcopy(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var m *Metric
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &m); err != nil {
+		return nil, err
+	}
+	return NewMetric(m.metric.Copy()), nil
+}
@@ -0,0 +1,116 @@
+package starlark
+
+import (
+	"errors"
+
+	"go.starlark.net/starlark"
+)
+
+// TagDict is a dict-like view of the tags of a Metric.  Keys and values are
+// strings.
+type TagDict struct {
+	metric *Metric
+}
+
+func (d *TagDict) String() string {
+	return dictString(d)
+}
+
+func (d *TagDict) Type() string {
+	return "Tags"
+}
+
+func (d *TagDict) Freeze() {
+	d.metric.Freeze()
+}
+
+func (d *TagDict) Truth() starlark.Bool {
+	return len(d.metric.metric.TagList()) != 0
+}
+
+func (d *TagDict) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+// AttrNames implements starlark.HasAttrs.
+func (d *TagDict) AttrNames() []string {
+	return dictAttrNames()
+}
+
+// Attr implements starlark.HasAttrs.
+func (d *TagDict) Attr(name string) (starlark.Value, error) {
+	return dictAttr(d, name)
+}
+
+// Get implements starlark.Mapping.
+func (d *TagDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	return dictGetKey(d, key)
+}
+
+// SetKey implements starlark.HasSetKey.
+func (d *TagDict) SetKey(k, v starlark.Value) error {
+	if err := d.checkMutable(); err != nil {
+		return err
+	}
+
+	key, ok := k.(starlark.String)
+	if !ok {
+		return errors.New("tag key must be of type 'str'")
+	}
+	value, ok := v.(starlark.String)
+	if !ok {
+		return errors.New("tag value must be of type 'str'")
+	}
+
+	d.metric.metric.AddTag(key.GoString(), value.GoString())
+	return nil
+}
+
+// Items implements starlark.IterableMapping.
+func (d *TagDict) Items() []starlark.Tuple {
+	return dictItemsOf(d)
+}
+
+// Iterate implements starlark.Iterable.
+func (d *TagDict) Iterate() starlark.Iterator {
+	d.metric.tagIterCount++
+	return &keyIterator{
+		keys: d.keys(),
+		done: func() { d.metric.tagIterCount-- },
+	}
+}
+
+// Len implements starlark.Sequence.
+func (d *TagDict) Len() int {
+	return len(d.metric.metric.TagList())
+}
+
+func (d *TagDict) keys() []string {
+	tags := d.metric.metric.TagList()
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, tag.Key)
+	}
+	return keys
+}
+
+func (d *TagDict) lookup(key string) (starlark.Value, bool) {
+	if v, ok := d.metric.metric.GetTag(key); ok {
+		return starlark.String(v), true
+	}
+	return nil, false
+}
+
+func (d *TagDict) remove(key string) {
+	d.metric.metric.RemoveTag(key)
+}
+
+func (d *TagDict) checkMutable() error {
+	if d.metric.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	if d.metric.tagIterCount > 0 {
+		return errors.New("cannot modify tags during iteration")
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package execd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon
+  command = ["telegraf-smartctl", "-d", "/dev/sda"]
+
+  ## Define how the process is signaled on each collection interval.
+  ## Valid values are:
+  ##   "none"    : Do not signal anything.
+  ##              The process must output metrics by itself.
+  ##   "STDIN"   : Send a newline on STDIN.
+  ##   "SIGHUP"  : Send a HUP signal. Not available on Windows.
+  ##   "SIGUSR1" : Send a USR1 signal. Not available on Windows.
+  ##   "SIGUSR2" : Send a USR2 signal. Not available on Windows.
+  signal = "none"
+
+  ## Delay before the process is restarted after an unexpected termination
+  restart_delay = "10s"
+
+  ## Data format to consume.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command      []string          `toml:"command"`
+	Signal       string            `toml:"signal"`
+	RestartDelay internal.Duration `toml:"restart_delay"`
+	Log          telegraf.Logger   `toml:"-"`
+
+	process *process.Process
+	acc     telegraf.Accumulator
+	parser  parsers.Parser
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running input plugin"
+}
+
+func (e *Execd) SetParser(parser parsers.Parser) {
+	e.parser = parser
+}
+
+func (e *Execd) Init() error {
+	switch e.Signal {
+	case "", "none", "STDIN":
+	default:
+		if _, err := signalFor(e.Signal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Execd) Start(acc telegraf.Accumulator) error {
+	e.acc = acc
+
+	var err error
+	e.process, err = process.New(e.Command)
+	if err != nil {
+		return fmt.Errorf("error creating new process: %v", err)
+	}
+	e.process.Log = e.Log
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.ReadStdoutFn = e.cmdReadOut
+	e.process.ReadStderrFn = e.cmdReadErr
+
+	if err = e.process.Start(); err != nil {
+		// if there was only one argument, and it contained spaces, warn the user
+		// that they may have configured it wrong.
+		if len(e.Command) == 1 && strings.ContainsAny(e.Command[0], " \t") {
+			e.Log.Warn("The inputs.execd Command contained spaces but no arguments. " +
+				"This setting expects the program and arguments as an array of strings, " +
+				"not as a space-delimited string. See the plugin readme for an example.")
+		}
+		return fmt.Errorf("failed to start process %s: %v", e.Command, err)
+	}
+
+	return nil
+}
+
+func (e *Execd) Stop() {
+	if e.process != nil {
+		e.process.Stop()
+	}
+}
+
+func (e *Execd) Gather(acc telegraf.Accumulator) error {
+	if e.process == nil {
+		return nil
+	}
+
+	switch e.Signal {
+	case "", "none":
+		return nil
+	case "STDIN":
+		if _, err := e.process.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("error writing to stdin: %v", err)
+		}
+		return nil
+	default:
+		sig, err := signalFor(e.Signal)
+		if err != nil {
+			return err
+		}
+		if err := e.process.Signal(sig); err != nil {
+			return fmt.Errorf("error signaling process: %v", err)
+		}
+		return nil
+	}
+}
+
+func (e *Execd) cmdReadOut(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		metrics, err := e.parser.Parse(scanner.Bytes())
+		if err != nil {
+			e.acc.AddError(fmt.Errorf("parse error: %v", err))
+		}
+
+		for _, metric := range metrics {
+			e.acc.AddMetric(metric)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.acc.AddError(fmt.Errorf("error reading stdout: %v", err))
+	}
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.acc.AddError(fmt.Errorf("error reading stderr: %v", err))
+	}
+}
+
+func init() {
+	inputs.Add("execd", func() telegraf.Input {
+		return &Execd{
+			Signal:       "none",
+			RestartDelay: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,22 @@
+// +build !windows
+
+package execd
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func signalFor(name string) (os.Signal, error) {
+	switch name {
+	case "SIGHUP":
+		return syscall.SIGHUP, nil
+	case "SIGUSR1":
+		return syscall.SIGUSR1, nil
+	case "SIGUSR2":
+		return syscall.SIGUSR2, nil
+	default:
+		return nil, fmt.Errorf("unsupported signal %q", name)
+	}
+}
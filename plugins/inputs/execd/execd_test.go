@@ -0,0 +1,70 @@
+// +build !windows
+
+package execd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecd_SignalStdin(t *testing.T) {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	e := &Execd{
+		Command: []string{"sh", "-c",
+			`while read line; do echo "counter value=1i"; done`},
+		Signal:       "STDIN",
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	e.SetParser(parser)
+	require.NoError(t, e.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+	defer e.Stop()
+
+	require.NoError(t, e.Gather(acc))
+	acc.Wait(1)
+	require.NoError(t, e.Gather(acc))
+	acc.Wait(2)
+
+	m := acc.GetTelegrafMetrics()[0]
+	require.Equal(t, "counter", m.Name())
+	require.Equal(t, map[string]interface{}{"value": int64(1)}, m.Fields())
+}
+
+func TestExecd_NoSignal(t *testing.T) {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	e := &Execd{
+		Command:      []string{"sh", "-c", `echo "cpu usage=42"; exec cat`},
+		Signal:       "none",
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	e.SetParser(parser)
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+	acc.Wait(1)
+	e.Stop()
+
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{},
+			map[string]interface{}{"usage": 42.0}, time.Unix(0, 0)),
+	}, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestExecd_InvalidSignal(t *testing.T) {
+	e := &Execd{Command: []string{"true"}, Signal: "SIGFOO"}
+	require.Error(t, e.Init())
+}
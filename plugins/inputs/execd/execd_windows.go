@@ -0,0 +1,12 @@
+// +build windows
+
+package execd
+
+import (
+	"fmt"
+	"os"
+)
+
+func signalFor(name string) (os.Signal, error) {
+	return nil, fmt.Errorf("unsupported signal %q: only \"none\" and \"STDIN\" are supported on Windows", name)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	parser "github.com/influxdata/telegraf/plugins/parsers/prometheus"
 )
 
 const acceptHeader = `application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3,*/*;q=0.1`
@@ -309,12 +310,11 @@ func (p *Prometheus) gatherURL(u URLAndAddress, acc telegraf.Accumulator) error
 		return fmt.Errorf("error reading body: %s", err)
 	}
 
-	if p.MetricVersion == 2 {
-		metrics, err = ParseV2(body, resp.Header)
-	} else {
-		metrics, err = Parse(body, resp.Header)
+	metricParser := parser.Parser{
+		MetricVersion: p.MetricVersion,
+		Header:        resp.Header,
 	}
-
+	metrics, err = metricParser.Parse(body)
 	if err != nil {
 		return fmt.Errorf("error reading metrics for %s: %s",
 			u.URL, err)
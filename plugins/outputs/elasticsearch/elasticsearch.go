@@ -242,6 +242,9 @@ func (a *Elasticsearch) Connect() error {
 	return nil
 }
 
+// SupportsConcurrentWrites marks the output as supporting concurrent writes.
+func (a *Elasticsearch) SupportsConcurrentWrites() {}
+
 func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 	if len(metrics) == 0 {
 		return nil
@@ -0,0 +1,134 @@
+package execd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon
+  command = ["my-telegraf-output", "--some-flag", "value"]
+
+  ## Delay before the process is restarted after an unexpected termination
+  restart_delay = "10s"
+
+  ## Data format to export.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+// Execd defines the execd output plugin.
+type Execd struct {
+	Command      []string          `toml:"command"`
+	RestartDelay internal.Duration `toml:"restart_delay"`
+	Log          telegraf.Logger   `toml:"-"`
+
+	process    *process.Process
+	serializer serializers.Serializer
+}
+
+// SampleConfig returns a sample configuration.
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description describes the plugin.
+func (e *Execd) Description() string {
+	return "Run executable as long-running output plugin"
+}
+
+// SetSerializer sets the serializer for the output.
+func (e *Execd) SetSerializer(s serializers.Serializer) {
+	e.serializer = s
+}
+
+// Connect starts the process.
+func (e *Execd) Connect() error {
+	var err error
+	e.process, err = process.New(e.Command)
+	if err != nil {
+		return fmt.Errorf("error creating process %s: %v", e.Command, err)
+	}
+	e.process.Log = e.Log
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.ReadStdoutFn = e.cmdReadOut
+	e.process.ReadStderrFn = e.cmdReadErr
+
+	if err = e.process.Start(); err != nil {
+		// if there was only one argument, and it contained spaces, warn the user
+		// that they may have configured it wrong.
+		if len(e.Command) == 1 && strings.ContainsAny(e.Command[0], " \t") {
+			e.Log.Warn("The outputs.execd Command contained spaces but no arguments. " +
+				"This setting expects the program and arguments as an array of strings, " +
+				"not as a space-delimited string. See the plugin readme for an example.")
+		}
+		return fmt.Errorf("failed to start process %s: %v", e.Command, err)
+	}
+
+	return nil
+}
+
+// Close stops the process.
+func (e *Execd) Close() error {
+	if e.process != nil {
+		e.process.Stop()
+	}
+	return nil
+}
+
+// Write writes the serialized metrics to stdin of the process.
+func (e *Execd) Write(metrics []telegraf.Metric) error {
+	b, err := e.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return fmt.Errorf("error serializing metrics: %v", err)
+	}
+	if len(b) == 0 {
+		return nil
+	}
+
+	if _, err = e.process.Write(b); err != nil {
+		return fmt.Errorf("error writing metrics: %v", err)
+	}
+	return nil
+}
+
+func (e *Execd) cmdReadOut(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		e.Log.Info(scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %v", err)
+	}
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stderr: %v", err)
+	}
+}
+
+func init() {
+	outputs.Add("execd", func() telegraf.Output {
+		return &Execd{
+			RestartDelay: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
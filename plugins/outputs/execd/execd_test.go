@@ -0,0 +1,56 @@
+// +build !windows
+
+package execd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecd_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-execd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.txt")
+
+	e := &Execd{
+		Command:      []string{"sh", "-c", "cat > " + path},
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	e.SetSerializer(influx.NewSerializer())
+
+	require.NoError(t, e.Connect())
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42.0}, time.Unix(0, 0)),
+		testutil.MustMetric("mem", map[string]string{"host": "a"},
+			map[string]interface{}{"free": int64(1)}, time.Unix(1, 0)),
+	}
+	require.NoError(t, e.Write(metrics))
+
+	// Close waits for the process to exit, after which all writes are on disk.
+	require.NoError(t, e.Close())
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t,
+		"cpu,host=a usage=42 0\nmem,host=a free=1i 1000000000\n", string(b))
+}
+
+func TestExecd_NoCommand(t *testing.T) {
+	e := &Execd{Log: testutil.Logger{}}
+	e.SetSerializer(influx.NewSerializer())
+	require.Error(t, e.Connect())
+}
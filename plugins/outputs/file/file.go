@@ -1,6 +1,7 @@
 package file
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -20,9 +21,12 @@ type File struct {
 	UseBatchFormat      bool              `toml:"use_batch_format"`
 	Log                 telegraf.Logger   `toml:"-"`
 
-	writer     io.Writer
+	writers    []io.Writer
 	closers    []io.Closer
 	serializer serializers.Serializer
+
+	// stdoutHeader is true once a header was written to stdout.
+	stdoutHeader bool
 }
 
 var sampleConfig = `
@@ -78,7 +82,7 @@ func (f *File) Connect() error {
 			f.closers = append(f.closers, of)
 		}
 	}
-	f.writer = io.MultiWriter(writers...)
+	f.writers = writers
 	return nil
 }
 
@@ -105,23 +109,25 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 	var writeErr error = nil
 
 	if f.UseBatchFormat {
+		header := f.header(metrics)
 		octets, err := f.serializer.SerializeBatch(metrics)
 		if err != nil {
 			f.Log.Errorf("Could not serialize metric: %v", err)
 		}
 
-		_, err = f.writer.Write(octets)
+		err = f.write(header, octets)
 		if err != nil {
 			f.Log.Errorf("Error writing to file: %v", err)
 		}
 	} else {
 		for _, metric := range metrics {
+			header := f.header([]telegraf.Metric{metric})
 			b, err := f.serializer.Serialize(metric)
 			if err != nil {
 				f.Log.Debugf("Could not serialize metric: %v", err)
 			}
 
-			_, err = f.writer.Write(b)
+			err = f.write(header, b)
 			if err != nil {
 				writeErr = fmt.Errorf("E! [outputs.file] failed to write message: %v", err)
 			}
@@ -131,6 +137,62 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 	return writeErr
 }
 
+// header returns the header of a file starting with the metrics, if the
+// data format has one.
+func (f *File) header(metrics []telegraf.Metric) []byte {
+	hs, ok := f.serializer.(serializers.HeaderSerializer)
+	if !ok {
+		return nil
+	}
+
+	header, err := hs.SerializeHeader(metrics)
+	if err != nil {
+		f.Log.Errorf("Could not serialize header: %v", err)
+	}
+	return header
+}
+
+// write writes the octets to the files, with the header at the start of
+// each file, including the new files created by rotation, unless the octets
+// start with it.
+func (f *File) write(header []byte, octets []byte) error {
+	if len(octets) == 0 {
+		return nil
+	}
+
+	for _, w := range f.writers {
+		b := octets
+		withHeader := len(header) > 0 && f.atStart(w) && !bytes.HasPrefix(octets, header)
+		if withHeader {
+			b = make([]byte, 0, len(header)+len(octets))
+			b = append(b, header...)
+			b = append(b, octets...)
+		}
+
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if len(header) > 0 && w == os.Stdout {
+			f.stdoutHeader = true
+		}
+	}
+	return nil
+}
+
+// atStart returns true if the current file of the writer is empty, stdout is
+// at its start until a header was written.
+func (f *File) atStart(w io.Writer) bool {
+	if w == os.Stdout {
+		return !f.stdoutHeader
+	}
+
+	if file, ok := w.(interface{ Stat() (os.FileInfo, error) }); ok {
+		info, err := file.Stat()
+		return err == nil && info.Size() == 0
+	}
+	return false
+}
+
 func init() {
 	outputs.Add("file", func() telegraf.Output {
 		return &File{}
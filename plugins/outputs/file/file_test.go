@@ -5,10 +5,13 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
@@ -180,6 +183,105 @@ func TestFileStdout(t *testing.T) {
 	assert.Equal(t, expNewFile, out)
 }
 
+func TestFileCSVHeader(t *testing.T) {
+	fh1 := createFile()
+	defer os.Remove(fh1.Name())
+	fh2 := tmpFile()
+	defer os.Remove(fh2)
+
+	s, err := serializers.NewCSVSerializer(&serializers.Config{CSVHeader: true})
+	assert.NoError(t, err)
+	f := File{
+		Files:      []string{fh1.Name(), fh2},
+		serializer: s,
+	}
+
+	err = f.Connect()
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		err = f.Write(testutil.MockMetrics())
+		assert.NoError(t, err)
+	}
+
+	row := "1257894000,test1,value1,value,1\n"
+	validateFile(fh1.Name(), "cpu,cpu=cpu0 value=100 1455312810012459582\n"+row+row, t)
+	validateFile(fh2, "timestamp,measurement,tag1,field,value\n"+row+row, t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
+func TestFileCSVHeaderNewMeasurement(t *testing.T) {
+	fh := tmpFile()
+	defer os.Remove(fh)
+
+	s, err := serializers.NewCSVSerializer(&serializers.Config{CSVHeader: true})
+	assert.NoError(t, err)
+	f := File{
+		Files:      []string{fh},
+		serializer: s,
+	}
+
+	err = f.Connect()
+	assert.NoError(t, err)
+
+	err = f.Write(testutil.MockMetrics())
+	assert.NoError(t, err)
+	err = f.Write([]telegraf.Metric{testutil.MustMetric(
+		"test2",
+		map[string]string{"tag2": "value2"},
+		map[string]interface{}{"value": 2},
+		time.Unix(1257894000, 0),
+	)})
+	assert.NoError(t, err)
+
+	validateFile(fh, "timestamp,measurement,tag1,field,value\n"+
+		"1257894000,test1,value1,value,1\n"+
+		"timestamp,measurement,tag2,field,value\n"+
+		"1257894000,test2,value2,value,2\n", t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
+func TestFileCSVHeaderRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	header := "timestamp,measurement,tag1,field,value\n"
+	row := "1257894000,test1,value1,value,1\n"
+
+	s, err := serializers.NewCSVSerializer(&serializers.Config{CSVHeader: true})
+	assert.NoError(t, err)
+	fname := filepath.Join(dir, "metrics.csv")
+	f := File{
+		Files: []string{fname},
+		// Rotate after the second write.
+		RotationMaxSize:     internal.Size{Size: int64(len(header) + len(row) + 1)},
+		RotationMaxArchives: -1,
+		serializer:          s,
+	}
+
+	err = f.Connect()
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = f.Write(testutil.MockMetrics())
+		assert.NoError(t, err)
+	}
+
+	archives, err := filepath.Glob(filepath.Join(dir, "metrics.*-*.csv"))
+	assert.NoError(t, err)
+	assert.Len(t, archives, 1)
+	validateFile(archives[0], header+row+row, t)
+	validateFile(fname, header+row, t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
 func createFile() *os.File {
 	f, err := ioutil.TempFile("", "")
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -88,6 +89,10 @@ type HTTP struct {
 
 	client     *http.Client
 	serializer serializers.Serializer
+
+	// Serializers are not required to be thread-safe, serializerMu guards
+	// the serializer when batches are written concurrently.
+	serializerMu sync.Mutex
 }
 
 func (h *HTTP) SetSerializer(serializer serializers.Serializer) {
@@ -158,8 +163,13 @@ func (h *HTTP) SampleConfig() string {
 	return sampleConfig
 }
 
+// SupportsConcurrentWrites marks the output as supporting concurrent writes.
+func (h *HTTP) SupportsConcurrentWrites() {}
+
 func (h *HTTP) Write(metrics []telegraf.Metric) error {
+	h.serializerMu.Lock()
 	reqBody, err := h.serializer.SerializeBatch(metrics)
+	h.serializerMu.Unlock()
 	if err != nil {
 		return err
 	}
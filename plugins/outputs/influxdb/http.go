@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"regexp"
 	"strings"
 	"time"
 
@@ -30,6 +31,11 @@ const (
 )
 
 var (
+	// fieldTypeConflict matches the error of a point with a field of a
+	// different type than the existing field.
+	fieldTypeConflict = regexp.MustCompile(
+		`field type conflict: input field "(.*?)" on measurement "(.*?)" is type (\w+)`)
+
 	// Escape an identifier in InfluxQL.
 	escapeIdentifier = strings.NewReplacer(
 		"\n", `\n`,
@@ -245,7 +251,9 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 			return err
 		}
 	} else {
-		for _, metric := range metrics {
+		// Indexes of the metrics of each batch in metrics.
+		indexes := make(map[string][]int)
+		for i, metric := range metrics {
 			db, ok := metric.GetTag(c.config.DatabaseTag)
 			if !ok {
 				db = c.config.Database
@@ -263,8 +271,10 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 			}
 
 			batches[db] = append(batches[db], metric)
+			indexes[db] = append(indexes[db], i)
 		}
 
+		var rejected []int
 		for db, batch := range batches {
 			if !c.config.SkipDatabaseCreation && !c.createdDatabases[db] {
 				err := c.CreateDatabase(ctx, db)
@@ -275,10 +285,23 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 			}
 
 			err := c.writeBatch(ctx, db, batch)
+			if perr, ok := err.(*telegraf.PartialWriteError); ok {
+				for _, i := range perr.MetricsReject {
+					rejected = append(rejected, indexes[db][i])
+				}
+				continue
+			}
 			if err != nil {
+				if len(rejected) > 0 {
+					return &telegraf.PartialWriteError{Err: err, MetricsReject: rejected}
+				}
 				return err
 			}
 		}
+
+		if len(rejected) > 0 {
+			return &telegraf.PartialWriteError{MetricsReject: rejected}
+		}
 	}
 	return nil
 }
@@ -347,10 +370,14 @@ func (c *httpClient) writeBatch(ctx context.Context, db string, metrics []telegr
 
 	// Other partial write errors, such as "field type conflict", are not
 	// correctable at this point and so the point is dropped instead of
-	// retrying.
+	// retrying.  The points with a field type conflict are reported as
+	// rejected.
 	if strings.Contains(desc, errStringPartialWrite) {
 		c.log.Errorf("When writing to [%s]: received error %v; discarding points",
 			c.URL(), desc)
+		if rejected := c.fieldTypeConflicts(desc, metrics); len(rejected) > 0 {
+			return &telegraf.PartialWriteError{MetricsReject: rejected}
+		}
 		return nil
 	}
 
@@ -369,6 +396,49 @@ func (c *httpClient) writeBatch(ctx context.Context, db string, metrics []telegr
 	}
 }
 
+// fieldTypeConflicts returns the indexes of the metrics with the field type
+// conflict of the error description.
+func (c *httpClient) fieldTypeConflicts(desc string, metrics []telegraf.Metric) []int {
+	match := fieldTypeConflict.FindStringSubmatch(desc)
+	if match == nil {
+		return nil
+	}
+	field, measurement, fieldType := match[1], match[2], match[3]
+
+	var rejected []int
+	for i, metric := range metrics {
+		if metric.Name() != measurement {
+			continue
+		}
+		value, ok := metric.GetField(field)
+		if ok && c.fieldType(value) == fieldType {
+			rejected = append(rejected, i)
+		}
+	}
+	return rejected
+}
+
+// fieldType returns the InfluxDB type of a field value.
+func (c *httpClient) fieldType(value interface{}) string {
+	switch value.(type) {
+	case float64:
+		return "float"
+	case int64:
+		return "integer"
+	case uint64:
+		if c.config.InfluxUintSupport {
+			return "unsigned"
+		}
+		return "integer"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		return ""
+	}
+}
+
 func (c *httpClient) makeQueryRequest(query string) (*http.Request, error) {
 	queryURL, err := makeQueryURL(c.config.URL)
 	if err != nil {
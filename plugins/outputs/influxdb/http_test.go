@@ -397,6 +397,24 @@ func TestHTTP_Write(t *testing.T) {
 				require.Contains(t, str, "partial write")
 			},
 		},
+		{
+			name: "field type conflicts are rejected",
+			config: influxdb.HTTPConfig{
+				URL:      u,
+				Database: "telegraf",
+				Log:      testutil.Logger{},
+			},
+			queryHandlerFunc: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "partial write: field type conflict: input field \"value\" on measurement \"cpu\" is type float, already exists as type integer dropped=1"}`))
+			},
+			errFunc: func(t *testing.T, err error) {
+				expected := &telegraf.PartialWriteError{
+					MetricsReject: []int{0},
+				}
+				require.Equal(t, expected, err)
+			},
+		},
 		{
 			name: "parse errors are logged no error",
 			config: influxdb.HTTPConfig{
@@ -209,6 +209,9 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 		if err == nil {
 			return nil
 		}
+		if perr, ok := err.(*telegraf.PartialWriteError); ok && perr.Err == nil {
+			return err
+		}
 
 		switch apiError := err.(type) {
 		case *DatabaseNotFoundError:
@@ -14,6 +14,7 @@ import (
 	"net/url"
 	"path"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -54,7 +55,9 @@ type HTTPConfig struct {
 	ContentEncoding  string
 	TLSConfig        *tls.Config
 
-	Serializer *influx.Serializer
+	// NewSerializer returns the serializer for each request, as serializers
+	// are not thread-safe.
+	NewSerializer func() *influx.Serializer
 }
 
 type httpClient struct {
@@ -66,10 +69,12 @@ type httpClient struct {
 	BucketTag        string
 	ExcludeBucketTag bool
 
-	client     *http.Client
-	serializer *influx.Serializer
-	url        *url.URL
-	retryTime  time.Time
+	client        *http.Client
+	newSerializer func() *influx.Serializer
+	url           *url.URL
+
+	mu        sync.Mutex
+	retryTime time.Time
 }
 
 func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
@@ -101,9 +106,9 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		proxy = http.ProxyFromEnvironment
 	}
 
-	serializer := config.Serializer
-	if serializer == nil {
-		serializer = influx.NewSerializer()
+	newSerializer := config.NewSerializer
+	if newSerializer == nil {
+		newSerializer = influx.NewSerializer
 	}
 
 	var transport *http.Transport
@@ -128,7 +133,7 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 	}
 
 	client := &httpClient{
-		serializer: serializer,
+		newSerializer: newSerializer,
 		client: &http.Client{
 			Timeout:   timeout,
 			Transport: transport,
@@ -168,7 +173,7 @@ func (g genericRespError) Error() string {
 }
 
 func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error {
-	if c.retryTime.After(time.Now()) {
+	if c.getRetryTime().After(time.Now()) {
 		return errors.New("Retry time has not elapsed")
 	}
 
@@ -258,7 +263,7 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 		if retry > defaultMaxWait {
 			retry = defaultMaxWait
 		}
-		c.retryTime = time.Now().Add(time.Duration(retry) * time.Second)
+		c.setRetryTime(time.Now().Add(time.Duration(retry) * time.Second))
 		return fmt.Errorf("waiting %ds for server before sending metric again", retry)
 	case http.StatusServiceUnavailable:
 		retryAfter := resp.Header.Get("Retry-After")
@@ -269,7 +274,7 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 		if retry > defaultMaxWait {
 			retry = defaultMaxWait
 		}
-		c.retryTime = time.Now().Add(time.Duration(retry) * time.Second)
+		c.setRetryTime(time.Now().Add(time.Duration(retry) * time.Second))
 		return fmt.Errorf("waiting %ds for server before sending metric again", retry)
 	}
 
@@ -307,7 +312,7 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 // requestBodyReader warp io.Reader from influx.NewReader to io.ReadCloser, which is usefully to fast close the write
 // side of the connection in case of error
 func (c *httpClient) requestBodyReader(metrics []telegraf.Metric) (io.ReadCloser, error) {
-	reader := influx.NewReader(metrics, c.serializer)
+	reader := influx.NewReader(metrics, c.newSerializer())
 
 	if c.ContentEncoding == "gzip" {
 		rc, err := internal.CompressWithGzip(reader)
@@ -321,6 +326,18 @@ func (c *httpClient) requestBodyReader(metrics []telegraf.Metric) (io.ReadCloser
 	return ioutil.NopCloser(reader), nil
 }
 
+func (c *httpClient) getRetryTime() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.retryTime
+}
+
+func (c *httpClient) setRetryTime(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryTime = t
+}
+
 func (c *httpClient) addHeaders(req *http.Request) {
 	for header, value := range c.Headers {
 		req.Header.Set(header, value)
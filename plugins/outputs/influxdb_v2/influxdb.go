@@ -151,6 +151,9 @@ func (i *InfluxDB) SampleConfig() string {
 	return sampleConfig
 }
 
+// SupportsConcurrentWrites marks the output as supporting concurrent writes.
+func (i *InfluxDB) SupportsConcurrentWrites() {}
+
 // Write sends metrics to one of the configured servers, logging each
 // unsuccessful. If all servers fail, return an error.
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
@@ -190,7 +193,7 @@ func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.U
 		UserAgent:        i.UserAgent,
 		ContentEncoding:  i.ContentEncoding,
 		TLSConfig:        tlsConfig,
-		Serializer:       i.newSerializer(),
+		NewSerializer:    i.newSerializer,
 	}
 
 	c, err := NewHTTPClient(config)
@@ -0,0 +1,288 @@
+package router
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	// DefaultRoute is the name of the route of the metrics not matched by
+	// any other route.
+	DefaultRoute = "default"
+
+	matchFirst = "first"
+	matchAll   = "all"
+)
+
+var sampleConfig = `
+  ## Metrics are forwarded to the outputs with the given aliases, these
+  ## outputs only receive the metrics routed to them.
+
+  ## Outputs receiving the metrics not matched by any route.  If empty these
+  ## metrics are dropped.
+  # default = ["influxdb_default"]
+
+  ## Forward metrics to the first matching route only ("first"), or to all
+  ## matching routes ("all").
+  # match = "first"
+
+  ## Routes are evaluated in order.  A route matches a metric if all of its
+  ## conditions match, a route without conditions matches all metrics.
+  [[outputs.router.route]]
+    ## Name of the route, used in the internal statistics.  Defaults to the
+    ## position of the route starting from 1.
+    name = "errors"
+    ## Aliases of the outputs receiving the metrics of the route.
+    outputs = ["influxdb_errors"]
+
+    ## Measurement names to match, or not to match, supports globs.
+    # namepass = ["http_*"]
+    # namedrop = ["http_internal"]
+
+    ## Field keys of which at least one must be present, supports globs.
+    # fieldpass = ["error*"]
+
+    ## Tags of which at least one must match, or none may match.  Tag values
+    ## support globs.
+    # [outputs.router.route.tagpass]
+    #   env = ["prod*"]
+    # [outputs.router.route.tagdrop]
+    #   region = ["test"]
+
+    ## Expression the metric must satisfy, using the syntax of metricpass.
+    # metricpass = 'fields.status_code >= 500'
+`
+
+// Route forwards the metrics matching its conditions to a set of outputs.
+type Route struct {
+	Name       string              `toml:"name"`
+	Outputs    []string            `toml:"outputs"`
+	NamePass   []string            `toml:"namepass"`
+	NameDrop   []string            `toml:"namedrop"`
+	FieldPass  []string            `toml:"fieldpass"`
+	TagPass    map[string][]string `toml:"tagpass"`
+	TagDrop    map[string][]string `toml:"tagdrop"`
+	MetricPass string              `toml:"metricpass"`
+
+	nameFilter filter.Filter
+	fieldPass  filter.Filter
+	tagPass    map[string]filter.Filter
+	tagDrop    map[string]filter.Filter
+	metricPass *filter.Expression
+
+	// metricPassFailed is set once an error evaluating metricpass is logged.
+	metricPassFailed int32
+}
+
+type Router struct {
+	Rules   []*Route `toml:"route"`
+	Default []string `toml:"default"`
+	Match   string   `toml:"match"`
+
+	Log telegraf.Logger `toml:"-"`
+}
+
+func (r *Router) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Router) Description() string {
+	return "Forward metrics to other outputs based on their name, tags and fields"
+}
+
+func (r *Router) Init() error {
+	switch r.Match {
+	case "":
+		r.Match = matchFirst
+	case matchFirst, matchAll:
+	default:
+		return fmt.Errorf("invalid match %q", r.Match)
+	}
+
+	names := map[string]bool{DefaultRoute: true}
+	for i, route := range r.Rules {
+		name := routeName(i, route)
+		if names[name] {
+			return fmt.Errorf("route %q: duplicate route name", name)
+		}
+		names[name] = true
+
+		if len(route.Outputs) == 0 {
+			return fmt.Errorf("route %q: no outputs", name)
+		}
+		if err := route.compile(); err != nil {
+			return fmt.Errorf("route %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Router) Connect() error {
+	return nil
+}
+
+func (r *Router) Close() error {
+	return nil
+}
+
+// Write is never called with any metrics, they are forwarded to the outputs
+// of the routes instead.
+func (r *Router) Write(metrics []telegraf.Metric) error {
+	return nil
+}
+
+func (r *Router) Routes() map[string][]string {
+	routes := make(map[string][]string, len(r.Rules)+1)
+	for i, route := range r.Rules {
+		routes[routeName(i, route)] = route.Outputs
+	}
+	if len(r.Default) > 0 {
+		routes[DefaultRoute] = r.Default
+	}
+	return routes
+}
+
+func (r *Router) Route(in telegraf.Metric) []string {
+	var names []string
+	for i, route := range r.Rules {
+		ok, err := route.match(in)
+		if err != nil {
+			r.metricPassError(routeName(i, route), route, err)
+		}
+		if !ok {
+			continue
+		}
+		names = append(names, routeName(i, route))
+		if r.Match == matchFirst {
+			break
+		}
+	}
+
+	if len(names) == 0 && len(r.Default) > 0 {
+		names = append(names, DefaultRoute)
+	}
+	return names
+}
+
+// metricPassError logs an error evaluating the metricpass of a route.  Such
+// errors usually repeat for every metric, so only the first one of each route
+// is logged as an error and the others at debug level.
+func (r *Router) metricPassError(name string, route *Route, err error) {
+	if atomic.CompareAndSwapInt32(&route.metricPassFailed, 0, 1) {
+		r.Log.Errorf("Route %q: error evaluating metricpass %q: %v "+
+			"(further errors are logged at debug level)", name, route.MetricPass, err)
+		return
+	}
+	r.Log.Debugf("Route %q: error evaluating metricpass %q: %v", name, route.MetricPass, err)
+}
+
+func routeName(i int, route *Route) string {
+	if route.Name != "" {
+		return route.Name
+	}
+	return strconv.Itoa(i + 1)
+}
+
+func (r *Route) compile() error {
+	var err error
+	if len(r.NamePass) > 0 || len(r.NameDrop) > 0 {
+		r.nameFilter, err = filter.NewIncludeExcludeFilter(r.NamePass, r.NameDrop)
+		if err != nil {
+			return fmt.Errorf("invalid name filter: %v", err)
+		}
+	}
+
+	r.fieldPass, err = filter.Compile(r.FieldPass)
+	if err != nil {
+		return fmt.Errorf("invalid fieldpass: %v", err)
+	}
+
+	r.tagPass, err = compileTagFilters(r.TagPass)
+	if err != nil {
+		return fmt.Errorf("invalid tagpass: %v", err)
+	}
+	r.tagDrop, err = compileTagFilters(r.TagDrop)
+	if err != nil {
+		return fmt.Errorf("invalid tagdrop: %v", err)
+	}
+
+	if r.MetricPass != "" {
+		r.metricPass, err = filter.CompileExpression(r.MetricPass)
+		if err != nil {
+			return fmt.Errorf("invalid metricpass: %v", err)
+		}
+	}
+	return nil
+}
+
+func compileTagFilters(tags map[string][]string) (map[string]filter.Filter, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	filters := make(map[string]filter.Filter, len(tags))
+	for key, values := range tags {
+		f, err := filter.Compile(values)
+		if err != nil {
+			return nil, err
+		}
+		filters[key] = f
+	}
+	return filters, nil
+}
+
+// match returns true if the metric matches the conditions of the route.  An
+// error is returned if metricpass fails to evaluate, the metric does not match
+// then.
+func (r *Route) match(in telegraf.Metric) (bool, error) {
+	if r.nameFilter != nil && !r.nameFilter.Match(in.Name()) {
+		return false, nil
+	}
+
+	if r.fieldPass != nil && !r.hasField(in) {
+		return false, nil
+	}
+
+	if r.tagPass != nil && !matchTags(r.tagPass, in) {
+		return false, nil
+	}
+	if r.tagDrop != nil && matchTags(r.tagDrop, in) {
+		return false, nil
+	}
+
+	if r.metricPass != nil {
+		return r.metricPass.Eval(in)
+	}
+	return true, nil
+}
+
+func (r *Route) hasField(in telegraf.Metric) bool {
+	for _, field := range in.FieldList() {
+		if r.fieldPass.Match(field.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTags returns true if any of the tags of the metric matches its
+// filter.
+func matchTags(filters map[string]filter.Filter, in telegraf.Metric) bool {
+	for _, tag := range in.TagList() {
+		if f, ok := filters[tag.Key]; ok && f != nil && f.Match(tag.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	outputs.Add("router", func() telegraf.Output {
+		return &Router{}
+	})
+}
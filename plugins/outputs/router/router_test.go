@@ -0,0 +1,197 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(name string, tags map[string]string, fields map[string]interface{}) telegraf.Metric {
+	return testutil.MustMetric(name, tags, fields, time.Unix(0, 0))
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name   string
+		router *Router
+	}{
+		{
+			name:   "invalid match",
+			router: &Router{Match: "any"},
+		},
+		{
+			name: "no outputs",
+			router: &Router{Rules: []*Route{
+				{Name: "a"},
+			}},
+		},
+		{
+			name: "duplicate name",
+			router: &Router{Rules: []*Route{
+				{Name: "a", Outputs: []string{"x"}},
+				{Name: "a", Outputs: []string{"y"}},
+			}},
+		},
+		{
+			name: "default name",
+			router: &Router{Rules: []*Route{
+				{Name: DefaultRoute, Outputs: []string{"x"}},
+			}},
+		},
+		{
+			name: "invalid metricpass",
+			router: &Router{Rules: []*Route{
+				{Outputs: []string{"x"}, MetricPass: "tags.env =="},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.router.Init())
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	r := &Router{
+		Rules: []*Route{
+			{Name: "errors", Outputs: []string{"a", "b"}},
+			{Outputs: []string{"c"}},
+		},
+		Default: []string{"d"},
+	}
+	require.Equal(t, map[string][]string{
+		"errors":     {"a", "b"},
+		"2":          {"c"},
+		DefaultRoute: {"d"},
+	}, r.Routes())
+}
+
+func TestRoute(t *testing.T) {
+	rules := func() []*Route {
+		return []*Route{
+			{
+				Name:     "http",
+				Outputs:  []string{"a"},
+				NamePass: []string{"http_*"},
+				NameDrop: []string{"http_internal"},
+			},
+			{
+				Name:    "prod",
+				Outputs: []string{"b"},
+				TagPass: map[string][]string{"env": {"prod*"}},
+				TagDrop: map[string][]string{"region": {"test"}},
+			},
+			{
+				Name:      "errors",
+				Outputs:   []string{"c"},
+				FieldPass: []string{"error*"},
+			},
+			{
+				Name:       "slow",
+				Outputs:    []string{"d"},
+				MetricPass: "fields.duration > 1.0",
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		match    string
+		dflt     []string
+		metric   telegraf.Metric
+		expected []string
+	}{
+		{
+			name:     "name",
+			metric:   newMetric("http_response", nil, map[string]interface{}{"value": 1}),
+			expected: []string{"http"},
+		},
+		{
+			name:     "name excluded",
+			metric:   newMetric("http_internal", nil, map[string]interface{}{"value": 1}),
+			expected: nil,
+		},
+		{
+			name: "tag",
+			metric: newMetric("cpu",
+				map[string]string{"env": "production"},
+				map[string]interface{}{"value": 1}),
+			expected: []string{"prod"},
+		},
+		{
+			name: "tag excluded",
+			metric: newMetric("cpu",
+				map[string]string{"env": "production", "region": "test"},
+				map[string]interface{}{"value": 1}),
+			expected: nil,
+		},
+		{
+			name:     "field",
+			metric:   newMetric("app", nil, map[string]interface{}{"errors_total": 1}),
+			expected: []string{"errors"},
+		},
+		{
+			name:     "expression",
+			metric:   newMetric("app", nil, map[string]interface{}{"duration": 1.5}),
+			expected: []string{"slow"},
+		},
+		{
+			name:     "default",
+			dflt:     []string{"e"},
+			metric:   newMetric("app", nil, map[string]interface{}{"duration": 0.5}),
+			expected: []string{DefaultRoute},
+		},
+		{
+			name: "first match",
+			dflt: []string{"e"},
+			metric: newMetric("http_response",
+				map[string]string{"env": "prod"},
+				map[string]interface{}{"errors": 1, "duration": 2.0}),
+			expected: []string{"http"},
+		},
+		{
+			name:  "all matches",
+			match: "all",
+			dflt:  []string{"e"},
+			metric: newMetric("http_response",
+				map[string]string{"env": "prod"},
+				map[string]interface{}{"errors": 1, "duration": 2.0}),
+			expected: []string{"http", "prod", "errors", "slow"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Router{
+				Rules:   rules(),
+				Default: tt.dflt,
+				Match:   tt.match,
+			}
+			require.NoError(t, r.Init())
+			require.Equal(t, tt.expected, r.Route(tt.metric))
+		})
+	}
+}
+
+func TestRouteMetricPassError(t *testing.T) {
+	r := &Router{
+		Rules: []*Route{
+			{
+				Name:       "slow",
+				Outputs:    []string{"a"},
+				MetricPass: "fields.duration > 1.0",
+			},
+		},
+		Default: []string{"b"},
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+
+	m := newMetric("app", nil, map[string]interface{}{"duration": "slow"})
+	require.Equal(t, []string{DefaultRoute}, r.Route(m))
+	require.Equal(t, []string{DefaultRoute}, r.Route(m))
+	require.Equal(t, int32(1), r.Rules[0].metricPassFailed)
+}
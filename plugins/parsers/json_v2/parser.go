@@ -0,0 +1,453 @@
+package json_v2
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/tidwall/gjson"
+)
+
+// Config defines the metrics created from a document.  The paths are GJSON
+// paths relative to the document root.
+type Config struct {
+	MeasurementName     string `toml:"measurement_name"`
+	MeasurementNamePath string `toml:"measurement_name_path"`
+	TimestampPath       string `toml:"timestamp_path"`
+	TimestampFormat     string `toml:"timestamp_format"`
+	TimestampTimezone   string `toml:"timestamp_timezone"`
+
+	// Tags and Fields are single values added to all metrics of the config.
+	Tags   []DataSet `toml:"tag"`
+	Fields []DataSet `toml:"field"`
+	// Objects are expanded into a metric for each object they select.
+	Objects []Object `toml:"object"`
+}
+
+// DataSet is a single value of the document.
+type DataSet struct {
+	Path   string `toml:"path"`
+	Rename string `toml:"rename"`
+	Type   string `toml:"type"`
+}
+
+// Object selects objects of the document, or arrays of objects, which are
+// flattened into metrics.  Nested objects are flattened into keys joined by
+// an underscore, and each element of nested arrays creates a metric with the
+// keys of the enclosing objects.
+type Object struct {
+	Path               string            `toml:"path"`
+	TimestampKey       string            `toml:"timestamp_key"`
+	TimestampFormat    string            `toml:"timestamp_format"`
+	TimestampTimezone  string            `toml:"timestamp_timezone"`
+	DisablePrependKeys bool              `toml:"disable_prepend_keys"`
+	IncludedKeys       []string          `toml:"included_keys"`
+	ExcludedKeys       []string          `toml:"excluded_keys"`
+	Tags               []string          `toml:"tags"`
+	Renames            map[string]string `toml:"renames"`
+	Fields             map[string]string `toml:"fields"`
+}
+
+// Parser parses JSON documents into metrics as defined by its configs.
+type Parser struct {
+	Configs     []Config
+	MetricName  string
+	DefaultTags map[string]string
+	TimeFunc    func() time.Time
+}
+
+// New returns a parser with the given configs.
+func New(configs []Config, metricName string, defaultTags map[string]string) (*Parser, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no json_v2 configuration")
+	}
+
+	for _, c := range configs {
+		for _, datasets := range [][]DataSet{c.Tags, c.Fields} {
+			for _, d := range datasets {
+				if d.Path == "" {
+					return nil, fmt.Errorf("tag or field without path")
+				}
+				if err := checkType(d.Type); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, o := range c.Objects {
+			if o.Path == "" {
+				return nil, fmt.Errorf("object without path")
+			}
+			for _, typ := range o.Fields {
+				if err := checkType(typ); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return &Parser{
+		Configs:     configs,
+		MetricName:  metricName,
+		DefaultTags: defaultTags,
+		TimeFunc:    time.Now,
+	}, nil
+}
+
+func checkType(typ string) error {
+	switch typ {
+	case "", "int", "uint", "float", "string", "bool":
+		return nil
+	default:
+		return fmt.Errorf("unknown type %q", typ)
+	}
+}
+
+// Parse parses a JSON document into the metrics of each config.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if !gjson.ValidBytes(buf) {
+		return nil, fmt.Errorf("invalid JSON")
+	}
+
+	t := p.TimeFunc()
+	metrics := make([]telegraf.Metric, 0)
+	for _, c := range p.Configs {
+		ms, err := p.parseConfig(c, buf, t)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, ms...)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) parseConfig(c Config, buf []byte, t time.Time) ([]telegraf.Metric, error) {
+	name := p.MetricName
+	if c.MeasurementName != "" {
+		name = c.MeasurementName
+	}
+	if c.MeasurementNamePath != "" {
+		if r := get(buf, c.MeasurementNamePath); r.Exists() {
+			name = r.String()
+		}
+	}
+
+	if c.TimestampPath != "" {
+		if r := get(buf, c.TimestampPath); r.Exists() {
+			var err error
+			t, err = parseTimestamp(r.Value(), c.TimestampFormat, c.TimestampTimezone)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tags := make(map[string]string)
+	for key, value := range p.DefaultTags {
+		tags[key] = value
+	}
+	for _, d := range c.Tags {
+		r := get(buf, d.Path)
+		if !isScalar(r) {
+			continue
+		}
+		tags[d.name()] = r.String()
+	}
+
+	fields := make(map[string]interface{})
+	for _, d := range c.Fields {
+		r := get(buf, d.Path)
+		if !isScalar(r) {
+			continue
+		}
+		v, err := convert(r, d.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", d.name(), err)
+		}
+		fields[d.name()] = v
+	}
+
+	if len(c.Objects) == 0 {
+		if len(fields) == 0 {
+			return nil, nil
+		}
+		m, err := metric.New(name, tags, fields, t)
+		if err != nil {
+			return nil, err
+		}
+		return []telegraf.Metric{m}, nil
+	}
+
+	var metrics []telegraf.Metric
+	for _, o := range c.Objects {
+		r := get(buf, o.Path)
+		if !r.Exists() {
+			continue
+		}
+
+		for _, row := range expand(o, "", r) {
+			m, err := o.metric(name, tags, fields, row, t)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil {
+				metrics = append(metrics, m)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+// get returns the value of the path in the document, the path "@this"
+// being the whole document.
+func get(buf []byte, path string) gjson.Result {
+	if path == "@this" {
+		return gjson.ParseBytes(buf)
+	}
+	return gjson.GetBytes(buf, path)
+}
+
+func (d DataSet) name() string {
+	if d.Rename != "" {
+		return d.Rename
+	}
+	return nameOfLast(d.Path)
+}
+
+// nameOfLast returns the last component of a GJSON path.
+func nameOfLast(path string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == '.' && (i == 0 || path[i-1] != '\\') {
+			return path[i+1:]
+		}
+	}
+	return path
+}
+
+// row is a flattened object, the values by key.
+type row map[string]gjson.Result
+
+// expand flattens a value into rows.  The keys of nested objects are
+// prefixed with the key of the enclosing object, and the elements of arrays
+// are expanded into separate rows combined with the keys of the enclosing
+// objects.
+func expand(o Object, prefix string, r gjson.Result) []row {
+	switch {
+	case r.IsArray():
+		var rows []row
+		for _, elem := range r.Array() {
+			rows = append(rows, expand(o, prefix, elem)...)
+		}
+		return rows
+	case r.IsObject():
+		rows := []row{{}}
+		r.ForEach(func(key, value gjson.Result) bool {
+			k := key.String()
+			if prefix != "" && !o.DisablePrependKeys {
+				k = prefix + "_" + k
+			}
+
+			children := expand(o, k, value)
+			if len(children) == 0 {
+				return true
+			}
+
+			combined := make([]row, 0, len(rows)*len(children))
+			for _, parent := range rows {
+				for _, child := range children {
+					c := make(row, len(parent)+len(child))
+					for k, v := range parent {
+						c[k] = v
+					}
+					for k, v := range child {
+						c[k] = v
+					}
+					combined = append(combined, c)
+				}
+			}
+			rows = combined
+			return true
+		})
+		return rows
+	case r.Type == gjson.Null:
+		return nil
+	default:
+		if prefix == "" || !o.included(prefix) {
+			return nil
+		}
+		return []row{{prefix: r}}
+	}
+}
+
+func (o Object) included(key string) bool {
+	for _, excluded := range o.ExcludedKeys {
+		if key == excluded {
+			return false
+		}
+	}
+	if len(o.IncludedKeys) == 0 || key == o.TimestampKey {
+		return true
+	}
+	for _, included := range o.IncludedKeys {
+		if key == included {
+			return true
+		}
+	}
+	for _, tag := range o.Tags {
+		if key == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// metric creates the metric of a row, with the tags and fields of the config.
+func (o Object) metric(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	r row,
+	t time.Time,
+) (telegraf.Metric, error) {
+	m, err := metric.New(name, tags, fields, t)
+	if err != nil {
+		return nil, err
+	}
+
+	isTag := make(map[string]bool, len(o.Tags))
+	for _, tag := range o.Tags {
+		isTag[tag] = true
+	}
+
+	for key, value := range r {
+		if key == o.TimestampKey {
+			tm, err := parseTimestamp(value.Value(), o.TimestampFormat, o.TimestampTimezone)
+			if err != nil {
+				return nil, err
+			}
+			m.SetTime(tm)
+			continue
+		}
+
+		name := key
+		if rename, ok := o.Renames[key]; ok {
+			name = rename
+		}
+
+		if isTag[key] {
+			m.AddTag(name, value.String())
+			continue
+		}
+
+		v, err := convert(value, o.Fields[key])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", key, err)
+		}
+		m.AddField(name, v)
+	}
+
+	if len(m.FieldList()) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+func isScalar(r gjson.Result) bool {
+	return r.Exists() && r.Type != gjson.Null && !r.IsArray() && !r.IsObject()
+}
+
+func parseTimestamp(value interface{}, format, timezone string) (time.Time, error) {
+	if format == "" {
+		return time.Time{}, fmt.Errorf("timestamp without timestamp_format")
+	}
+	if f, ok := value.(float64); ok && format != "unix" {
+		value = int64(f)
+	}
+	tm, err := internal.ParseTimestamp(format, value, timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %v: %v", value, err)
+	}
+	return tm, nil
+}
+
+// convert converts a JSON value to the type.  Integers are converted from
+// the raw value so that they keep their precision beyond that of a float64.
+// Without type the value is kept.
+func convert(r gjson.Result, typ string) (interface{}, error) {
+	switch typ {
+	case "":
+		return r.Value(), nil
+	case "string":
+		return r.String(), nil
+	}
+
+	switch r.Type {
+	case gjson.Number:
+		switch typ {
+		case "int":
+			return r.Int(), nil
+		case "uint":
+			if r.Num < 0 {
+				return nil, fmt.Errorf("negative value %s is not an uint", r.Raw)
+			}
+			return r.Uint(), nil
+		case "float":
+			return r.Num, nil
+		case "bool":
+			return r.Num != 0, nil
+		}
+	case gjson.True, gjson.False:
+		v := r.Bool()
+		switch typ {
+		case "int":
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case "uint":
+			if v {
+				return uint64(1), nil
+			}
+			return uint64(0), nil
+		case "float":
+			if v {
+				return 1.0, nil
+			}
+			return 0.0, nil
+		case "bool":
+			return v, nil
+		}
+	case gjson.String:
+		switch typ {
+		case "int":
+			return strconv.ParseInt(r.Str, 10, 64)
+		case "uint":
+			return strconv.ParseUint(r.Str, 10, 64)
+		case "float":
+			return strconv.ParseFloat(r.Str, 64)
+		case "bool":
+			return strconv.ParseBool(r.Str)
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %v to %s", r.Value(), typ)
+}
+
+// ParseLine parses a single JSON document into a metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("no metric in line")
+	}
+	return metrics[0], nil
+}
+
+// SetDefaultTags sets the default tags for every metric.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
@@ -0,0 +1,212 @@
+package json_v2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const status = `{
+  "host": "router-1",
+  "version": "2.4.1",
+  "uptime": 3600,
+  "time": "2020-03-10T09:00:00Z",
+  "interfaces": [
+    {
+      "name": "eth0",
+      "up": true,
+      "stats": {"rx_bytes": 1200, "tx_bytes": 800},
+      "queues": [{"id": 0, "drops": 1}, {"id": 1, "drops": 2}]
+    },
+    {
+      "name": "eth1",
+      "up": false,
+      "stats": {"rx_bytes": 0, "tx_bytes": null},
+      "queues": []
+    }
+  ]
+}`
+
+func newParser(t *testing.T, configs ...Config) *Parser {
+	p, err := New(configs, "file", map[string]string{"source": "test"})
+	require.NoError(t, err)
+	p.TimeFunc = func() time.Time { return time.Unix(42, 0) }
+	return p
+}
+
+func TestParseFields(t *testing.T) {
+	p := newParser(t, Config{
+		MeasurementName: "router",
+		TimestampPath:   "time",
+		TimestampFormat: "2006-01-02T15:04:05Z07:00",
+		Tags: []DataSet{
+			{Path: "host"},
+		},
+		Fields: []DataSet{
+			{Path: "version"},
+			{Path: "uptime", Type: "int"},
+			{Path: "interfaces.#", Rename: "interfaces"},
+			{Path: "missing"},
+		},
+	})
+
+	metrics, err := p.Parse([]byte(status))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("router",
+			map[string]string{"host": "router-1", "source": "test"},
+			map[string]interface{}{
+				"version":    "2.4.1",
+				"uptime":     int64(3600),
+				"interfaces": 2.0,
+			},
+			time.Unix(1583830800, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseObjects(t *testing.T) {
+	p := newParser(t, Config{
+		MeasurementName: "interface",
+		Tags:            []DataSet{{Path: "host"}},
+		Objects: []Object{
+			{
+				Path:         "interfaces",
+				Tags:         []string{"name", "queues_id"},
+				ExcludedKeys: []string{"stats_tx_bytes"},
+				Renames:      map[string]string{"queues_id": "queue"},
+				Fields:       map[string]string{"stats_rx_bytes": "int", "queues_drops": "uint"},
+			},
+		},
+	})
+
+	metrics, err := p.Parse([]byte(status))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("interface",
+			map[string]string{"host": "router-1", "source": "test", "name": "eth0", "queue": "0"},
+			map[string]interface{}{"up": true, "stats_rx_bytes": int64(1200), "queues_drops": uint64(1)},
+			time.Unix(42, 0)),
+		testutil.MustMetric("interface",
+			map[string]string{"host": "router-1", "source": "test", "name": "eth0", "queue": "1"},
+			map[string]interface{}{"up": true, "stats_rx_bytes": int64(1200), "queues_drops": uint64(2)},
+			time.Unix(42, 0)),
+		testutil.MustMetric("interface",
+			map[string]string{"host": "router-1", "source": "test", "name": "eth1"},
+			map[string]interface{}{"up": false, "stats_rx_bytes": int64(0)},
+			time.Unix(42, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics, testutil.SortMetrics())
+}
+
+func TestParseObjectOptions(t *testing.T) {
+	p := newParser(t, Config{
+		MeasurementNamePath: "host",
+		Objects: []Object{
+			{
+				Path:               "interfaces.#(name==\"eth0\")",
+				DisablePrependKeys: true,
+				IncludedKeys:       []string{"rx_bytes", "drops"},
+			},
+		},
+	})
+
+	metrics, err := p.Parse([]byte(status))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("router-1",
+			map[string]string{"source": "test"},
+			map[string]interface{}{"rx_bytes": 1200.0, "drops": 1.0},
+			time.Unix(42, 0)),
+		testutil.MustMetric("router-1",
+			map[string]string{"source": "test"},
+			map[string]interface{}{"rx_bytes": 1200.0, "drops": 2.0},
+			time.Unix(42, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics, testutil.SortMetrics())
+}
+
+func TestParseObjectTimestamp(t *testing.T) {
+	p := newParser(t, Config{
+		Objects: []Object{
+			{
+				Path:            "@this",
+				TimestampKey:    "ts",
+				TimestampFormat: "unix_ms",
+			},
+		},
+	})
+
+	metrics, err := p.Parse([]byte(`[{"ts": 1583830800000, "value": 1}, {"ts": 1583830801000, "value": 2}]`))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("file",
+			map[string]string{"source": "test"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(1583830800, 0)),
+		testutil.MustMetric("file",
+			map[string]string{"source": "test"},
+			map[string]interface{}{"value": 2.0},
+			time.Unix(1583830801, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseLargeIntegers(t *testing.T) {
+	doc := `{"id": 9007199254740993, "count": 18446744073709551615, ` +
+		`"items": [{"id": 9007199254740993, "count": 18446744073709551615}]}`
+
+	p := newParser(t, Config{
+		Fields: []DataSet{
+			{Path: "id", Type: "int"},
+			{Path: "count", Type: "uint"},
+		},
+	})
+	metrics, err := p.Parse([]byte(doc))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("file",
+			map[string]string{"source": "test"},
+			map[string]interface{}{
+				"id":    int64(9007199254740993),
+				"count": uint64(18446744073709551615),
+			},
+			time.Unix(42, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+
+	p = newParser(t, Config{
+		Objects: []Object{
+			{
+				Path:   "items",
+				Fields: map[string]string{"id": "int", "count": "uint"},
+			},
+		},
+	})
+	metrics, err = p.Parse([]byte(doc))
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := New(nil, "file", nil)
+	require.Error(t, err)
+
+	_, err = New([]Config{{Fields: []DataSet{{Path: "a", Type: "integer"}}}}, "file", nil)
+	require.Error(t, err)
+
+	p := newParser(t, Config{Fields: []DataSet{{Path: "host", Type: "int"}}})
+	_, err = p.Parse([]byte(status))
+	require.Error(t, err)
+
+	_, err = p.Parse([]byte(`{"host":`))
+	require.Error(t, err)
+}
@@ -21,10 +21,56 @@ import (
 	"github.com/prometheus/common/expfmt"
 )
 
+// Parser parses the Prometheus exposition formats into metrics.
+type Parser struct {
+	// MetricVersion selects the mapping of Prometheus metrics, 1 creates a
+	// metric per Prometheus metric named after it and 2 creates metrics
+	// named "prometheus" with a field per Prometheus metric.
+	MetricVersion int
+	// Header is the HTTP header of the response the data was read from, the
+	// Content-Type selects between the text and protocol buffer formats.
+	Header      http.Header
+	DefaultTags map[string]string
+	TimeFunc    func() time.Time
+}
+
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
-func ParseV2(buf []byte, header http.Header) ([]telegraf.Metric, error) {
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metricFamilies, err := p.readMetricFamilies(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if p.TimeFunc != nil {
+		now = p.TimeFunc()
+	}
+
 	var metrics []telegraf.Metric
+	for metricName, mf := range metricFamilies {
+		for _, m := range mf.Metric {
+			// reading tags
+			tags := p.makeLabels(m)
+			// metrics without timestamp in the exposition are at the time
+			// of parsing
+			t := now
+			if m.TimestampMs != nil && *m.TimestampMs > 0 {
+				t = time.Unix(0, *m.TimestampMs*1000000)
+			}
+
+			if p.MetricVersion == 2 {
+				metrics = append(metrics, parseV2(m, tags, metricName, mf.GetType(), t)...)
+			} else {
+				metrics = append(metrics, parseV1(m, tags, metricName, mf.GetType(), t)...)
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) readMetricFamilies(buf []byte) (map[string]*dto.MetricFamily, error) {
 	var parser expfmt.TextParser
 	// parse even if the buffer begins with a newline
 	buf = bytes.TrimPrefix(buf, []byte("\n"))
@@ -32,13 +78,11 @@ func ParseV2(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 	buffer := bytes.NewBuffer(buf)
 	reader := bufio.NewReader(buffer)
 
-	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
-	// Prepare output
-	metricFamilies := make(map[string]*dto.MetricFamily)
-
+	mediatype, params, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
 	if err == nil && mediatype == "application/vnd.google.protobuf" &&
 		params["encoding"] == "delimited" &&
 		params["proto"] == "io.prometheus.client.MetricFamily" {
+		metricFamilies := make(map[string]*dto.MetricFamily)
 		for {
 			mf := &dto.MetricFamily{}
 			if _, ierr := pbutil.ReadDelimited(reader, mf); ierr != nil {
@@ -49,62 +93,76 @@ func ParseV2(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 			}
 			metricFamilies[mf.GetName()] = mf
 		}
+		return metricFamilies, nil
+	}
+
+	metricFamilies, err := parser.TextToMetricFamilies(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading text format failed: %s", err)
+	}
+	return metricFamilies, nil
+}
+
+// parseV1 creates a metric named after the Prometheus metric, with the
+// quantiles or buckets as fields.
+func parseV1(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType, t time.Time) []telegraf.Metric {
+	// reading fields
+	var fields map[string]interface{}
+	if metricType == dto.MetricType_SUMMARY {
+		// summary metric
+		fields = makeQuantiles(m)
+		fields["count"] = float64(m.GetSummary().GetSampleCount())
+		fields["sum"] = float64(m.GetSummary().GetSampleSum())
+	} else if metricType == dto.MetricType_HISTOGRAM {
+		// histogram metric
+		fields = makeBuckets(m)
+		fields["count"] = float64(m.GetHistogram().GetSampleCount())
+		fields["sum"] = float64(m.GetHistogram().GetSampleSum())
 	} else {
-		metricFamilies, err = parser.TextToMetricFamilies(reader)
-		if err != nil {
-			return nil, fmt.Errorf("reading text format failed: %s", err)
-		}
+		// standard metric
+		fields = getNameAndValue(m)
 	}
 
-	// read metrics
-	for metricName, mf := range metricFamilies {
-		for _, m := range mf.Metric {
-			// reading tags
-			tags := makeLabels(m)
-
-			if mf.GetType() == dto.MetricType_SUMMARY {
-				// summary metric
-				telegrafMetrics := makeQuantilesV2(m, tags, metricName, mf.GetType())
-				metrics = append(metrics, telegrafMetrics...)
-			} else if mf.GetType() == dto.MetricType_HISTOGRAM {
-				// histogram metric
-				telegrafMetrics := makeBucketsV2(m, tags, metricName, mf.GetType())
-				metrics = append(metrics, telegrafMetrics...)
-			} else {
-				// standard metric
-				// reading fields
-				fields := make(map[string]interface{})
-				fields = getNameAndValueV2(m, metricName)
-				// converting to telegraf metric
-				if len(fields) > 0 {
-					var t time.Time
-					if m.TimestampMs != nil && *m.TimestampMs > 0 {
-						t = time.Unix(0, *m.TimestampMs*1000000)
-					} else {
-						t = time.Now()
-					}
-					metric, err := metric.New("prometheus", tags, fields, t, valueType(mf.GetType()))
-					if err == nil {
-						metrics = append(metrics, metric)
-					}
-				}
-			}
-		}
+	// converting to telegraf metric
+	if len(fields) == 0 {
+		return nil
+	}
+	met, err := metric.New(metricName, tags, fields, t, valueType(metricType))
+	if err != nil {
+		return nil
+	}
+	return []telegraf.Metric{met}
+}
+
+// parseV2 creates metrics named "prometheus" with the Prometheus metric as
+// field, and a metric for each quantile or bucket.
+func parseV2(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType, t time.Time) []telegraf.Metric {
+	if metricType == dto.MetricType_SUMMARY {
+		// summary metric
+		return makeQuantilesV2(m, tags, metricName, metricType, t)
+	} else if metricType == dto.MetricType_HISTOGRAM {
+		// histogram metric
+		return makeBucketsV2(m, tags, metricName, metricType, t)
 	}
 
-	return metrics, err
+	// standard metric
+	// reading fields
+	fields := getNameAndValueV2(m, metricName)
+	// converting to telegraf metric
+	if len(fields) == 0 {
+		return nil
+	}
+	met, err := metric.New("prometheus", tags, fields, t, valueType(metricType))
+	if err != nil {
+		return nil
+	}
+	return []telegraf.Metric{met}
 }
 
 // Get Quantiles for summary metric & Buckets for histogram
-func makeQuantilesV2(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType) []telegraf.Metric {
+func makeQuantilesV2(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType, t time.Time) []telegraf.Metric {
 	var metrics []telegraf.Metric
 	fields := make(map[string]interface{})
-	var t time.Time
-	if m.TimestampMs != nil && *m.TimestampMs > 0 {
-		t = time.Unix(0, *m.TimestampMs*1000000)
-	} else {
-		t = time.Now()
-	}
 	fields[metricName+"_count"] = float64(m.GetSummary().GetSampleCount())
 	fields[metricName+"_sum"] = float64(m.GetSummary().GetSampleSum())
 	met, err := metric.New("prometheus", tags, fields, t, valueType(metricType))
@@ -113,7 +171,7 @@ func makeQuantilesV2(m *dto.Metric, tags map[string]string, metricName string, m
 	}
 
 	for _, q := range m.GetSummary().Quantile {
-		newTags := tags
+		newTags := copyTags(tags)
 		fields = make(map[string]interface{})
 		if !math.IsNaN(q.GetValue()) {
 			newTags["quantile"] = fmt.Sprint(q.GetQuantile())
@@ -129,15 +187,9 @@ func makeQuantilesV2(m *dto.Metric, tags map[string]string, metricName string, m
 }
 
 // Get Buckets  from histogram metric
-func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType) []telegraf.Metric {
+func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType, t time.Time) []telegraf.Metric {
 	var metrics []telegraf.Metric
 	fields := make(map[string]interface{})
-	var t time.Time
-	if m.TimestampMs != nil && *m.TimestampMs > 0 {
-		t = time.Unix(0, *m.TimestampMs*1000000)
-	} else {
-		t = time.Now()
-	}
 	fields[metricName+"_count"] = float64(m.GetHistogram().GetSampleCount())
 	fields[metricName+"_sum"] = float64(m.GetHistogram().GetSampleSum())
 
@@ -147,7 +199,7 @@ func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, met
 	}
 
 	for _, b := range m.GetHistogram().Bucket {
-		newTags := tags
+		newTags := copyTags(tags)
 		fields = make(map[string]interface{})
 		newTags["le"] = fmt.Sprint(b.GetUpperBound())
 		fields[metricName+"_bucket"] = float64(b.GetCumulativeCount())
@@ -160,80 +212,12 @@ func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, met
 	return metrics
 }
 
-// Parse returns a slice of Metrics from a text representation of a
-// metrics
-func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
-	var metrics []telegraf.Metric
-	var parser expfmt.TextParser
-	// parse even if the buffer begins with a newline
-	buf = bytes.TrimPrefix(buf, []byte("\n"))
-	// Read raw data
-	buffer := bytes.NewBuffer(buf)
-	reader := bufio.NewReader(buffer)
-
-	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
-	// Prepare output
-	metricFamilies := make(map[string]*dto.MetricFamily)
-
-	if err == nil && mediatype == "application/vnd.google.protobuf" &&
-		params["encoding"] == "delimited" &&
-		params["proto"] == "io.prometheus.client.MetricFamily" {
-		for {
-			mf := &dto.MetricFamily{}
-			if _, ierr := pbutil.ReadDelimited(reader, mf); ierr != nil {
-				if ierr == io.EOF {
-					break
-				}
-				return nil, fmt.Errorf("reading metric family protocol buffer failed: %s", ierr)
-			}
-			metricFamilies[mf.GetName()] = mf
-		}
-	} else {
-		metricFamilies, err = parser.TextToMetricFamilies(reader)
-		if err != nil {
-			return nil, fmt.Errorf("reading text format failed: %s", err)
-		}
+func copyTags(tags map[string]string) map[string]string {
+	result := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		result[k] = v
 	}
-
-	// read metrics
-	for metricName, mf := range metricFamilies {
-		for _, m := range mf.Metric {
-			// reading tags
-			tags := makeLabels(m)
-			// reading fields
-			fields := make(map[string]interface{})
-			if mf.GetType() == dto.MetricType_SUMMARY {
-				// summary metric
-				fields = makeQuantiles(m)
-				fields["count"] = float64(m.GetSummary().GetSampleCount())
-				fields["sum"] = float64(m.GetSummary().GetSampleSum())
-			} else if mf.GetType() == dto.MetricType_HISTOGRAM {
-				// histogram metric
-				fields = makeBuckets(m)
-				fields["count"] = float64(m.GetHistogram().GetSampleCount())
-				fields["sum"] = float64(m.GetHistogram().GetSampleSum())
-
-			} else {
-				// standard metric
-				fields = getNameAndValue(m)
-			}
-			// converting to telegraf metric
-			if len(fields) > 0 {
-				var t time.Time
-				if m.TimestampMs != nil && *m.TimestampMs > 0 {
-					t = time.Unix(0, *m.TimestampMs*1000000)
-				} else {
-					t = time.Now()
-				}
-				metric, err := metric.New(metricName, tags, fields, t, valueType(mf.GetType()))
-				if err == nil {
-					metrics = append(metrics, metric)
-				}
-			}
-		}
-	}
-
-	return metrics, err
+	return result
 }
 
 func valueType(mt dto.MetricType) telegraf.ValueType {
@@ -271,9 +255,12 @@ func makeBuckets(m *dto.Metric) map[string]interface{} {
 	return fields
 }
 
-// Get labels from metric
-func makeLabels(m *dto.Metric) map[string]string {
+// Get labels from metric, with the default tags for labels not set
+func (p *Parser) makeLabels(m *dto.Metric) map[string]string {
 	result := map[string]string{}
+	for key, value := range p.DefaultTags {
+		result[key] = value
+	}
 	for _, lp := range m.Label {
 		result[lp.GetName()] = lp.GetValue()
 	}
@@ -317,3 +304,27 @@ func getNameAndValueV2(m *dto.Metric, metricName string) map[string]interface{}
 	}
 	return fields
 }
+
+// ParseLine parses a single line of the text format into a metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	// the text format requires lines to end with a newline
+	metrics, err := p.Parse([]byte(line + "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("no metrics in line")
+	}
+
+	if len(metrics) > 1 {
+		return nil, fmt.Errorf("more than one metric in line")
+	}
+
+	return metrics[0], nil
+}
+
+// SetDefaultTags sets the default tags for every metric.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
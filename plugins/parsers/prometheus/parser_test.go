@@ -1,10 +1,10 @@
 package prometheus
 
 import (
-	"net/http"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -102,8 +102,10 @@ cpu,host=foo,datacenter=us-east usage_idle=99,usage_busy=1
 `
 
 func TestParseValidPrometheus(t *testing.T) {
+	parser := Parser{}
+
 	// Gauge value
-	metrics, err := Parse([]byte(validUniqueGauge), http.Header{})
+	metrics, err := parser.Parse([]byte(validUniqueGauge))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "cadvisor_version_info", metrics[0].Name())
@@ -119,7 +121,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	}, metrics[0].Tags())
 
 	// Counter value
-	metrics, err = Parse([]byte(validUniqueCounter), http.Header{})
+	metrics, err = parser.Parse([]byte(validUniqueCounter))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "get_token_fail_count", metrics[0].Name())
@@ -130,7 +132,7 @@ func TestParseValidPrometheus(t *testing.T) {
 
 	// Summary data
 	//SetDefaultTags(map[string]string{})
-	metrics, err = Parse([]byte(validUniqueSummary), http.Header{})
+	metrics, err = parser.Parse([]byte(validUniqueSummary))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "http_request_duration_microseconds", metrics[0].Name())
@@ -144,7 +146,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	assert.Equal(t, map[string]string{"handler": "prometheus"}, metrics[0].Tags())
 
 	// histogram data
-	metrics, err = Parse([]byte(validUniqueHistogram), http.Header{})
+	metrics, err = parser.Parse([]byte(validUniqueHistogram))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "apiserver_request_latencies", metrics[0].Name())
@@ -165,3 +167,97 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func TestParseValidPrometheusV2(t *testing.T) {
+	parser := Parser{MetricVersion: 2}
+
+	// Gauge value
+	metrics, err := parser.Parse([]byte(validUniqueGauge))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, "prometheus", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"cadvisor_version_info": float64(1),
+	}, metrics[0].Fields())
+	assert.Equal(t, telegraf.Gauge, metrics[0].Type())
+
+	// Summary data
+	metrics, err = parser.Parse([]byte(validUniqueSummary))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 4)
+	assert.Equal(t, map[string]interface{}{
+		"http_request_duration_microseconds_count": 9.0,
+		"http_request_duration_microseconds_sum":   1.8909097205e+07,
+	}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"handler": "prometheus"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"http_request_duration_microseconds": 552048.506,
+	}, metrics[1].Fields())
+	assert.Equal(t, map[string]string{"handler": "prometheus", "quantile": "0.5"}, metrics[1].Tags())
+
+	// histogram data
+	metrics, err = parser.Parse([]byte(validUniqueHistogram))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 9)
+	assert.Equal(t, map[string]interface{}{
+		"apiserver_request_latencies_count": 2025.0,
+		"apiserver_request_latencies_sum":   1.02726334e+08,
+	}, metrics[0].Fields())
+	assert.Equal(t, map[string]interface{}{
+		"apiserver_request_latencies_bucket": 1994.0,
+	}, metrics[1].Fields())
+	assert.Equal(t,
+		map[string]string{"verb": "POST", "resource": "bindings", "le": "125000"},
+		metrics[1].Tags())
+}
+
+func TestParseTimestamp(t *testing.T) {
+	const data = `# TYPE get_token_fail_count counter
+get_token_fail_count 1 1257894000000
+get_token_fail_count{host="b"} 2
+`
+	for _, version := range []int{1, 2} {
+		parser := Parser{
+			MetricVersion: version,
+			TimeFunc:      func() time.Time { return time.Unix(42, 0) },
+		}
+		metrics, err := parser.Parse([]byte(data))
+		assert.NoError(t, err)
+		assert.Len(t, metrics, 2)
+		for _, m := range metrics {
+			if m.HasTag("host") {
+				assert.Equal(t, time.Unix(42, 0), m.Time())
+			} else {
+				assert.Equal(t, exptime, m.Time().UTC())
+			}
+		}
+	}
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser := Parser{}
+	parser.SetDefaultTags(map[string]string{
+		"handler": "default",
+		"region":  "us-east",
+	})
+
+	metrics, err := parser.Parse([]byte(validUniqueSummary))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t,
+		map[string]string{"handler": "prometheus", "region": "us-east"},
+		metrics[0].Tags())
+}
+
+func TestParseLine(t *testing.T) {
+	parser := Parser{}
+
+	m, err := parser.ParseLine(`get_token_fail_count{host="a"} 3`)
+	assert.NoError(t, err)
+	assert.Equal(t, "get_token_fail_count", m.Name())
+	assert.Equal(t, map[string]interface{}{"value": 3.0}, m.Fields())
+	assert.Equal(t, map[string]string{"host": "a"}, m.Tags())
+
+	_, err = parser.ParseLine(`get_token_fail_count{host="a" 3`)
+	assert.Error(t, err)
+}
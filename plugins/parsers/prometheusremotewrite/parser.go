@@ -0,0 +1,84 @@
+package prometheusremotewrite
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/prompb"
+)
+
+// Parser parses the body of Prometheus remote write requests, a snappy
+// compressed WriteRequest.  The metrics are mapped as by the prometheus data
+// format with metric version 2: a metric named "prometheus" per sample with
+// the metric name as field and the labels as tags.
+type Parser struct {
+	DefaultTags map[string]string
+}
+
+// Parse decodes a remote write request into a metric per sample.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	data, err := snappy.Decode(nil, buf)
+	if err != nil {
+		return nil, fmt.Errorf("decoding snappy failed: %v", err)
+	}
+
+	var req prompb.WriteRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal request body: %v", err)
+	}
+
+	var metrics []telegraf.Metric
+	for _, ts := range req.Timeseries {
+		tags := map[string]string{}
+		for key, value := range p.DefaultTags {
+			tags[key] = value
+		}
+
+		var metricName string
+		for _, l := range ts.Labels {
+			if l.Name == prompb.MetricNameLabel {
+				metricName = l.Value
+				continue
+			}
+			tags[l.Name] = l.Value
+		}
+		if metricName == "" {
+			return nil, fmt.Errorf("series without %s label", prompb.MetricNameLabel)
+		}
+
+		for _, s := range ts.Samples {
+			// NaN values, such as the staleness markers of Prometheus, can't
+			// be represented as fields.
+			if math.IsNaN(s.Value) {
+				continue
+			}
+
+			fields := map[string]interface{}{
+				metricName: s.Value,
+			}
+			t := time.Unix(0, s.Timestamp*int64(time.Millisecond))
+			m, err := metric.New("prometheus", tags, fields, t)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics, nil
+}
+
+// ParseLine is not supported, remote write requests are binary.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	return nil, fmt.Errorf("ParseLine not supported: %s, for data format: prometheusremotewrite", line)
+}
+
+// SetDefaultTags sets the default tags for every metric.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
@@ -0,0 +1,107 @@
+package prometheusremotewrite
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/prompb"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(t *testing.T, req *prompb.WriteRequest) []byte {
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+	return snappy.Encode(nil, data)
+}
+
+func TestParse(t *testing.T) {
+	req := &prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels: []*prompb.Label{
+					{Name: "__name__", Value: "go_gc_duration_seconds"},
+					{Name: "quantile", Value: "0.99"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: 4.63, Timestamp: 1257894000000},
+					{Value: 4.75, Timestamp: 1257894010000},
+				},
+			},
+			{
+				Labels: []*prompb.Label{
+					{Name: "__name__", Value: "up"},
+					{Name: "job", Value: "node"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: math.NaN(), Timestamp: 1257894000000},
+				},
+			},
+		},
+	}
+
+	parser := Parser{
+		DefaultTags: map[string]string{"host": "example.org"},
+	}
+	metrics, err := parser.Parse(encode(t, req))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"quantile": "0.99", "host": "example.org"},
+			map[string]interface{}{"go_gc_duration_seconds": 4.63},
+			time.Unix(1257894000, 0),
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"quantile": "0.99", "host": "example.org"},
+			map[string]interface{}{"go_gc_duration_seconds": 4.75},
+			time.Unix(1257894010, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+// TestParseWire checks the decoding of a request encoded as by Prometheus.
+func TestParseWire(t *testing.T) {
+	data, err := hex.DecodeString("0a2f0a0e0a085f5f6e616d655f5f120275700a0b0a036a6f6212046e6f6465121009000000000000f03f1080ebcc82ce24")
+	require.NoError(t, err)
+
+	parser := Parser{}
+	metrics, err := parser.Parse(snappy.Encode(nil, data))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"job": "node"},
+			map[string]interface{}{"up": 1.0},
+			time.Unix(1257894000, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := Parser{}
+
+	_, err := parser.Parse([]byte("up 1\n"))
+	require.Error(t, err)
+
+	req := &prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels:  []*prompb.Label{{Name: "job", Value: "node"}},
+				Samples: []*prompb.Sample{{Value: 1, Timestamp: 1257894000000}},
+			},
+		},
+	}
+	_, err = parser.Parse(encode(t, req))
+	require.Error(t, err)
+}
@@ -13,10 +13,14 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
 	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheusremotewrite"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 	"github.com/influxdata/telegraf/plugins/parsers/wavefront"
+	"github.com/influxdata/telegraf/plugins/parsers/xml"
 )
 
 type ParserFunc func() (Parser, error)
@@ -148,6 +152,15 @@ type Config struct {
 
 	// FormData configuration
 	FormUrlencodedTagKeys []string `toml:"form_urlencoded_tag_keys"`
+
+	// Prometheus configuration
+	PrometheusMetricVersion int `toml:"prometheus_metric_version"`
+
+	// XML configuration
+	XMLConfig []xml.Config `toml:"xml"`
+
+	// JSONv2 configuration
+	JSONV2Config []json_v2.Config `toml:"json_v2"`
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -227,6 +240,17 @@ func NewParser(config *Config) (Parser, error) {
 			config.DefaultTags,
 			config.FormUrlencodedTagKeys,
 		)
+	case "prometheus":
+		parser, err = NewPrometheusParser(
+			config.PrometheusMetricVersion,
+			config.DefaultTags,
+		)
+	case "prometheusremotewrite":
+		parser, err = NewPrometheusRemoteWriteParser(config.DefaultTags)
+	case "json_v2":
+		parser, err = json_v2.New(config.JSONV2Config, config.MetricName, config.DefaultTags)
+	case "xml":
+		parser, err = xml.New(config.XMLConfig, config.MetricName, config.DefaultTags)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -392,3 +416,31 @@ func NewFormUrlencodedParser(
 		TagKeys:     tagKeys,
 	}, nil
 }
+
+// NewPrometheusParser returns a parser of the Prometheus text format, mapping
+// the metrics as selected by the metric version, either 1 or 2.
+func NewPrometheusParser(
+	metricVersion int,
+	defaultTags map[string]string,
+) (Parser, error) {
+	switch metricVersion {
+	case 0:
+		metricVersion = 1
+	case 1, 2:
+	default:
+		return nil, fmt.Errorf("invalid prometheus_metric_version %d", metricVersion)
+	}
+
+	return &prometheus.Parser{
+		MetricVersion: metricVersion,
+		DefaultTags:   defaultTags,
+	}, nil
+}
+
+// NewPrometheusRemoteWriteParser returns a parser of Prometheus remote write
+// requests.
+func NewPrometheusRemoteWriteParser(defaultTags map[string]string) (Parser, error) {
+	return &prometheusremotewrite.Parser{
+		DefaultTags: defaultTags,
+	}, nil
+}
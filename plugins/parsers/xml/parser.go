@@ -0,0 +1,327 @@
+package xml
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Config selects the metric nodes of a document and maps them to metrics.
+// All queries except Selection are evaluated relative to the metric node.
+type Config struct {
+	// Selection selects the metric nodes, by default the document root.
+	Selection string `toml:"metric_selection"`
+	// MetricQuery is the query of the metric name, by default the name of
+	// the parser.
+	MetricQuery string `toml:"metric_name"`
+	// Timestamp is the query of the metric time, by default the time of
+	// parsing.
+	Timestamp       string `toml:"timestamp"`
+	TimestampFormat string `toml:"timestamp_format"`
+	Timezone        string `toml:"timezone"`
+
+	// Tags and Fields are the queries of the tags and fields by name.  The
+	// fields have the type of the query result, the FieldsInt are converted
+	// to integers.
+	Tags      map[string]string `toml:"tags"`
+	Fields    map[string]string `toml:"fields"`
+	FieldsInt map[string]string `toml:"fields_int"`
+
+	// FieldSelection selects nodes which are each added as a field, named by
+	// FieldNameQuery with the value of FieldValueQuery relative to the node.
+	FieldSelection  string `toml:"field_selection"`
+	FieldNameQuery  string `toml:"field_name"`
+	FieldValueQuery string `toml:"field_value"`
+}
+
+// Parser parses XML documents into metrics using XPath queries.
+type Parser struct {
+	Configs     []Config
+	MetricName  string
+	DefaultTags map[string]string
+	TimeFunc    func() time.Time
+
+	queries map[string]*xpath.Expr
+}
+
+// New returns a parser with the given configs, compiling their queries.
+func New(configs []Config, metricName string, defaultTags map[string]string) (*Parser, error) {
+	p := &Parser{
+		Configs:     configs,
+		MetricName:  metricName,
+		DefaultTags: defaultTags,
+		TimeFunc:    time.Now,
+		queries:     make(map[string]*xpath.Expr),
+	}
+
+	if len(p.Configs) == 0 {
+		return nil, fmt.Errorf("no xml configuration")
+	}
+
+	for i := range p.Configs {
+		c := &p.Configs[i]
+		if c.Selection == "" {
+			c.Selection = "/"
+		}
+		if c.FieldNameQuery == "" {
+			c.FieldNameQuery = "name()"
+		}
+		if c.FieldValueQuery == "" {
+			c.FieldValueQuery = "."
+		}
+
+		queries := []string{c.Selection, c.MetricQuery, c.Timestamp}
+		if c.FieldSelection != "" {
+			queries = append(queries, c.FieldSelection, c.FieldNameQuery, c.FieldValueQuery)
+		}
+		for _, m := range []map[string]string{c.Tags, c.Fields, c.FieldsInt} {
+			for _, query := range m {
+				queries = append(queries, query)
+			}
+		}
+
+		for _, query := range queries {
+			if err := p.compile(query); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Parser) compile(query string) error {
+	if _, ok := p.queries[query]; ok || query == "" {
+		return nil
+	}
+
+	expr, err := xpath.Compile(query)
+	if err != nil {
+		return fmt.Errorf("invalid query %q: %v", query, err)
+	}
+	p.queries[query] = expr
+	return nil
+}
+
+// Parse parses an XML document into metrics, one for each node selected by
+// each config.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	doc, err := xmlquery.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.TimeFunc()
+	metrics := make([]telegraf.Metric, 0)
+	for _, c := range p.Configs {
+		nodes := p.queries[c.Selection].Select(xmlquery.CreateXPathNavigator(doc))
+		for nodes.MoveNext() {
+			m, err := p.parseNode(c, nodes.Current().Copy(), t)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) parseNode(c Config, node xpath.NodeNavigator, t time.Time) (telegraf.Metric, error) {
+	name := p.MetricName
+	if c.MetricQuery != "" {
+		v, err := p.evaluate(c.MetricQuery, node)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			name = toString(v)
+		}
+	}
+
+	if c.Timestamp != "" {
+		v, err := p.evaluate(c.Timestamp, node)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			t, err = parseTimestamp(c, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp %v: %v", v, err)
+			}
+		}
+	}
+
+	tags := make(map[string]string)
+	for key, query := range c.Tags {
+		v, err := p.evaluate(query, node)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			tags[key] = toString(v)
+		}
+	}
+
+	fields := make(map[string]interface{})
+	for key, query := range c.Fields {
+		v, err := p.evaluate(query, node)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			fields[key] = v
+		}
+	}
+
+	for key, query := range c.FieldsInt {
+		v, err := p.evaluate(query, node)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+
+		switch v := v.(type) {
+		case float64:
+			fields[key] = int64(v)
+		case bool:
+			if v {
+				fields[key] = int64(1)
+			} else {
+				fields[key] = int64(0)
+			}
+		case string:
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %q is not an integer: %v", key, err)
+			}
+			fields[key] = i
+		}
+	}
+
+	if c.FieldSelection != "" {
+		nodes := p.queries[c.FieldSelection].Select(node.Copy())
+		for nodes.MoveNext() {
+			selected := nodes.Current().Copy()
+			key, err := p.evaluate(c.FieldNameQuery, selected)
+			if err != nil {
+				return nil, err
+			}
+			v, err := p.evaluate(c.FieldValueQuery, selected)
+			if err != nil {
+				return nil, err
+			}
+			if key == nil || v == nil {
+				continue
+			}
+
+			if s, ok := v.(string); ok {
+				v = guessType(s)
+			}
+			fields[toString(key)] = v
+		}
+	}
+
+	for key, value := range p.DefaultTags {
+		if _, ok := tags[key]; !ok {
+			tags[key] = value
+		}
+	}
+
+	return metric.New(name, tags, fields, t)
+}
+
+// evaluate returns the result of the query relative to the node, either a
+// float64, bool or string.  If the query selects nodes the result is the
+// value of the first node, or nil if no node was selected.
+func (p *Parser) evaluate(query string, node xpath.NodeNavigator) (result interface{}, err error) {
+	expr, ok := p.queries[query]
+	if !ok {
+		return nil, fmt.Errorf("unknown query %q", query)
+	}
+
+	// The xpath package panics on some invalid function arguments.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("evaluating query %q failed: %v", query, r)
+		}
+	}()
+
+	switch v := expr.Evaluate(node.Copy()).(type) {
+	case *xpath.NodeIterator:
+		if !v.MoveNext() {
+			return nil, nil
+		}
+		return v.Current().Value(), nil
+	case float64, bool, string:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported result %T of query %q", v, query)
+	}
+}
+
+func parseTimestamp(c Config, v interface{}) (time.Time, error) {
+	if c.TimestampFormat == "" {
+		return time.Time{}, fmt.Errorf("no timestamp_format")
+	}
+	if f, ok := v.(float64); ok && c.TimestampFormat != "unix" {
+		v = int64(f)
+	}
+	return internal.ParseTimestamp(c.TimestampFormat, v, c.Timezone)
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// guessType converts the value to an integer, float or boolean if possible.
+func guessType(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return s
+}
+
+// ParseLine parses a single XML document into a metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("no metric in line")
+	}
+	return metrics[0], nil
+}
+
+// SetDefaultTags sets the default tags for every metric.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
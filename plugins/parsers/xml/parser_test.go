@@ -0,0 +1,147 @@
+package xml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const ups = `<?xml version="1.0"?>
+<Status>
+  <Device serial="UPS-1234">
+    <Model>Smart-UPS 1500</Model>
+    <Timestamp>1583830800</Timestamp>
+    <Battery charge="98" online="true">
+      <Voltage>27.3</Voltage>
+      <Runtime>3540</Runtime>
+    </Battery>
+  </Device>
+  <Outlets>
+    <Outlet name="A" state="on"><Load>120.5</Load></Outlet>
+    <Outlet name="B" state="off"><Load>0</Load></Outlet>
+  </Outlets>
+</Status>
+`
+
+func newParser(t *testing.T, configs ...Config) *Parser {
+	p, err := New(configs, "ups", map[string]string{"source": "test"})
+	require.NoError(t, err)
+	p.TimeFunc = func() time.Time { return time.Unix(42, 0) }
+	return p
+}
+
+func TestParseFields(t *testing.T) {
+	p := newParser(t, Config{
+		Selection:       "/Status/Device",
+		Timestamp:       "Timestamp",
+		TimestampFormat: "unix",
+		Tags: map[string]string{
+			"serial": "@serial",
+			"model":  "Model",
+		},
+		Fields: map[string]string{
+			"charge":  "number(Battery/@charge)",
+			"online":  "Battery/@online = 'true'",
+			"voltage": "number(Battery/Voltage)",
+			"model":   "Model",
+			"missing": "Battery/Current",
+			"outlets": "count(/Status/Outlets/Outlet)",
+		},
+		FieldsInt: map[string]string{
+			"runtime": "Battery/Runtime",
+		},
+	})
+
+	metrics, err := p.Parse([]byte(ups))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("ups",
+			map[string]string{
+				"serial": "UPS-1234",
+				"model":  "Smart-UPS 1500",
+				"source": "test",
+			},
+			map[string]interface{}{
+				"charge":  98.0,
+				"online":  true,
+				"voltage": 27.3,
+				"model":   "Smart-UPS 1500",
+				"outlets": 2.0,
+				"runtime": int64(3540),
+			},
+			time.Unix(1583830800, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseSelection(t *testing.T) {
+	p := newParser(t, Config{
+		Selection:      "//Outlet",
+		MetricQuery:    "concat('outlet_', @state)",
+		Tags:           map[string]string{"outlet": "@name"},
+		FieldSelection: "*|@state",
+	})
+
+	metrics, err := p.Parse([]byte(ups))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("outlet_on",
+			map[string]string{"outlet": "A", "source": "test"},
+			map[string]interface{}{"Load": 120.5, "state": "on"},
+			time.Unix(42, 0)),
+		testutil.MustMetric("outlet_off",
+			map[string]string{"outlet": "B", "source": "test"},
+			map[string]interface{}{"Load": int64(0), "state": "off"},
+			time.Unix(42, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseMultipleConfigs(t *testing.T) {
+	p := newParser(t,
+		Config{
+			Selection: "/Status/Device/Battery",
+			Fields:    map[string]string{"charge": "number(@charge)"},
+		},
+		Config{
+			Selection:   "/Status/Outlets",
+			MetricQuery: "'outlets'",
+			Fields:      map[string]string{"load": "sum(Outlet/Load)"},
+		},
+	)
+
+	metrics, err := p.Parse([]byte(ups))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("ups",
+			map[string]string{"source": "test"},
+			map[string]interface{}{"charge": 98.0},
+			time.Unix(42, 0)),
+		testutil.MustMetric("outlets",
+			map[string]string{"source": "test"},
+			map[string]interface{}{"load": 120.5},
+			time.Unix(42, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := New(nil, "ups", nil)
+	require.Error(t, err)
+
+	_, err = New([]Config{{Selection: "//Outlet["}}, "ups", nil)
+	require.Error(t, err)
+
+	p := newParser(t, Config{FieldsInt: map[string]string{"model": "//Model"}})
+	_, err = p.Parse([]byte(ups))
+	require.Error(t, err)
+
+	_, err = p.Parse([]byte("<Status><Device></Status>"))
+	require.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package execd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon
+  ## eg: command = ["/path/to/your_program", "arg1", "arg2"]
+  command = ["cat"]
+
+  ## Delay before the process is restarted after an unexpected termination
+  restart_delay = "10s"
+
+  ## Data format used both to write metrics to the program and to read
+  ## metrics from it.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command      []string          `toml:"command"`
+	RestartDelay internal.Duration `toml:"restart_delay"`
+	Log          telegraf.Logger   `toml:"-"`
+
+	parser     parsers.Parser
+	serializer serializers.Serializer
+	acc        telegraf.Accumulator
+	process    *process.Process
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running processor plugin"
+}
+
+func (e *Execd) SetParser(parser parsers.Parser) {
+	e.parser = parser
+}
+
+func (e *Execd) SetSerializer(serializer serializers.Serializer) {
+	e.serializer = serializer
+}
+
+func (e *Execd) Start(acc telegraf.Accumulator) error {
+	e.acc = acc
+
+	var err error
+	e.process, err = process.New(e.Command)
+	if err != nil {
+		return fmt.Errorf("error creating new process: %v", err)
+	}
+	e.process.Log = e.Log
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.ReadStdoutFn = e.cmdReadOut
+	e.process.ReadStderrFn = e.cmdReadErr
+
+	if err = e.process.Start(); err != nil {
+		// if there was only one argument, and it contained spaces, warn the user
+		// that they may have configured it wrong.
+		if len(e.Command) == 1 && strings.ContainsAny(e.Command[0], " \t") {
+			e.Log.Warn("The processors.execd Command contained spaces but no arguments. " +
+				"This setting expects the program and arguments as an array of strings, " +
+				"not as a space-delimited string. See the plugin readme for an example.")
+		}
+		return fmt.Errorf("failed to start process %s: %v", e.Command, err)
+	}
+
+	return nil
+}
+
+// Add writes the metric to the process.  The metrics read back from the
+// process are new metrics, so the original is accepted once it is written.
+func (e *Execd) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	b, err := e.serializer.Serialize(m)
+	if err != nil {
+		m.Drop()
+		return fmt.Errorf("metric serializing error: %v", err)
+	}
+
+	_, err = e.process.Write(b)
+	if err != nil {
+		m.Drop()
+		return fmt.Errorf("error writing to process stdin: %v", err)
+	}
+
+	m.Accept()
+	return nil
+}
+
+func (e *Execd) Stop() error {
+	if e.process != nil {
+		e.process.Stop()
+	}
+	return nil
+}
+
+func (e *Execd) cmdReadOut(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		metrics, err := e.parser.Parse(scanner.Bytes())
+		if err != nil {
+			e.Log.Errorf("Parse error: %v", err)
+		}
+
+		for _, metric := range metrics {
+			e.acc.AddMetric(metric)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %v", err)
+	}
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stderr: %v", err)
+	}
+}
+
+func init() {
+	processors.AddStreaming("execd", func() telegraf.StreamingProcessor {
+		return &Execd{
+			RestartDelay: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
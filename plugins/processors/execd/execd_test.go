@@ -0,0 +1,43 @@
+// +build !windows
+
+package execd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecd_Passthrough(t *testing.T) {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	e := &Execd{
+		Command:      []string{"sed", "-u", "s/^cpu/proc_cpu/"},
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	e.SetParser(parser)
+	e.SetSerializer(influx.NewSerializer())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+
+	m := testutil.MustMetric("cpu", map[string]string{"host": "a"},
+		map[string]interface{}{"usage": 42.0}, time.Unix(1, 0))
+	require.NoError(t, e.Add(m, acc))
+
+	acc.Wait(1)
+	require.NoError(t, e.Stop())
+
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		testutil.MustMetric("proc_cpu", map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42.0}, time.Unix(1, 0)),
+	}, acc.GetTelegrafMetrics())
+}
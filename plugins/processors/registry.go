@@ -3,9 +3,18 @@ package processors
 import "github.com/influxdata/telegraf"
 
 type Creator func() telegraf.Processor
+type StreamingCreator func() telegraf.StreamingProcessor
 
 var Processors = map[string]Creator{}
 
+// StreamingProcessors contains the processors added with AddStreaming.
+var StreamingProcessors = map[string]StreamingCreator{}
+
 func Add(name string, creator Creator) {
 	Processors[name] = creator
 }
+
+// AddStreaming adds a telegraf.StreamingProcessor processor.
+func AddStreaming(name string, creator StreamingCreator) {
+	StreamingProcessors[name] = creator
+}
@@ -0,0 +1,101 @@
+package starlark
+
+import (
+	"github.com/influxdata/telegraf"
+	common "github.com/influxdata/telegraf/plugins/common/starlark"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const (
+	description  = "Process metrics using a Starlark script"
+	sampleConfig = `
+  ## The Starlark source can be set as a string in this configuration file, or
+  ## by referencing a file containing the script.  Only one source or script
+  ## should be set at once.
+  ##
+  ## Source of the Starlark script.
+  source = '''
+def apply(metric):
+  return metric
+'''
+
+  ## File containing a Starlark script.
+  # script = "/usr/local/bin/myscript.star"
+`
+)
+
+type Starlark struct {
+	common.Common
+}
+
+func (s *Starlark) Description() string {
+	return description
+}
+
+func (s *Starlark) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Starlark) Init() error {
+	if err := s.Common.Init(); err != nil {
+		return err
+	}
+	return s.AddFunction("apply", 1)
+}
+
+func (s *Starlark) Start(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (s *Starlark) Add(metric telegraf.Metric, acc telegraf.Accumulator) error {
+	rv, err := s.Call("apply", common.NewMetric(metric))
+	if err != nil {
+		s.Log.Errorf("Error calling apply: %v", err)
+		metric.Reject()
+		return nil
+	}
+
+	results, err := common.Metrics(rv)
+	if err != nil {
+		s.Log.Errorf("Error in apply: %v", err)
+		metric.Reject()
+		return nil
+	}
+
+	// A metric returned more than once is copied, so that each metric passed
+	// on is independent.  The copies are made before any metric is passed
+	// on, since it may be modified downstream.
+	kept := false
+	seen := make(map[telegraf.Metric]bool, len(results))
+	metrics := make([]telegraf.Metric, 0, len(results))
+	for _, m := range results {
+		unwrapped := m.Unwrap()
+		if seen[unwrapped] {
+			unwrapped = unwrapped.Copy()
+		}
+		seen[unwrapped] = true
+
+		if unwrapped == metric {
+			kept = true
+		}
+		metrics = append(metrics, unwrapped)
+	}
+
+	if !kept {
+		metric.Drop()
+	}
+	for _, m := range metrics {
+		acc.AddMetric(m)
+	}
+	return nil
+}
+
+func (s *Starlark) Stop() error {
+	return nil
+}
+
+func init() {
+	processors.AddStreaming("starlark", func() telegraf.StreamingProcessor {
+		return &Starlark{}
+	})
+}
@@ -0,0 +1,354 @@
+package starlark
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newStarlarkFromSource(source string) *Starlark {
+	s := &Starlark{}
+	s.Source = source
+	s.Log = testutil.Logger{}
+	return s
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{name: "no source"},
+		{
+			name:   "syntax error",
+			source: "def apply(metric):\n  return metric +",
+		},
+		{
+			name:   "apply not defined",
+			source: "def process(metric):\n  return metric",
+		},
+		{
+			name:   "apply not a function",
+			source: "apply = 42",
+		},
+		{
+			name:   "apply with wrong arguments",
+			source: "def apply():\n  pass",
+		},
+		{
+			name:   "load",
+			source: "load('module.star', 'x')\ndef apply(metric):\n  return metric",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newStarlarkFromSource(tt.source)
+			require.Error(t, plugin.Init())
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		input    []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "passthrough",
+			source: `
+def apply(metric):
+  return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"time_idle": 42},
+					time.Unix(0, 0)),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"time_idle": 42},
+					time.Unix(0, 0)),
+			},
+		},
+		{
+			name: "drop",
+			source: `
+def apply(metric):
+  if metric.fields["value"] > 10:
+    return None
+  return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 2},
+					time.Unix(0, 0)),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 2},
+					time.Unix(0, 0)),
+			},
+		},
+		{
+			name: "modify name, tags, fields and time",
+			source: `
+def apply(metric):
+  metric.name = metric.name + "_total"
+  metric.tags["env"] = metric.tags.pop("environment")
+  metric.tags.update(region="us-east-1")
+  for k, v in metric.fields.items():
+    metric.fields[k] = v * 2
+  metric.fields["ok"] = "errors" not in metric.fields
+  metric.fields["ratio"] = 0.5
+  metric.fields["state"] = "running"
+  metric.time = metric.time + 1000000000
+  return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"environment": "prod"},
+					map[string]interface{}{"value": 21},
+					time.Unix(0, 0)),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu_total",
+					map[string]string{
+						"env":    "prod",
+						"region": "us-east-1",
+					},
+					map[string]interface{}{
+						"value": 42,
+						"ok":    true,
+						"ratio": 0.5,
+						"state": "running",
+					},
+					time.Unix(1, 0)),
+			},
+		},
+		{
+			name: "replace tags and fields",
+			source: `
+def apply(metric):
+  metric.tags = {"a": "b"}
+  metric.fields = {"x": 1}
+  return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"a": "b"},
+					map[string]interface{}{"x": 1},
+					time.Unix(0, 0)),
+			},
+		},
+		{
+			name: "emit new metrics",
+			source: `
+def apply(metric):
+  metrics = []
+  for k, v in metric.fields.items():
+    m = Metric(metric.name + "_" + k)
+    m.tags.update(metric.tags)
+    m.fields["value"] = v
+    m.time = metric.time
+    metrics.append(m)
+  return metrics
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"cpu": "cpu0"},
+					map[string]interface{}{
+						"idle":   42,
+						"system": 2.5,
+					},
+					time.Unix(0, 0)),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu_idle",
+					map[string]string{"cpu": "cpu0"},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+				testutil.MustMetric("cpu_system",
+					map[string]string{"cpu": "cpu0"},
+					map[string]interface{}{"value": 2.5},
+					time.Unix(0, 0)),
+			},
+		},
+		{
+			name: "return metric twice",
+			source: `
+def apply(metric):
+  return [metric, metric]
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+			},
+		},
+		{
+			name: "deepcopy",
+			source: `
+def apply(metric):
+  copy = deepcopy(metric)
+  copy.name = "copy"
+  return [metric, copy]
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+				testutil.MustMetric("copy",
+					map[string]string{},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+			},
+		},
+		{
+			name: "state is kept between calls",
+			source: `
+def apply(metric):
+  last = state.get("last")
+  state["last"] = metric.fields["value"]
+  if last != None:
+    metric.fields["delta"] = metric.fields["value"] - last
+  return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 40},
+					time.Unix(0, 0)),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42},
+					time.Unix(10, 0)),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 40},
+					time.Unix(0, 0)),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42, "delta": 2},
+					time.Unix(10, 0)),
+			},
+		},
+		{
+			name: "runtime error rejects the metric",
+			source: `
+def apply(metric):
+  metric.tags["value"] = metric.fields["value"]
+  return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+			},
+		},
+		{
+			name: "modify during iteration",
+			source: `
+def apply(metric):
+  for k in metric.tags:
+    metric.tags.pop(k)
+  return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{"value": 42},
+					time.Unix(0, 0)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newStarlarkFromSource(tt.source)
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			for _, m := range tt.input {
+				require.NoError(t, plugin.Add(m, &acc))
+			}
+			require.NoError(t, plugin.Stop())
+
+			testutil.RequireMetricsEqual(t, tt.expected, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestScript(t *testing.T) {
+	f, err := ioutil.TempFile("", "starlark")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(`
+def apply(metric):
+  metric.tags["script"] = "true"
+  return metric
+`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	plugin := &Starlark{}
+	plugin.Script = f.Name()
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	require.NoError(t, plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0)), &acc))
+
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"script": "true"},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0)),
+	}, acc.GetTelegrafMetrics())
+}
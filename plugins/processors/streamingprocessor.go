@@ -0,0 +1,56 @@
+package processors
+
+import (
+	"github.com/influxdata/telegraf"
+)
+
+// NewStreamingProcessorFromProcessor is a converter that turns a standard
+// processor into a streaming processor.
+func NewStreamingProcessorFromProcessor(p telegraf.Processor) telegraf.StreamingProcessor {
+	return &streamingProcessor{
+		processor: p,
+	}
+}
+
+// streamingProcessor passes each metric through the Apply function of the
+// wrapped processor.
+type streamingProcessor struct {
+	processor telegraf.Processor
+}
+
+func (sp *streamingProcessor) SampleConfig() string {
+	return sp.processor.SampleConfig()
+}
+
+func (sp *streamingProcessor) Description() string {
+	return sp.processor.Description()
+}
+
+func (sp *streamingProcessor) Start(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (sp *streamingProcessor) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	for _, m := range sp.processor.Apply(m) {
+		acc.AddMetric(m)
+	}
+	return nil
+}
+
+func (sp *streamingProcessor) Stop() error {
+	return nil
+}
+
+// Init calls Init on the wrapped processor if it is a telegraf.Initializer.
+func (sp *streamingProcessor) Init() error {
+	if p, ok := sp.processor.(telegraf.Initializer); ok {
+		return p.Init()
+	}
+	return nil
+}
+
+// Unwrap returns the wrapped processor, it is used to configure the
+// processor and set its logger.
+func (sp *streamingProcessor) Unwrap() telegraf.Processor {
+	return sp.processor
+}
@@ -0,0 +1,194 @@
+package file
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, used to reference secrets as
+  ## "@{<id>:<key>}" in the configuration of other plugins.
+  id = "local"
+
+  ## Path of the encrypted secrets file.  Secrets are added to the file with
+  ## "telegraf secrets set <id> <key>".
+  path = "/etc/telegraf/secrets.enc"
+
+  ## Encryption key as 64 hexadecimal characters, such as generated by
+  ## "openssl rand -hex 32".  Either key or key_file must be set; avoid
+  ## writing the key into the configuration by using an environment variable.
+  # key = "${TELEGRAF_SECRETS_KEY}"
+  # key_file = "/etc/telegraf/secrets.key"
+`
+
+// magic identifies the file format, it is also used as the additional data
+// for the encryption.
+var magic = []byte("TGSECRETS1")
+
+type File struct {
+	ID      string `toml:"id"`
+	Path    string `toml:"path"`
+	Key     string `toml:"key"`
+	KeyFile string `toml:"key_file"`
+
+	aead    cipher.AEAD
+	secrets map[string]string
+}
+
+func (f *File) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *File) Description() string {
+	return "Read secrets from a file encrypted with AES-256-GCM"
+}
+
+func (f *File) Init() error {
+	if f.Path == "" {
+		return errors.New("path must be set")
+	}
+
+	key := f.Key
+	if f.KeyFile != "" {
+		if key != "" {
+			return errors.New("only one of key or key_file can be set")
+		}
+		b, err := ioutil.ReadFile(f.KeyFile)
+		if err != nil {
+			return fmt.Errorf("reading key file: %v", err)
+		}
+		key = string(b)
+	}
+	if key == "" {
+		return errors.New("key or key_file must be set")
+	}
+
+	b, err := hex.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(b) != 32 {
+		return errors.New("key must be 32 bytes encoded as 64 hexadecimal characters")
+	}
+
+	block, err := aes.NewCipher(b)
+	if err != nil {
+		return err
+	}
+	f.aead, err = cipher.NewGCM(block)
+	return err
+}
+
+// Get returns the secret with the given key, the file is read on first use.
+func (f *File) Get(key string) (string, error) {
+	if f.secrets == nil {
+		secrets, err := f.read()
+		if err != nil {
+			return "", err
+		}
+		f.secrets = secrets
+	}
+
+	value, ok := f.secrets[key]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found in %s", key, f.Path)
+	}
+	return value, nil
+}
+
+// Set adds the secret to the file, creating it if it does not exist.
+func (f *File) Set(key, value string) error {
+	secrets, err := f.read()
+	if os.IsNotExist(err) {
+		secrets = make(map[string]string)
+	} else if err != nil {
+		return err
+	}
+	secrets[key] = value
+
+	if err := f.write(secrets); err != nil {
+		return err
+	}
+	f.secrets = secrets
+	return nil
+}
+
+func (f *File) read() (map[string]string, error) {
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(data, magic) {
+		return nil, fmt.Errorf("%s is not a secrets file", f.Path)
+	}
+	data = data[len(magic):]
+
+	size := f.aead.NonceSize()
+	if len(data) < size {
+		return nil, fmt.Errorf("%s is truncated", f.Path)
+	}
+	plaintext, err := f.aead.Open(nil, data[:size], data[size:], magic)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: wrong key or corrupted file", f.Path)
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("decoding %s: %v", f.Path, err)
+	}
+	return secrets, nil
+}
+
+func (f *File) write(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	data := append([]byte{}, magic...)
+	data = append(data, nonce...)
+	data = f.aead.Seal(data, nonce, plaintext, magic)
+
+	// Replace the file atomically so that a running Telegraf never reads a
+	// partially written file.
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+func init() {
+	secretstores.Add("file", func() telegraf.SecretStore {
+		return &File{}
+	})
+}
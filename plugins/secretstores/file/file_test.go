@@ -0,0 +1,68 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestSetGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "secrets.enc")
+
+	f := &File{Path: path, Key: testKey}
+	require.NoError(t, f.Init())
+	require.NoError(t, f.Set("password", "hunter2"))
+	require.NoError(t, f.Set("token", "abc"))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "hunter2")
+
+	f = &File{Path: path, Key: testKey}
+	require.NoError(t, f.Init())
+
+	value, err := f.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", value)
+
+	value, err = f.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "abc", value)
+
+	_, err = f.Get("missing")
+	require.Error(t, err)
+}
+
+func TestWrongKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "secrets.enc")
+
+	f := &File{Path: path, Key: testKey}
+	require.NoError(t, f.Init())
+	require.NoError(t, f.Set("password", "hunter2"))
+
+	f = &File{Path: path, Key: "ff" + testKey[2:]}
+	require.NoError(t, f.Init())
+	_, err = f.Get("password")
+	require.Error(t, err)
+}
+
+func TestInvalidKey(t *testing.T) {
+	f := &File{Path: "secrets.enc", Key: "abcd"}
+	require.Error(t, f.Init())
+
+	f = &File{Path: "secrets.enc"}
+	require.Error(t, f.Init())
+}
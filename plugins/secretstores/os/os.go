@@ -0,0 +1,115 @@
+package os
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, used to reference secrets as
+  ## "@{<id>:<key>}" in the configuration of other plugins.
+  id = "keyring"
+
+  ## Name of the service the secrets belong to.
+  # service = "telegraf"
+
+  ## Directory holding the keyring, each secret is stored in the file
+  ## <keyring_dir>/<service>/<key>.  Secrets are added with
+  ## "telegraf secrets set <id> <key>".
+  # keyring_dir = "/etc/telegraf/keyring"
+`
+
+// OS reads secrets from a keyring local to the host.
+//
+// The keyring is a directory with one file per secret that must only be
+// accessible by its owner, standing in for the keyring services of the
+// operating systems so that no system libraries are required.
+type OS struct {
+	ID         string `toml:"id"`
+	Service    string `toml:"service"`
+	KeyringDir string `toml:"keyring_dir"`
+}
+
+func (o *OS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *OS) Description() string {
+	return "Read secrets from the local keyring"
+}
+
+func (o *OS) Init() error {
+	if o.Service == "" || !validName(o.Service) {
+		return fmt.Errorf("invalid service %q", o.Service)
+	}
+	if o.KeyringDir == "" {
+		return errors.New("keyring_dir must be set")
+	}
+	return nil
+}
+
+func (o *OS) Get(key string) (string, error) {
+	path, err := o.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("secret %q not found in keyring", key)
+	} else if err != nil {
+		return "", err
+	}
+	if err := checkPermissions(fi); err != nil {
+		return "", fmt.Errorf("secret %q: %v", key, err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// Set stores the secret in the keyring, creating the directories as needed.
+func (o *OS) Set(key, value string) error {
+	path, err := o.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(value), 0600)
+}
+
+func (o *OS) path(key string) (string, error) {
+	if !validName(key) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(o.KeyringDir, o.Service, key), nil
+}
+
+// validName returns true if the name can be used as a file name without
+// escaping the keyring directory.
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." &&
+		!strings.ContainsAny(name, `/\`)
+}
+
+func init() {
+	secretstores.Add("os", func() telegraf.SecretStore {
+		return &OS{
+			Service:    "telegraf",
+			KeyringDir: defaultKeyringDir,
+		}
+	})
+}
@@ -0,0 +1,19 @@
+// +build !windows
+
+package os
+
+import (
+	"fmt"
+	"os"
+)
+
+const defaultKeyringDir = "/etc/telegraf/keyring"
+
+// checkPermissions returns an error if the secret can be accessed by users
+// other than its owner.
+func checkPermissions(fi os.FileInfo) error {
+	if fi.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("permissions %v are too open, the file must only be accessible by its owner", fi.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// +build !windows
+
+package os
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-keyring")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	o := &OS{Service: "telegraf", KeyringDir: dir}
+	require.NoError(t, o.Init())
+	require.NoError(t, o.Set("password", "hunter2"))
+
+	value, err := o.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", value)
+
+	_, err = o.Get("missing")
+	require.Error(t, err)
+}
+
+func TestPermissions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-keyring")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	o := &OS{Service: "telegraf", KeyringDir: dir}
+	require.NoError(t, o.Init())
+	require.NoError(t, o.Set("password", "hunter2"))
+	require.NoError(t, os.Chmod(filepath.Join(dir, "telegraf", "password"), 0644))
+
+	_, err = o.Get("password")
+	require.Error(t, err)
+}
+
+func TestInvalidKey(t *testing.T) {
+	o := &OS{Service: "telegraf", KeyringDir: "/nonexistent"}
+	require.NoError(t, o.Init())
+
+	_, err := o.Get("../password")
+	require.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, used to reference secrets as
+  ## "@{<id>:<key>}" in the configuration of other plugins.
+  id = "vault"
+
+  ## Address of the Vault server.
+  url = "https://127.0.0.1:8200"
+
+  ## Mount path and path of the secret in a key/value secrets engine, each key
+  ## of the secret can be referenced.
+  # mount_path = "secret"
+  # secret_path = "telegraf"
+
+  ## Version of the key/value secrets engine, 1 or 2.
+  # kv_version = 2
+
+  ## Token used to authenticate, either token or token_file must be set.
+  # token = "${VAULT_TOKEN}"
+  # token_file = "/etc/telegraf/vault-token"
+
+  ## Vault Enterprise namespace.
+  # namespace = ""
+
+  ## Timeout for HTTP requests.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type Vault struct {
+	ID         string            `toml:"id"`
+	URL        string            `toml:"url"`
+	MountPath  string            `toml:"mount_path"`
+	SecretPath string            `toml:"secret_path"`
+	KVVersion  int               `toml:"kv_version"`
+	Token      string            `toml:"token"`
+	TokenFile  string            `toml:"token_file"`
+	Namespace  string            `toml:"namespace"`
+	Timeout    internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client  *http.Client
+	secrets map[string]string
+}
+
+func (v *Vault) SampleConfig() string {
+	return sampleConfig
+}
+
+func (v *Vault) Description() string {
+	return "Read secrets from a HashiCorp Vault key/value secrets engine"
+}
+
+func (v *Vault) Init() error {
+	if v.URL == "" {
+		return errors.New("url must be set")
+	}
+	if v.KVVersion != 1 && v.KVVersion != 2 {
+		return fmt.Errorf("invalid kv_version %d", v.KVVersion)
+	}
+	if v.Token != "" && v.TokenFile != "" {
+		return errors.New("only one of token or token_file can be set")
+	}
+	if v.TokenFile != "" {
+		b, err := ioutil.ReadFile(v.TokenFile)
+		if err != nil {
+			return fmt.Errorf("reading token file: %v", err)
+		}
+		v.Token = strings.TrimSpace(string(b))
+	}
+	if v.Token == "" {
+		return errors.New("token or token_file must be set")
+	}
+
+	tlsCfg, err := v.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	v.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: v.Timeout.Duration,
+	}
+	return nil
+}
+
+// Get returns the secret with the given key, the secret is requested from
+// Vault on first use.
+func (v *Vault) Get(key string) (string, error) {
+	if v.secrets == nil {
+		secrets, err := v.fetch()
+		if err != nil {
+			return "", err
+		}
+		v.secrets = secrets
+	}
+
+	value, ok := v.secrets[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret %s", key, v.SecretPath)
+	}
+	return value, nil
+}
+
+func (v *Vault) fetch() (map[string]string, error) {
+	u := strings.TrimRight(v.URL, "/") + "/v1/" + strings.Trim(v.MountPath, "/")
+	if v.KVVersion == 2 {
+		u += "/data"
+	}
+	u += "/" + strings.Trim(v.SecretPath, "/")
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading secret %s: %s", v.SecretPath, resp.Status)
+	}
+
+	var data map[string]interface{}
+	if v.KVVersion == 2 {
+		var body struct {
+			Data struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("decoding secret %s: %v", v.SecretPath, err)
+		}
+		data = body.Data.Data
+	} else {
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("decoding secret %s: %v", v.SecretPath, err)
+		}
+		data = body.Data
+	}
+
+	secrets := make(map[string]string, len(data))
+	for k, value := range data {
+		switch value := value.(type) {
+		case string:
+			secrets[k] = value
+		default:
+			b, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			secrets[k] = string(b)
+		}
+	}
+	return secrets, nil
+}
+
+func init() {
+	secretstores.Add("vault", func() telegraf.SecretStore {
+		return &Vault{
+			MountPath:  "secret",
+			SecretPath: "telegraf",
+			KVVersion:  2,
+			Timeout:    internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
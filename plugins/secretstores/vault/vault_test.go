@@ -0,0 +1,82 @@
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "/v1/secret/data/telegraf", r.URL.Path)
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"password":"hunter2","port":8086}}}`))
+	}))
+	defer ts.Close()
+
+	v := &Vault{
+		URL:        ts.URL,
+		MountPath:  "secret",
+		SecretPath: "telegraf",
+		KVVersion:  2,
+		Token:      "s.token",
+		Timeout:    internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, v.Init())
+
+	value, err := v.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", value)
+
+	value, err = v.Get("port")
+	require.NoError(t, err)
+	require.Equal(t, "8086", value)
+
+	_, err = v.Get("missing")
+	require.Error(t, err)
+
+	require.Equal(t, 1, requests)
+}
+
+func TestGet_KVVersion1(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/kv/telegraf/influxdb", r.URL.Path)
+		w.Write([]byte(`{"data":{"password":"hunter2"}}`))
+	}))
+	defer ts.Close()
+
+	v := &Vault{
+		URL:        ts.URL + "/",
+		MountPath:  "kv",
+		SecretPath: "telegraf/influxdb",
+		KVVersion:  1,
+		Token:      "s.token",
+	}
+	require.NoError(t, v.Init())
+
+	value, err := v.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", value)
+}
+
+func TestGet_Forbidden(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	v := &Vault{URL: ts.URL, KVVersion: 2, Token: "wrong"}
+	require.NoError(t, v.Init())
+
+	_, err := v.Get("password")
+	require.Error(t, err)
+}
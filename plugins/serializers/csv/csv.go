@@ -0,0 +1,362 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	columnTimestamp   = "timestamp"
+	columnMeasurement = "measurement"
+	columnField       = "field"
+	columnValue       = "value"
+	prefixTag         = "tag."
+	prefixField       = "field."
+)
+
+type Config struct {
+	// Columns are the columns in order, "timestamp", "measurement",
+	// "tag.<key>", "field.<key>" when pivoting, else "field" and "value".
+	// By default the columns of the tags and fields of each measurement.
+	Columns []string
+	// Separator separates the columns, by default a comma.
+	Separator string
+	// Header enables the header row with the column names.
+	Header bool
+	// TimestampFormat is "unix", "unix_ms", "unix_us", "unix_ns" or a Go
+	// time layout.
+	TimestampFormat string
+	// Pivot writes a row per measurement, tags and timestamp with a column
+	// per field, instead of a row per field.
+	Pivot bool
+}
+
+type Serializer struct {
+	config    Config
+	separator rune
+	// columns are the configured columns, used for all metrics.
+	columns []string
+	// layouts are the columns by measurement without configured columns,
+	// replaced as new tags and fields appear.
+	layouts map[string][]string
+	// written are the columns of the last row written.
+	written []string
+}
+
+func NewSerializer(config Config) (*Serializer, error) {
+	s := &Serializer{
+		config:    config,
+		separator: ',',
+		layouts:   make(map[string][]string),
+	}
+
+	if config.Separator != "" {
+		r, size := utf8.DecodeRuneInString(config.Separator)
+		if size != len(config.Separator) || r == utf8.RuneError ||
+			r == '\r' || r == '\n' || r == '"' {
+			return nil, fmt.Errorf("invalid csv_separator %q", config.Separator)
+		}
+		s.separator = r
+	}
+
+	if s.config.TimestampFormat == "" {
+		s.config.TimestampFormat = "unix"
+	}
+
+	for _, column := range config.Columns {
+		if err := s.checkColumn(column); err != nil {
+			return nil, err
+		}
+	}
+	if len(config.Columns) > 0 {
+		s.columns = config.Columns
+	}
+
+	return s, nil
+}
+
+func (s *Serializer) checkColumn(column string) error {
+	switch {
+	case column == columnTimestamp, column == columnMeasurement:
+	case strings.HasPrefix(column, prefixTag) && len(column) > len(prefixTag):
+	case s.config.Pivot && strings.HasPrefix(column, prefixField) && len(column) > len(prefixField):
+	case !s.config.Pivot && (column == columnField || column == columnValue):
+	default:
+		return fmt.Errorf("invalid csv column %q", column)
+	}
+	return nil
+}
+
+// SerializeHeader returns the header row of the first metric, if enabled.
+func (s *Serializer) SerializeHeader(metrics []telegraf.Metric) ([]byte, error) {
+	if !s.config.Header || len(metrics) == 0 {
+		return nil, nil
+	}
+
+	for _, metric := range metrics {
+		s.addColumns(metric)
+	}
+	return s.write([][]string{header(s.columnsOf(metrics[0]))})
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch returns the rows of the metrics.  With the header enabled a
+// header row is written before the rows whose columns differ from the
+// previous row, the header of the first row is left to SerializeHeader.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	for _, metric := range metrics {
+		s.addColumns(metric)
+	}
+
+	var records [][]string
+	addRecord := func(columns []string, record []string) {
+		if s.config.Header && !equal(columns, s.written) {
+			if s.written != nil {
+				records = append(records, header(columns))
+			}
+			s.written = columns
+		}
+		records = append(records, record)
+	}
+
+	if s.config.Pivot {
+		for _, row := range pivot(metrics) {
+			columns := s.columnsOf(row.metric)
+			addRecord(columns, s.record(columns, row.metric, row.fields, "", nil))
+		}
+	} else {
+		for _, metric := range metrics {
+			columns := s.columnsOf(metric)
+			for _, field := range sortedFields(metric) {
+				addRecord(columns, s.record(columns, metric, nil, field.Key, field.Value))
+			}
+		}
+	}
+
+	return s.write(records)
+}
+
+// columnsOf returns the columns of the rows of the metric.
+func (s *Serializer) columnsOf(metric telegraf.Metric) []string {
+	if len(s.columns) > 0 {
+		return s.columns
+	}
+	return s.layouts[metric.Name()]
+}
+
+// addColumns adds the columns of the tags and fields of the metric missing
+// from the columns of its measurement, unless the columns are configured.
+// New tags are added after the other tags and new fields at the end, in
+// alphabetical order.
+func (s *Serializer) addColumns(metric telegraf.Metric) {
+	if len(s.columns) > 0 {
+		return
+	}
+
+	columns, ok := s.layouts[metric.Name()]
+	if !ok {
+		columns = []string{columnTimestamp, columnMeasurement}
+		if !s.config.Pivot {
+			columns = append(columns, columnField, columnValue)
+		}
+	}
+
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+	var tags, fields []string
+	for _, tag := range metric.TagList() {
+		if !known[prefixTag+tag.Key] {
+			tags = append(tags, prefixTag+tag.Key)
+		}
+	}
+	if s.config.Pivot {
+		for _, field := range metric.FieldList() {
+			if !known[prefixField+field.Key] {
+				fields = append(fields, prefixField+field.Key)
+			}
+		}
+	}
+	if ok && len(tags) == 0 && len(fields) == 0 {
+		return
+	}
+	sort.Strings(tags)
+	sort.Strings(fields)
+
+	// The columns are replaced rather than modified, so that the columns of
+	// the rows written can be compared.
+	updated := make([]string, 0, len(columns)+len(tags)+len(fields))
+	i := 0
+	for i < len(columns) && !isValueColumn(columns[i]) {
+		i++
+	}
+	updated = append(updated, columns[:i]...)
+	updated = append(updated, tags...)
+	updated = append(updated, columns[i:]...)
+	updated = append(updated, fields...)
+	s.layouts[metric.Name()] = updated
+}
+
+// isValueColumn returns true for the columns following the tag columns.
+func isValueColumn(column string) bool {
+	return column == columnField || column == columnValue ||
+		strings.HasPrefix(column, prefixField)
+}
+
+// header returns the header row of the columns, the tag and field keys
+// without prefix.
+func header(columns []string) []string {
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		switch {
+		case strings.HasPrefix(column, prefixTag):
+			names = append(names, strings.TrimPrefix(column, prefixTag))
+		case strings.HasPrefix(column, prefixField):
+			names = append(names, strings.TrimPrefix(column, prefixField))
+		default:
+			names = append(names, column)
+		}
+	}
+	return names
+}
+
+// record returns the row of the metric in the columns, with the fields of a
+// pivoted row or else the field key and value.
+func (s *Serializer) record(
+	columns []string,
+	metric telegraf.Metric,
+	fields map[string]interface{},
+	key string,
+	value interface{},
+) []string {
+	record := make([]string, 0, len(columns))
+	for _, column := range columns {
+		switch {
+		case column == columnTimestamp:
+			record = append(record, s.formatTimestamp(metric.Time()))
+		case column == columnMeasurement:
+			record = append(record, metric.Name())
+		case column == columnField:
+			record = append(record, key)
+		case column == columnValue:
+			record = append(record, formatValue(value))
+		case strings.HasPrefix(column, prefixTag):
+			v, _ := metric.GetTag(strings.TrimPrefix(column, prefixTag))
+			record = append(record, v)
+		case strings.HasPrefix(column, prefixField):
+			record = append(record, formatValue(fields[strings.TrimPrefix(column, prefixField)]))
+		}
+	}
+	return record
+}
+
+func (s *Serializer) write(records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = s.separator
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Serializer) formatTimestamp(t time.Time) string {
+	switch s.config.TimestampFormat {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unix_ms":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case "unix_us":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Microsecond), 10)
+	case "unix_ns":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	default:
+		return t.UTC().Format(s.config.TimestampFormat)
+	}
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// row is a pivoted row, the fields of the metrics of a series at a time.
+type row struct {
+	metric telegraf.Metric
+	fields map[string]interface{}
+}
+
+// pivot merges the fields of the metrics with the same measurement, tags and
+// timestamp into rows, in the order of the metrics.
+func pivot(metrics []telegraf.Metric) []*row {
+	type key struct {
+		id   uint64
+		time int64
+	}
+
+	var rows []*row
+	index := make(map[key]*row)
+	for _, metric := range metrics {
+		k := key{id: metric.HashID(), time: metric.Time().UnixNano()}
+		r, ok := index[k]
+		if !ok {
+			r = &row{
+				metric: metric,
+				fields: make(map[string]interface{}),
+			}
+			index[k] = r
+			rows = append(rows, r)
+		}
+		for _, field := range metric.FieldList() {
+			r.fields[field.Key] = field.Value
+		}
+	}
+	return rows
+}
+
+func sortedFields(metric telegraf.Metric) []*telegraf.Field {
+	fields := make([]*telegraf.Field, len(metric.FieldList()))
+	copy(fields, metric.FieldList())
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+	return fields
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
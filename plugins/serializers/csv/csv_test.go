@@ -0,0 +1,148 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func metrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "a", "cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 98.5, "usage_user": 1.5},
+			time.Unix(1574317740, 0),
+		),
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "a", "cpu": "cpu0"},
+			map[string]interface{}{"running": true},
+			time.Unix(1574317740, 0),
+		),
+		testutil.MustMetric(
+			"disk",
+			map[string]string{"host": "b", "path": "/"},
+			map[string]interface{}{"used": int64(42), "fstype": "ext4, rw"},
+			time.Unix(1574317750, 0),
+		),
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		header   string
+		expected string
+	}{
+		{
+			name:   "rows per field",
+			config: Config{Header: true},
+			header: "timestamp,measurement,cpu,host,field,value\n",
+			expected: "1574317740,cpu,cpu0,a,usage_idle,98.5\n" +
+				"1574317740,cpu,cpu0,a,usage_user,1.5\n" +
+				"1574317740,cpu,cpu0,a,running,true\n" +
+				"timestamp,measurement,host,path,field,value\n" +
+				"1574317750,disk,b,/,fstype,\"ext4, rw\"\n" +
+				"1574317750,disk,b,/,used,42\n",
+		},
+		{
+			name: "pivot",
+			config: Config{
+				Header: true,
+				Pivot:  true,
+			},
+			header: "timestamp,measurement,cpu,host,usage_idle,usage_user,running\n",
+			expected: "1574317740,cpu,cpu0,a,98.5,1.5,true\n" +
+				"timestamp,measurement,host,path,fstype,used\n" +
+				"1574317750,disk,b,/,\"ext4, rw\",42\n",
+		},
+		{
+			name: "columns",
+			config: Config{
+				Columns:         []string{"measurement", "tag.host", "field.usage_idle", "timestamp"},
+				Header:          true,
+				Pivot:           true,
+				Separator:       ";",
+				TimestampFormat: "2006-01-02T15:04:05Z07:00",
+			},
+			header: "measurement;host;usage_idle;timestamp\n",
+			expected: "cpu;a;98.5;2019-11-21T06:29:00Z\n" +
+				"disk;b;;2019-11-21T06:29:10Z\n",
+		},
+		{
+			name: "no header",
+			config: Config{
+				Columns:         []string{"timestamp", "field", "value"},
+				TimestampFormat: "unix_ms",
+			},
+			expected: "1574317740000,usage_idle,98.5\n" +
+				"1574317740000,usage_user,1.5\n" +
+				"1574317740000,running,true\n" +
+				"1574317750000,fstype,\"ext4, rw\"\n" +
+				"1574317750000,used,42\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.config)
+			require.NoError(t, err)
+
+			header, err := s.SerializeHeader(metrics())
+			require.NoError(t, err)
+			require.Equal(t, tt.header, string(header))
+
+			actual, err := s.SerializeBatch(metrics())
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
+
+// TestSerializeNewColumns checks that the tags and fields appearing in later
+// metrics are added as columns, with a new header row.
+func TestSerializeNewColumns(t *testing.T) {
+	s, err := NewSerializer(Config{Header: true, Pivot: true})
+	require.NoError(t, err)
+
+	ms := metrics()
+	header, err := s.SerializeHeader(ms[:1])
+	require.NoError(t, err)
+	require.Equal(t, "timestamp,measurement,cpu,host,usage_idle,usage_user\n", string(header))
+	actual, err := s.Serialize(ms[0])
+	require.NoError(t, err)
+	require.Equal(t, "1574317740,cpu,cpu0,a,98.5,1.5\n", string(actual))
+
+	actual, err = s.Serialize(testutil.MustMetric(
+		"cpu",
+		map[string]string{"host": "a", "cpu": "cpu0", "region": "x"},
+		map[string]interface{}{"usage_idle": 97.0, "usage_system": 2.0},
+		time.Unix(1574317800, 0),
+	))
+	require.NoError(t, err)
+	require.Equal(t, "timestamp,measurement,cpu,host,region,usage_idle,usage_user,usage_system\n"+
+		"1574317800,cpu,cpu0,a,x,97,,2\n", string(actual))
+
+	// The columns did not change since the last row.
+	actual, err = s.Serialize(ms[0])
+	require.NoError(t, err)
+	require.Equal(t, "1574317740,cpu,cpu0,a,,98.5,1.5,\n", string(actual))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	configs := []Config{
+		{Separator: "\n"},
+		{Separator: ";;"},
+		{Columns: []string{"host"}},
+		{Columns: []string{"field.usage_idle"}},
+		{Columns: []string{"value"}, Pivot: true},
+	}
+	for _, config := range configs {
+		_, err := NewSerializer(config)
+		require.Error(t, err)
+	}
+}
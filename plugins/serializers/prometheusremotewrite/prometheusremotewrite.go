@@ -0,0 +1,181 @@
+package prometheusremotewrite
+
+import (
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/prompb"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+)
+
+type FormatConfig struct {
+	MetricSortOrder prometheus.MetricSortOrder
+	StringHandling  prometheus.StringHandling
+}
+
+// Serializer serializes metrics into the body of a Prometheus remote write
+// request, a snappy compressed WriteRequest.  Metrics are named and labeled
+// as by the prometheus serializer.
+type Serializer struct {
+	config FormatConfig
+}
+
+func NewSerializer(config FormatConfig) (*Serializer, error) {
+	s := &Serializer{config: config}
+	return s, nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	series := make(map[uint64]*prompb.TimeSeries)
+	var keys []uint64
+	for _, metric := range metrics {
+		labels := s.createLabels(metric)
+		timestamp := metric.Time().UnixNano() / int64(time.Millisecond)
+		for _, field := range metric.FieldList() {
+			// Histograms and summaries are written as their series, so the
+			// "_bucket", "_sum" and "_count" suffixes are kept.
+			metricName := prometheus.MetricName(metric.Name(), field.Key, telegraf.Untyped)
+			metricName, ok := prometheus.SanitizeMetricName(metricName)
+			if !ok {
+				continue
+			}
+
+			value, ok := prometheus.SampleValue(field.Value)
+			if !ok {
+				continue
+			}
+
+			key := makeKey(metricName, labels)
+			ts, ok := series[key]
+			if !ok {
+				ts = &prompb.TimeSeries{
+					Labels: make([]*prompb.Label, 0, len(labels)+1),
+				}
+				ts.Labels = append(ts.Labels, &prompb.Label{
+					Name:  prompb.MetricNameLabel,
+					Value: metricName,
+				})
+				ts.Labels = append(ts.Labels, labels...)
+				sortLabels(ts.Labels)
+				series[key] = ts
+				keys = append(keys, key)
+			}
+
+			ts.Samples = append(ts.Samples, &prompb.Sample{
+				Value:     value,
+				Timestamp: timestamp,
+			})
+		}
+	}
+
+	req := &prompb.WriteRequest{
+		Timeseries: make([]*prompb.TimeSeries, 0, len(series)),
+	}
+	for _, key := range keys {
+		ts := series[key]
+		// Samples of a series must be in time order.
+		sort.SliceStable(ts.Samples, func(i, j int) bool {
+			return ts.Samples[i].Timestamp < ts.Samples[j].Timestamp
+		})
+		req.Timeseries = append(req.Timeseries, ts)
+	}
+
+	if s.config.MetricSortOrder == prometheus.SortMetrics {
+		sort.Slice(req.Timeseries, func(i, j int) bool {
+			return lessLabels(req.Timeseries[i].Labels, req.Timeseries[j].Labels)
+		})
+	}
+
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, data), nil
+}
+
+func (s *Serializer) createLabels(metric telegraf.Metric) []*prompb.Label {
+	labels := make([]*prompb.Label, 0, len(metric.TagList()))
+	for _, tag := range metric.TagList() {
+		name, ok := prometheus.SanitizeLabelName(tag.Key)
+		if !ok {
+			continue
+		}
+
+		labels = append(labels, &prompb.Label{Name: name, Value: tag.Value})
+	}
+
+	if s.config.StringHandling != prometheus.StringAsLabel {
+		return labels
+	}
+
+	for _, field := range metric.FieldList() {
+		value, ok := field.Value.(string)
+		if !ok {
+			continue
+		}
+
+		name, ok := prometheus.SanitizeLabelName(field.Key)
+		if !ok {
+			continue
+		}
+
+		// If there is a tag with the same name as the string field, discard
+		// the field and use the tag instead.
+		if hasLabel(name, labels) {
+			continue
+		}
+
+		labels = append(labels, &prompb.Label{Name: name, Value: value})
+	}
+
+	return labels
+}
+
+func hasLabel(name string, labels []*prompb.Label) bool {
+	for _, label := range labels {
+		if name == label.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// makeKey returns the key of the series of the metric name and labels.
+func makeKey(metricName string, labels []*prompb.Label) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(metricName))
+	h.Write([]byte("\x00"))
+	for _, label := range labels {
+		h.Write([]byte(label.Name))
+		h.Write([]byte("\x00"))
+		h.Write([]byte(label.Value))
+		h.Write([]byte("\x00"))
+	}
+	return h.Sum64()
+}
+
+func sortLabels(labels []*prompb.Label) {
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+}
+
+func lessLabels(lhs, rhs []*prompb.Label) bool {
+	for i := 0; i < len(lhs) && i < len(rhs); i++ {
+		if lhs[i].Name != rhs[i].Name {
+			return lhs[i].Name < rhs[i].Name
+		}
+		if lhs[i].Value != rhs[i].Value {
+			return lhs[i].Value < rhs[i].Value
+		}
+	}
+	return len(lhs) < len(rhs)
+}
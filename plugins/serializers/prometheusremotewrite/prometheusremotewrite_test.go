@@ -0,0 +1,201 @@
+package prometheusremotewrite
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/prompb"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, buf []byte) *prompb.WriteRequest {
+	data, err := snappy.Decode(nil, buf)
+	require.NoError(t, err)
+
+	var req prompb.WriteRequest
+	require.NoError(t, proto.Unmarshal(data, &req))
+	return &req
+}
+
+func TestSerializeBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   FormatConfig
+		metrics  []telegraf.Metric
+		expected *prompb.WriteRequest
+	}{
+		{
+			name: "simple",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{
+						"host": "example.org",
+					},
+					map[string]interface{}{
+						"time_idle": 42.0,
+						"state":     "idle",
+					},
+					time.Unix(1, 0),
+				),
+			},
+			expected: &prompb.WriteRequest{
+				Timeseries: []*prompb.TimeSeries{
+					{
+						Labels: []*prompb.Label{
+							{Name: "__name__", Value: "cpu_time_idle"},
+							{Name: "host", Value: "example.org"},
+						},
+						Samples: []*prompb.Sample{{Value: 42, Timestamp: 1000}},
+					},
+				},
+			},
+		},
+		{
+			name: "series samples in time order",
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"prometheus",
+					map[string]string{"code": "400"},
+					map[string]interface{}{"http_requests_total": 4.0},
+					time.Unix(2, 0),
+					telegraf.Counter,
+				),
+				testutil.MustMetric(
+					"prometheus",
+					map[string]string{"code": "400"},
+					map[string]interface{}{"http_requests_total": 3.0},
+					time.Unix(1, 0),
+					telegraf.Counter,
+				),
+			},
+			expected: &prompb.WriteRequest{
+				Timeseries: []*prompb.TimeSeries{
+					{
+						Labels: []*prompb.Label{
+							{Name: "__name__", Value: "http_requests_total"},
+							{Name: "code", Value: "400"},
+						},
+						Samples: []*prompb.Sample{
+							{Value: 3, Timestamp: 1000},
+							{Value: 4, Timestamp: 2000},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "histogram",
+			config: FormatConfig{
+				MetricSortOrder: prometheus.SortMetrics,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"prometheus",
+					map[string]string{},
+					map[string]interface{}{
+						"http_request_duration_seconds_sum":   53423.0,
+						"http_request_duration_seconds_count": 144320.0,
+					},
+					time.Unix(0, 0),
+					telegraf.Histogram,
+				),
+				testutil.MustMetric(
+					"prometheus",
+					map[string]string{"le": "0.5"},
+					map[string]interface{}{
+						"http_request_duration_seconds_bucket": 129389.0,
+					},
+					time.Unix(0, 0),
+					telegraf.Histogram,
+				),
+			},
+			expected: &prompb.WriteRequest{
+				Timeseries: []*prompb.TimeSeries{
+					{
+						Labels: []*prompb.Label{
+							{Name: "__name__", Value: "http_request_duration_seconds_bucket"},
+							{Name: "le", Value: "0.5"},
+						},
+						Samples: []*prompb.Sample{{Value: 129389}},
+					},
+					{
+						Labels: []*prompb.Label{
+							{Name: "__name__", Value: "http_request_duration_seconds_count"},
+						},
+						Samples: []*prompb.Sample{{Value: 144320}},
+					},
+					{
+						Labels: []*prompb.Label{
+							{Name: "__name__", Value: "http_request_duration_seconds_sum"},
+						},
+						Samples: []*prompb.Sample{{Value: 53423}},
+					},
+				},
+			},
+		},
+		{
+			name: "string as label",
+			config: FormatConfig{
+				StringHandling: prometheus.StringAsLabel,
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"host": "example.org"},
+					map[string]interface{}{
+						"time_idle": 42.0,
+						"cpu":       "cpu0",
+						"host":      "discarded",
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: &prompb.WriteRequest{
+				Timeseries: []*prompb.TimeSeries{
+					{
+						Labels: []*prompb.Label{
+							{Name: "__name__", Value: "cpu_time_idle"},
+							{Name: "cpu", Value: "cpu0"},
+							{Name: "host", Value: "example.org"},
+						},
+						Samples: []*prompb.Sample{{Value: 42}},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.config)
+			require.NoError(t, err)
+			actual, err := s.SerializeBatch(tt.metrics)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, decode(t, actual))
+		})
+	}
+}
+
+// TestSerializeWire checks the encoding is the one of Prometheus.
+func TestSerializeWire(t *testing.T) {
+	s, err := NewSerializer(FormatConfig{})
+	require.NoError(t, err)
+
+	actual, err := s.Serialize(testutil.MustMetric(
+		"prometheus",
+		map[string]string{"job": "node"},
+		map[string]interface{}{"up": 1.0},
+		time.Unix(1257894000, 0),
+	))
+	require.NoError(t, err)
+
+	data, err := snappy.Decode(nil, actual)
+	require.NoError(t, err)
+	require.Equal(t, "0a2f0a0e0a085f5f6e616d655f5f120275700a0b0a036a6f6212046e6f6465121009000000000000f03f1080ebcc82ce24", hex.EncodeToString(data))
+}
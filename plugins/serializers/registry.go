@@ -6,11 +6,13 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/serializers/carbon2"
+	"github.com/influxdata/telegraf/plugins/serializers/csv"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
 	"github.com/influxdata/telegraf/plugins/serializers/nowmetric"
 	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheusremotewrite"
 	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
 	"github.com/influxdata/telegraf/plugins/serializers/wavefront"
 )
@@ -42,6 +44,15 @@ type Serializer interface {
 	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
 }
 
+// HeaderSerializer is implemented by serializers of formats with a header at
+// the start of each file.  Outputs writing files add the header to files
+// before the first serialized metrics.
+type HeaderSerializer interface {
+	// SerializeHeader returns the header of a file containing the metrics,
+	// or nil if the format has no header.
+	SerializeHeader(metrics []telegraf.Metric) ([]byte, error)
+}
+
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
@@ -94,6 +105,22 @@ type Config struct {
 	// Output string fields as metric labels; when false string fields are
 	// discarded.
 	PrometheusStringAsLabel bool `toml:"prometheus_string_as_label"`
+
+	// Columns of the csv format in order, by default the columns of the
+	// tags and fields of each measurement.
+	CSVColumns []string `toml:"csv_columns"`
+
+	// Separator of the csv columns.
+	CSVSeparator string `toml:"csv_separator"`
+
+	// Write a header row with the column names at the start of each file.
+	CSVHeader bool `toml:"csv_header"`
+
+	// Format of the csv timestamp column.
+	CSVTimestampFormat string `toml:"csv_timestamp_format"`
+
+	// Pivot the fields of each measurement into columns.
+	CSVPivot bool `toml:"csv_pivot"`
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -117,6 +144,10 @@ func NewSerializer(config *Config) (Serializer, error) {
 		serializer, err = NewWavefrontSerializer(config.Prefix, config.WavefrontUseStrict, config.WavefrontSourceOverride)
 	case "prometheus":
 		serializer, err = NewPrometheusSerializer(config)
+	case "prometheusremotewrite":
+		serializer, err = NewPrometheusRemoteWriteSerializer(config)
+	case "csv":
+		serializer, err = NewCSVSerializer(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -146,6 +177,33 @@ func NewPrometheusSerializer(config *Config) (Serializer, error) {
 	})
 }
 
+func NewPrometheusRemoteWriteSerializer(config *Config) (Serializer, error) {
+	sortMetrics := prometheus.NoSortMetrics
+	if config.PrometheusSortMetrics {
+		sortMetrics = prometheus.SortMetrics
+	}
+
+	stringAsLabels := prometheus.DiscardStrings
+	if config.PrometheusStringAsLabel {
+		stringAsLabels = prometheus.StringAsLabel
+	}
+
+	return prometheusremotewrite.NewSerializer(prometheusremotewrite.FormatConfig{
+		MetricSortOrder: sortMetrics,
+		StringHandling:  stringAsLabels,
+	})
+}
+
+func NewCSVSerializer(config *Config) (Serializer, error) {
+	return csv.NewSerializer(csv.Config{
+		Columns:         config.CSVColumns,
+		Separator:       config.CSVSeparator,
+		Header:          config.CSVHeader,
+		TimestampFormat: config.CSVTimestampFormat,
+		Pivot:           config.CSVPivot,
+	})
+}
+
 func NewWavefrontSerializer(prefix string, useStrict bool, sourceOverride []string) (Serializer, error) {
 	return wavefront.NewSerializer(prefix, useStrict, sourceOverride)
 }
@@ -10,3 +10,31 @@ type Processor interface {
 	// Apply the filter to the given metric.
 	Apply(in ...Metric) []Metric
 }
+
+// StreamingProcessor is a processor that can take in a stream of messages,
+// potentially holding them or emitting new metrics at any time.
+//
+// Unlike a Processor, metrics are not returned but passed downstream with the
+// accumulator, which may be done asynchronously.
+type StreamingProcessor interface {
+	// SampleConfig returns the default configuration of the Processor
+	SampleConfig() string
+
+	// Description returns a one-sentence description on the Processor
+	Description() string
+
+	// Start is called once when the plugin starts; it is only called once per
+	// plugin instance, and never in parallel.  The accumulator can be used to
+	// emit metrics at any time until Stop returns.
+	Start(acc Accumulator) error
+
+	// Add is called for each metric to be processed.  The processor takes
+	// ownership of the metric and must either pass it downstream with the
+	// accumulator or drop it.
+	Add(metric Metric, acc Accumulator) error
+
+	// Stop gives you a callback to free resources and to emit any metrics
+	// still held.  Add is not called after Stop and the accumulator must not
+	// be used after Stop returns.
+	Stop() error
+}
@@ -0,0 +1,12 @@
+package telegraf
+
+// SecretStore is a source of secrets, such as passwords and tokens, that can
+// be referenced from the configuration of other plugins.
+type SecretStore interface {
+	// Description returns a one-sentence description on the SecretStore
+	Description() string
+	// SampleConfig returns the default configuration of the SecretStore
+	SampleConfig() string
+	// Get returns the value of the secret with the given key.
+	Get(key string) (string, error)
+}
@@ -109,6 +109,24 @@ func Metrics() []telegraf.Metric {
 	return metrics
 }
 
+// Values returns the current value of each stat registered with the given
+// measurement and tags, keyed by field name.  Unlike Metrics, reading the
+// values does not clear the average of timing stats.
+func Values(measurement string, tags map[string]string) map[string]int64 {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	values := make(map[string]int64)
+	for field, stat := range registry.stats[key("internal_"+measurement, tags)] {
+		if ts, ok := stat.(*timingStat); ok {
+			values[field] = ts.peek()
+			continue
+		}
+		values[field] = stat.Get()
+	}
+	return values
+}
+
 type rgstry struct {
 	stats map[uint64]map[string]Stat
 	mu    sync.Mutex
@@ -211,3 +211,24 @@ func TestRegisterCopy(t *testing.T) {
 	tags["new"] = "value"
 	require.NotEqual(t, tags, stat.Tags())
 }
+
+func TestValues(t *testing.T) {
+	testLock.Lock()
+	defer testCleanup()
+
+	s := Register("test", "count", map[string]string{"test": "foo"})
+	ts := RegisterTiming("test", "time_ns", map[string]string{"test": "foo"})
+	Register("test", "count", map[string]string{"test": "bar"}).Set(42)
+
+	s.Incr(3)
+	ts.Incr(10)
+	ts.Incr(20)
+
+	expected := map[string]int64{"count": 3, "time_ns": 15}
+	require.Equal(t, expected, Values("test", map[string]string{"test": "foo"}))
+
+	// Reading the values does not clear the timing average.
+	require.Equal(t, int64(15), ts.Get())
+
+	require.Empty(t, Values("test", map[string]string{"test": "baz"}))
+}
@@ -57,3 +57,13 @@ func (s *timingStat) Tags() map[string]string {
 	}
 	return m
 }
+
+// peek returns the value Get would return without clearing the average.
+func (s *timingStat) peek() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count > 0 {
+		return s.v / s.count
+	}
+	return s.prev
+}